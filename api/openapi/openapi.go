@@ -0,0 +1,27 @@
+// Package openapi содержит спецификацию HTTP API в формате OpenAPI 3.
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Spec — встроенный в бинарник документ openapi.json.
+//
+//go:embed openapi.json
+var Spec []byte
+
+// Load разбирает Spec и проверяет, что документ корректен.
+func Load() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(Spec)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: parse spec: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("openapi: invalid spec: %w", err)
+	}
+	return doc, nil
+}
@@ -0,0 +1,16 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	doc, err := Load()
+	require.NoError(t, err)
+
+	assert.NotNil(t, doc.Paths.Find("/api"))
+	assert.NotNil(t, doc.Paths.Find("/api/openapi.json"))
+}
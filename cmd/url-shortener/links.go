@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/app"
+	"github.com/Rasulikus/url-shortener/internal/config"
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	urlService "github.com/Rasulikus/url-shortener/internal/service/url"
+	"github.com/Rasulikus/url-shortener/internal/utils/linkio"
+	"github.com/Rasulikus/url-shortener/internal/utils/logger"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// linkView — представление ссылки в выводе CLI.
+type linkView struct {
+	ID           int64     `json:"id"`
+	Domain       string    `json:"domain,omitempty"`
+	Alias        string    `json:"alias"`
+	ShortURL     string    `json:"short_url"`
+	LongURL      string    `json:"long_url"`
+	Disabled     bool      `json:"disabled"`
+	CreatedAt    time.Time `json:"created_at"`
+	Interstitial bool      `json:"interstitial"`
+
+	Meta   *model.Metadata `json:"meta,omitempty"`
+	Health *model.Health   `json:"health,omitempty"`
+}
+
+func newLinkView(s *urlService.Service, u *model.URL) linkView {
+	return linkView{
+		ID:           u.ID,
+		Domain:       u.Domain,
+		Alias:        u.Alias,
+		ShortURL:     s.ShortURL(u.Domain, u.Alias),
+		LongURL:      u.LongURL,
+		Disabled:     u.Disabled,
+		CreatedAt:    u.CreatedAt,
+		Interstitial: u.Interstitial,
+		Meta:         u.Meta,
+		Health:       u.Health,
+	}
+}
+
+// healthText — состояние ссылки по последней проверке для табличного вывода.
+func healthText(h *model.Health) string {
+	switch {
+	case h == nil:
+		return "-"
+	case h.Broken:
+		return "broken"
+	case h.Failures > 0:
+		return "failing"
+	default:
+		return "ok"
+	}
+}
+
+func runLinks(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing links command\n\n%s", usage)
+	}
+	cmd, args := args[0], args[1:]
+
+	fs := flag.NewFlagSet("links "+cmd, flag.ContinueOnError)
+	format := fs.String("o", formatTable, "output format: table|json")
+	domain := fs.String("d", "", "create, resolve, disable, enable, interstitial, delete: short domain, default is the BASE_URL domain")
+	after := fs.Int64("after", 0, "list: return links with id greater than this")
+	limit := fs.Int("limit", 50, "list: maximum number of links")
+	broken := fs.Bool("broken", false, "list: only links marked broken by the health check")
+	data := fs.String("f", string(linkio.FormatJSONL), "export, import: data format: jsonl|csv")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != formatTable && *format != formatJSON {
+		return fmt.Errorf("unknown output format %q", *format)
+	}
+	dataFormat, err := linkio.ParseFormat(*data)
+	if err != nil {
+		return err
+	}
+
+	// Логи пишем в stderr, чтобы не смешивать их с выводом команды
+	if err := logger.Init(logger.Config{Level: cfg.LogLevel, Output: os.Stderr}); err != nil {
+		return err
+	}
+
+	deps, err := app.NewDeps(cfg)
+	if err != nil {
+		return err
+	}
+	defer deps.Close()
+
+	s := deps.URLService
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	switch cmd {
+	case "create":
+		longURL, err := oneArg(fs, "long_url")
+		if err != nil {
+			return err
+		}
+		u, err := s.CreateOrGetURL(ctx, *domain, longURL)
+		if err != nil {
+			return err
+		}
+		return printLinks(os.Stdout, *format, []linkView{newLinkView(s, u)})
+	case "resolve":
+		alias, err := oneArg(fs, "alias")
+		if err != nil {
+			return err
+		}
+		u, err := s.Resolve(ctx, *domain, alias)
+		if err != nil {
+			return err
+		}
+		return printLinks(os.Stdout, *format, []linkView{newLinkView(s, u)})
+	case "list":
+		urls, err := s.List(ctx, repository.ListFilter{AfterID: *after, Limit: *limit, Broken: *broken})
+		if err != nil {
+			return err
+		}
+		views := make([]linkView, 0, len(urls))
+		for _, u := range urls {
+			views = append(views, newLinkView(s, u))
+		}
+		return printLinks(os.Stdout, *format, views)
+	case "disable", "enable":
+		alias, err := oneArg(fs, "alias")
+		if err != nil {
+			return err
+		}
+		return s.SetDisabled(ctx, *domain, alias, cmd == "disable")
+	case "interstitial":
+		if fs.NArg() != 2 || (fs.Arg(1) != "on" && fs.Arg(1) != "off") {
+			return fmt.Errorf("%s: expected arguments <alias> on|off", fs.Name())
+		}
+		return s.SetInterstitial(ctx, *domain, fs.Arg(0), fs.Arg(1) == "on")
+	case "delete":
+		alias, err := oneArg(fs, "alias")
+		if err != nil {
+			return err
+		}
+		return s.Delete(ctx, *domain, alias)
+	case "export":
+		enc := linkio.NewEncoder(os.Stdout, dataFormat)
+		if err := s.Export(ctx, enc.Encode); err != nil {
+			return err
+		}
+		return enc.Flush()
+	case "import":
+		if fs.NArg() > 1 {
+			return fmt.Errorf("%s: expected at most one argument [file]", fs.Name())
+		}
+		return importLinks(ctx, os.Stdout, s, *format, fs.Arg(0), dataFormat)
+	default:
+		return fmt.Errorf("unknown links command %q\n\n%s", cmd, usage)
+	}
+}
+
+func oneArg(fs *flag.FlagSet, name string) (string, error) {
+	if fs.NArg() != 1 {
+		return "", fmt.Errorf("%s: expected exactly one argument <%s>", fs.Name(), name)
+	}
+	return fs.Arg(0), nil
+}
+
+func printLinks(w io.Writer, format string, links []linkView) error {
+	if format == formatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if len(links) == 1 {
+			return enc.Encode(links[0])
+		}
+		return enc.Encode(links)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tALIAS\tSHORT_URL\tLONG_URL\tDISABLED\tINTERSTITIAL\tHEALTH\tCREATED_AT")
+	for _, l := range links {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			l.ID, l.Alias, l.ShortURL, l.LongURL, strconv.FormatBool(l.Disabled), strconv.FormatBool(l.Interstitial),
+			healthText(l.Health), l.CreatedAt.Format(time.RFC3339))
+	}
+	return tw.Flush()
+}
+
+// importLinks загружает ссылки из файла path или из stdin, если path пуст.
+func importLinks(ctx context.Context, w io.Writer, s *urlService.Service, format string, path string, f linkio.Format) error {
+	in := io.Reader(os.Stdin)
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+
+	res, err := s.Import(ctx, linkio.NewDecoder(in, f))
+	if err != nil {
+		return err
+	}
+
+	if format == formatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+
+	fmt.Fprintf(w, "total: %d, imported: %d, skipped: %d, invalid: %d\n", res.Total, res.Imported, res.Skipped, res.Invalid)
+	for _, e := range res.Errors {
+		fmt.Fprintf(w, "line %d: %s\n", e.Line, e.Error)
+	}
+	return nil
+}
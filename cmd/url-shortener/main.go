@@ -1,28 +1,165 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/Rasulikus/url-shortener/internal/app"
 	"github.com/Rasulikus/url-shortener/internal/config"
 	"github.com/rs/zerolog/log"
 )
 
+const usage = `usage:
+  url-shortener [flags] [command]
+
+  url-shortener [serve]              запустить HTTP-сервер (и gRPC, если GRPC_ENABLED=true)
+  url-shortener migrate up           применить все миграции
+  url-shortener migrate down [N]     откатить N последних миграций (по умолчанию 1)
+  url-shortener migrate status       показать версию схемы и ожидающие миграции
+
+  url-shortener links create [-d DOMAIN] [-o table|json] <long_url>
+                                                           создать ссылку или вернуть существующую
+  url-shortener links resolve [-d DOMAIN] [-o table|json] <alias>
+                                                           показать ссылку по алиасу
+  url-shortener links list [-o table|json] [-after ID] [-limit N] [-broken]
+                                                           список ссылок всех доменов по возрастанию ID,
+                                                           -broken — только неработающие
+  url-shortener links disable [-d DOMAIN] <alias>          отключить ссылку
+  url-shortener links enable [-d DOMAIN] <alias>           включить ссылку
+  url-shortener links interstitial [-d DOMAIN] <alias> on|off
+                                                           всегда показывать страницу предпросмотра
+                                                           вместо редиректа или перестать
+  url-shortener links delete [-d DOMAIN] <alias>           удалить ссылку
+  url-shortener links export [-f jsonl|csv]                выгрузить все ссылки в stdout
+  url-shortener links import [-f jsonl|csv] [-o table|json] [file]
+                                                           загрузить ссылки из файла или stdin
+  -d DOMAIN — короткий домен из DOMAINS, по умолчанию домен из BASE_URL
+
+flags (указываются до команды, перекрывают файл конфигурации и окружение):
+  --config FILE                      файл конфигурации YAML или TOML
+  --print-config                     вывести итоговую конфигурацию (секреты скрыты) и выйти
+  --<параметр> VALUE                 любой параметр, например --http-port 8080 или --db-host db;
+                                     полный список — url-shortener help
+`
+
 func main() {
-	cfg, err := config.New()
+	cfg, cmdline, err := config.Load(os.Args[1:])
 	if err != nil {
-		log.Fatal().Err(err).Msg("failed to create config")
+		if errors.Is(err, flag.ErrHelp) {
+			printHelp()
+			return
+		}
+		// Ошибки конфигурации выводятся списком, по одной на строку
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		os.Exit(2)
+	}
+
+	if cmdline.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatal().Err(err).Msg("failed to print config")
+		}
+		return
 	}
 
+	cmd, args := "serve", cmdline.Args
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
+	}
+
+	switch cmd {
+	case "serve":
+		serve(cfg)
+	case "migrate":
+		if err := runMigrate(cfg, args); err != nil {
+			log.Fatal().Err(err).Msg("migrate failed")
+		}
+	case "links":
+		if err := runLinks(cfg, args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return
+			}
+			log.Fatal().Err(err).Msg("links command failed")
+		}
+	case "help":
+		printHelp()
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
+	}
+}
+
+func printHelp() {
+	fmt.Print(usage)
+	fmt.Println("\nall flags:")
+	config.PrintFlags(os.Stdout)
+}
+
+func serve(cfg *config.Config) {
+	handler, grpcServer, rt, closeDeps := app.App(cfg)
+	defer closeDeps()
+
 	server := http.Server{
 		Addr:    fmt.Sprintf("%s:%s", cfg.HTTP.Host, cfg.HTTP.Port),
-		Handler: app.App(cfg),
+		Handler: handler,
 	}
 
-	log.Info().Msgf("starting server on %s", server.Addr)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go rt.Watch(ctx, func() (*config.Config, error) {
+		cfg, _, err := config.Load(os.Args[1:])
+		return cfg, err
+	})
 
-	if err := server.ListenAndServe(); err != nil {
+	errCh := make(chan error, 2)
+	go func() {
+		log.Info().Msgf("starting server on %s", server.Addr)
+		errCh <- server.ListenAndServe()
+	}()
+	if grpcServer != nil {
+		addr := fmt.Sprintf("%s:%s", cfg.GRPC.Host, cfg.GRPC.Port)
+		go func() {
+			lis, err := net.Listen("tcp", addr)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			log.Info().Msgf("starting grpc server on %s", addr)
+			errCh <- grpcServer.Serve(lis)
+		}()
+	}
+
+	select {
+	case err := <-errCh:
+		closeDeps()
 		log.Fatal().Err(err).Msg("failed to start server")
+	case <-ctx.Done():
+	}
+
+	log.Info().Msg("shutting down server")
+
+	// Хранилище закрывается после того, как завершатся текущие запросы
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.WriteTimeout)
+	defer cancel()
+
+	if grpcServer != nil {
+		go func() {
+			// Вызовы, не успевшие завершиться вместе с HTTP, обрываются
+			<-shutdownCtx.Done()
+			grpcServer.Stop()
+		}()
+	}
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("failed to shut down server gracefully")
+	}
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
 	}
 }
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/config"
+	"github.com/Rasulikus/url-shortener/internal/migrate"
+	"github.com/Rasulikus/url-shortener/internal/repository/postgres"
+	"github.com/Rasulikus/url-shortener/migrations"
+)
+
+func runMigrate(cfg *config.Config, args []string) error {
+	if cfg.DB == nil {
+		return fmt.Errorf("migrate requires STORAGE=%s", config.StoragePostgres)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate command\n\n%s", usage)
+	}
+
+	pool, err := postgres.NewPool(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	m, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	switch args[0] {
+	case "up":
+		err = m.Up(ctx)
+		if errors.Is(err, migrate.ErrNoChange) {
+			fmt.Println("no change")
+			return nil
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid steps %q: %w", args[1], err)
+			}
+		}
+		return m.Down(ctx, steps)
+	case "status":
+		st, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printStatus(st)
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q\n\n%s", args[0], usage)
+	}
+}
+
+func printStatus(st *migrate.Status) {
+	fmt.Printf("version: %d", st.Version)
+	if st.Dirty {
+		fmt.Print(" (dirty)")
+	}
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE")
+	for _, mg := range st.Applied {
+		fmt.Fprintf(w, "%d\t%s\tapplied\n", mg.Version, mg.Name)
+	}
+	for _, mg := range st.Pending {
+		fmt.Fprintf(w, "%d\t%s\tpending\n", mg.Version, mg.Name)
+	}
+	_ = w.Flush()
+}
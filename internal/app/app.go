@@ -2,20 +2,39 @@ package app
 
 import (
 	"context"
+	"errors"
+	"expvar"
+	"fmt"
 	"time"
 
+	"github.com/Rasulikus/url-shortener/api/openapi"
 	"github.com/Rasulikus/url-shortener/internal/config"
+	"github.com/Rasulikus/url-shortener/internal/migrate"
+	"github.com/Rasulikus/url-shortener/internal/repository/bolt"
 	"github.com/Rasulikus/url-shortener/internal/repository/memory"
 	"github.com/Rasulikus/url-shortener/internal/repository/postgres"
+	"github.com/Rasulikus/url-shortener/internal/repository/sqlite"
 	urlService "github.com/Rasulikus/url-shortener/internal/service/url"
+	"github.com/Rasulikus/url-shortener/internal/service/webhook"
+	grpcapi "github.com/Rasulikus/url-shortener/internal/transport/grpc"
 	"github.com/Rasulikus/url-shortener/internal/transport/http"
+	"github.com/Rasulikus/url-shortener/internal/transport/http/pages"
+	"github.com/Rasulikus/url-shortener/internal/utils/domains"
 	"github.com/Rasulikus/url-shortener/internal/utils/generator"
+	"github.com/Rasulikus/url-shortener/internal/utils/linkcheck"
 	"github.com/Rasulikus/url-shortener/internal/utils/logger"
+	"github.com/Rasulikus/url-shortener/internal/utils/unfurl"
+	"github.com/Rasulikus/url-shortener/migrations"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
 )
 
-func App(cfg *config.Config) *gin.Engine {
+// App собирает HTTP-обработчик и, если он включён, gRPC-сервер (иначе nil). Runtime
+// применяет перезагруженную конфигурацию, возвращаемая функция освобождает хранилище
+// и должна вызываться после остановки серверов.
+func App(cfg *config.Config) (*gin.Engine, *grpc.Server, *Runtime, func()) {
 	err := logger.Init(logger.Config{
 		Level: cfg.LogLevel,
 	})
@@ -23,57 +42,337 @@ func App(cfg *config.Config) *gin.Engine {
 		log.Fatal().Err(err).Msg("failed to initialize logger")
 	}
 
-	var urlRepo urlService.URLRepository
+	deps, err := NewDeps(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize dependencies")
+	}
+
+	urlServ := deps.URLService
+	rt := newRuntime(cfg, urlServ)
+
+	// Фоновые воркеры запускает только сервер: CLI не должен ждать их при выходе.
+	// Они останавливаются раньше, чем закрывается хранилище.
+	if cfg.Unfurl.Enabled {
+		fetcher := unfurl.New(unfurl.Config{
+			Timeout:      cfg.Unfurl.Timeout,
+			MaxBytes:     cfg.Unfurl.MaxBytes,
+			AllowPrivate: cfg.Unfurl.AllowPrivate,
+		})
+		deps.closers = append(deps.closers, urlServ.StartUnfurl(fetcher, urlService.UnfurlConfig{
+			Workers:   cfg.Unfurl.Workers,
+			QueueSize: cfg.Unfurl.QueueSize,
+		}))
+	}
+	if cfg.HealthCheck.Enabled {
+		checker := linkcheck.New(linkcheck.Config{
+			Timeout:      cfg.HealthCheck.Timeout,
+			HostDelay:    cfg.HealthCheck.HostDelay,
+			AllowPrivate: cfg.HealthCheck.AllowPrivate,
+		})
+		deps.closers = append(deps.closers, urlServ.StartHealthCheck(checker, urlService.HealthConfig{
+			Interval:         cfg.HealthCheck.Interval,
+			Workers:          cfg.HealthCheck.Workers,
+			FailureThreshold: cfg.HealthCheck.FailureThreshold,
+		}))
+	}
+	// Рассылка работает и без подписок, иначе outbox рос бы бесконечно
+	deps.closers = append(deps.closers, deps.Webhooks.Start())
+
+	publishMetrics("url_service", func() any { return urlServ.Metrics() })
+	publishMetrics("webhooks", func() any { return deps.Webhooks.Metrics() })
+
+	errorPages, err := pages.New(cfg.PagesDir)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load error pages")
+	}
+
+	urlHandler := http.NewURLHandler(urlServ, deps.Domains, errorPages)
+
+	r := gin.Default()
+	r.Use(http.RequestID())
+	if cfg.DevMode {
+		doc, err := openapi.Load()
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to load OpenAPI spec")
+		}
+		r.Use(http.ValidateOpenAPI(doc))
+	}
+
+	r.GET("/", urlHandler.Root)
+	r.GET("/:alias", urlHandler.Redirect)
+
+	urlApi := r.Group("/api")
+	{
+		urlApi.POST("", urlHandler.Create)
+		urlApi.GET("/openapi.json", http.OpenAPISpec(openapi.Spec))
+		urlApi.GET("/:alias", urlHandler.GetLongURLByAlias)
+	}
+
+	if cfg.AdminToken != "" {
+		adminHandler := http.NewAdminHandler(urlServ, cfg.AdminImportMaxBytes)
+		webhookHandler := http.NewWebhookHandler(deps.Webhooks)
+
+		admin := r.Group("/admin", http.AdminAuth(func() string { return rt.Config().AdminToken }))
+		{
+			admin.GET("/export", adminHandler.Export)
+			admin.POST("/import", adminHandler.Import)
+			admin.GET("/webhooks", webhookHandler.List)
+			admin.GET("/webhooks/deliveries", webhookHandler.Deliveries)
+			admin.POST("/webhooks/deliveries/:id/replay", webhookHandler.Replay)
+			// expvar публикует cmdline, а в нём могут быть секреты из флагов
+			admin.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+		}
+	}
+
+	var grpcServer *grpc.Server
+	if cfg.GRPC.Enabled {
+		grpcServer = grpcapi.New(urlServ, func() string { return rt.Config().AdminToken })
+	}
+
+	return r, grpcServer, rt, deps.Close
+}
+
+// Deps — хранилище и сервисы, общие для HTTP-сервера и CLI.
+type Deps struct {
+	URLRepo    urlService.URLRepository
+	URLService *urlService.Service
+	Domains    *domains.Registry
+	// Webhooks рассылает события из outbox того же хранилища.
+	Webhooks *webhook.Service
+
+	closers []func()
+}
+
+// Close освобождает ресурсы, например пул соединений с БД.
+func (d *Deps) Close() {
+	for i := len(d.closers) - 1; i >= 0; i-- {
+		d.closers[i]()
+	}
+	d.closers = nil
+}
+
+// localIDBlockSize — сколько ID за раз резервирует хранилище в процессе. Резерв
+// не требует обращения к сети, поэтому пачка небольшая.
+const localIDBlockSize = 100
+
+// NewDeps создаёт хранилище, генератор алиасов и сервис по конфигурации.
+func NewDeps(cfg *config.Config) (*Deps, error) {
+	var (
+		deps  = new(Deps)
+		ids   generator.IDAllocator
+		hooks webhook.Repository
+		err   error
+	)
+
+	deps.Domains, err = domains.New(cfg.BaseURL, cfg.DefaultDomain(), cfg.Domains)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize domains: %w", err)
+	}
 
 	switch cfg.Storage {
 	case config.StoragePostgres:
 		pool, err := postgres.NewPool(cfg.DB)
 		if err != nil {
-			log.Fatal().Err(err).Msg("failed to initialize postgres pool")
+			return nil, fmt.Errorf("failed to initialize postgres pool: %w", err)
+		}
+		deps.closers = append(deps.closers, pool.Close)
+
+		if cfg.DB.AutoMigrate {
+			if err := runMigrations(pool); err != nil {
+				deps.Close()
+				return nil, fmt.Errorf("failed to apply migrations: %w", err)
+			}
+		}
+
+		var pgRepo *postgres.Repo
+		if len(cfg.DB.ReplicaDSNs) > 0 {
+			replicas, err := postgres.NewReplicas(pool, cfg.DB)
+			if err != nil {
+				deps.Close()
+				return nil, fmt.Errorf("failed to initialize postgres replicas: %w", err)
+			}
+			deps.closers = append(deps.closers, replicas.Close)
+			pgRepo, err = postgres.NewReplicatedRepository(pool, replicas)
+		} else {
+			pgRepo, err = postgres.NewRepository(pool)
+		}
+		if err != nil {
+			deps.Close()
+			return nil, fmt.Errorf("failed to initialize postgres repository: %w", err)
 		}
+		deps.URLRepo, hooks = pgRepo, pgRepo
 
-		urlRepo, err = postgres.NewRepository(pool)
+		// ID резервируются пачками из последовательности БД, чтобы несколько реплик не выдавали одинаковых алиасов
+		ids, err = generator.NewBlockAllocator(pgRepo, cfg.DB.IDBlockSize)
 		if err != nil {
-			log.Fatal().Err(err).Msg("failed to initialize postgres repository")
+			deps.Close()
+			return nil, fmt.Errorf("failed to initialize id allocator: %w", err)
 		}
 	case config.StorageMemory:
-		m := memory.New()
+		m, err := memory.Open(memory.Config{
+			DataDir:          cfg.Memory.DataDir,
+			Fsync:            memory.FsyncPolicy(cfg.Memory.Fsync),
+			FsyncInterval:    cfg.Memory.FsyncInterval,
+			SnapshotInterval: cfg.Memory.SnapshotInterval,
+			LockTimeout:      cfg.Memory.LockTimeout,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to open memory storage: %w", err)
+		}
+		deps.closers = append(deps.closers, func() {
+			if err := m.Close(); err != nil {
+				log.Error().Err(err).Msg("failed to close memory storage")
+			}
+		})
 
-		urlRepo, err = memory.NewRepository(m)
+		memRepo, err := memory.NewRepository(m)
 		if err != nil {
-			log.Fatal().Err(err).Msg("failed to initialize memory repository")
+			deps.Close()
+			return nil, fmt.Errorf("failed to initialize memory repository: %w", err)
 		}
-	}
+		deps.URLRepo, hooks = memRepo, memRepo
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+		// ID берутся из того же счётчика, что у Import, иначе импорт займёт уже выданные ID
+		ids, err = generator.NewBlockAllocator(memRepo, localIDBlockSize)
+		if err != nil {
+			deps.Close()
+			return nil, fmt.Errorf("failed to initialize id allocator: %w", err)
+		}
+	case config.StorageBolt:
+		db, err := bolt.Open(bolt.Config{
+			Path:    cfg.Bolt.Path,
+			Timeout: cfg.Bolt.Timeout,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to open bolt storage: %w", err)
+		}
+		deps.closers = append(deps.closers, func() {
+			if err := db.Close(); err != nil {
+				log.Error().Err(err).Msg("failed to close bolt storage")
+			}
+		})
+
+		boltRepo, err := bolt.NewRepository(db)
+		if err != nil {
+			deps.Close()
+			return nil, fmt.Errorf("failed to initialize bolt repository: %w", err)
+		}
+		deps.URLRepo, hooks = boltRepo, boltRepo
+
+		// ID резервируются в последовательности бакета, из которой берёт ID и Import
+		ids, err = generator.NewBlockAllocator(boltRepo, localIDBlockSize)
+		if err != nil {
+			deps.Close()
+			return nil, fmt.Errorf("failed to initialize id allocator: %w", err)
+		}
+	case config.StorageSQLite:
+		db, err := sqlite.Open(sqlite.Config{
+			Path:        cfg.SQLite.Path,
+			BusyTimeout: cfg.SQLite.BusyTimeout,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to open sqlite storage: %w", err)
+		}
+		deps.closers = append(deps.closers, func() {
+			if err := db.Close(); err != nil {
+				log.Error().Err(err).Msg("failed to close sqlite storage")
+			}
+		})
+
+		sqliteRepo, err := sqlite.NewRepository(db)
+		if err != nil {
+			deps.Close()
+			return nil, fmt.Errorf("failed to initialize sqlite repository: %w", err)
+		}
+		deps.URLRepo, hooks = sqliteRepo, sqliteRepo
 
-	nextID, err := urlRepo.GetLastID(ctx)
+		// Файл базы может открыть и CLI, поэтому ID резервируются в базе, как в Postgres
+		ids, err = generator.NewBlockAllocator(sqliteRepo, cfg.SQLite.IDBlockSize)
+		if err != nil {
+			deps.Close()
+			return nil, fmt.Errorf("failed to initialize id allocator: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown storage: %s", cfg.Storage)
+	}
+
+	gen, err := newAliasGenerator(cfg.Alias, ids)
 	if err != nil {
-		log.Fatal().Err(err).Msg("failed to initialize next id")
+		deps.Close()
+		return nil, fmt.Errorf("failed to initialize alias generator: %w", err)
 	}
 
-	gen, err := generator.NewCounter(nextID, cfg.AliasSecret, generator.DefaultLength)
+	log.Info().
+		Str("strategy", string(cfg.Alias.Strategy)).
+		Int("alias_length", cfg.Alias.Length).
+		Msg("alias generator initialized")
+
+	deps.URLService, err = urlService.NewService(deps.Domains, gen, deps.URLRepo, urlService.RetryPolicy{
+		MaxAttempts: cfg.Alias.MaxAttempts,
+		Backoff:     cfg.Alias.RetryBackoff,
+	})
 	if err != nil {
-		log.Fatal().Err(err).Msg("failed to initialize alias generator")
+		deps.Close()
+		return nil, fmt.Errorf("failed to initialize url service: %w", err)
 	}
+	deps.URLService.SetClickThresholds(cfg.Webhook.ClickThresholds)
 
-	urlServ, err := urlService.NewService(cfg.BaseURL, gen, urlRepo)
+	deps.Webhooks, err = webhook.NewService(hooks, webhook.Config{
+		Endpoints:    cfg.Webhook.Endpoints,
+		Secret:       cfg.Webhook.Secret,
+		PollInterval: cfg.Webhook.PollInterval,
+		Workers:      cfg.Webhook.Workers,
+		Timeout:      cfg.Webhook.Timeout,
+		MaxAttempts:  cfg.Webhook.MaxAttempts,
+		Backoff:      cfg.Webhook.RetryBackoff,
+	})
 	if err != nil {
-		log.Fatal().Err(err).Msg("failed to initialize url service")
+		deps.Close()
+		return nil, fmt.Errorf("failed to initialize webhooks: %w", err)
 	}
 
-	urlHandler := http.NewURLHandler(urlServ)
+	return deps, nil
+}
 
-	r := gin.Default()
+func runMigrations(pool *pgxpool.Pool) error {
+	m, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		return err
+	}
 
-	r.GET("/:alias", urlHandler.Redirect)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
 
-	urlApi := r.Group("/api")
-	{
-		urlApi.POST("", urlHandler.Create)
-		urlApi.GET("/:alias", urlHandler.GetLongURLByAlias)
+	err = m.Up(ctx)
+	if errors.Is(err, migrate.ErrNoChange) {
+		log.Info().Msg("database schema is up to date")
+		return nil
 	}
+	return err
+}
 
-	return r
+// publishMetrics публикует метрики через expvar. Повторная публикация
+// под тем же именем (например, при повторном вызове App) игнорируется.
+func publishMetrics(name string, f func() any) {
+	if expvar.Get(name) != nil {
+		return
+	}
+	expvar.Publish(name, expvar.Func(f))
+}
+
+func newAliasGenerator(cfg config.AliasConfig, ids generator.IDAllocator) (urlService.AliasGenerator, error) {
+	switch cfg.Strategy {
+	case config.AliasStrategyCounter:
+		return generator.NewCounter(ids, cfg.Secret, cfg.Length)
+	case config.AliasStrategyHashids:
+		return generator.NewHashids(ids, cfg.Secret, cfg.Length)
+	case config.AliasStrategyRandom:
+		return generator.NewRandom(cfg.Length)
+	case config.AliasStrategyWord:
+		return generator.NewWord(cfg.Length)
+	case config.AliasStrategyHash:
+		return generator.NewHash(cfg.Length)
+	default:
+		return nil, fmt.Errorf("unknown alias strategy: %s", cfg.Strategy)
+	}
 }
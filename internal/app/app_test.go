@@ -0,0 +1,91 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Rasulikus/url-shortener/api/openapi"
+	"github.com/Rasulikus/url-shortener/internal/config"
+	transport "github.com/Rasulikus/url-shortener/internal/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestApp_OpenAPI сверяет маршруты сервера со спецификацией в обе стороны.
+func TestApp_OpenAPI(t *testing.T) {
+	cfg, _, err := config.Load([]string{
+		"--storage", "memory",
+		"--alias-secret", "1",
+		"--admin-token", "secret",
+		"--unfurl-enabled", "false",
+		"--dev-mode", "true",
+	})
+	require.NoError(t, err)
+
+	r, _, _, closeApp := App(cfg)
+	defer closeApp()
+
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	registered := make(map[string]bool)
+	for _, route := range r.Routes() {
+		path := transport.OpenAPIPath(route.Path)
+		registered[route.Method+" "+path] = true
+
+		item := doc.Paths.Value(path)
+		if assert.NotNil(t, item, "route %s %s is not in spec", route.Method, route.Path) {
+			assert.NotNil(t, item.GetOperation(route.Method), "route %s %s is not in spec", route.Method, route.Path)
+		}
+	}
+
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			assert.True(t, registered[method+" "+path], "spec operation %s %s is not registered", method, path)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, string(openapi.Spec), w.Body.String())
+}
+
+// TestApp_DebugVars проверяет, что метрики expvar не доступны без токена.
+func TestApp_DebugVars(t *testing.T) {
+	cfg, _, err := config.Load([]string{
+		"--storage", "memory",
+		"--alias-secret", "1",
+		"--admin-token", "secret",
+		"--unfurl-enabled", "false",
+	})
+	require.NoError(t, err)
+
+	r, _, _, closeApp := App(cfg)
+	defer closeApp()
+
+	cases := []struct {
+		name       string
+		path       string
+		token      string
+		wantStatus int
+	}{
+		{"public path", "/debug/vars", "", http.StatusNotFound},
+		{"no token", "/admin/debug/vars", "", http.StatusUnauthorized},
+		{"admin", "/admin/debug/vars", "secret", http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatus, w.Code)
+		})
+	}
+}
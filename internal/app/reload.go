@@ -0,0 +1,141 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/config"
+	urlService "github.com/Rasulikus/url-shortener/internal/service/url"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Runtime хранит текущую конфигурацию и применяет к работающим компонентам
+// параметры, которые меняются без перезапуска: уровень логов, политику повторов
+// при коллизии алиаса и токен административного API.
+type Runtime struct {
+	// mu не даёт двум перезагрузкам выполняться одновременно.
+	mu      sync.Mutex
+	cfg     atomic.Pointer[config.Config]
+	service *urlService.Service
+}
+
+func newRuntime(cfg *config.Config, service *urlService.Service) *Runtime {
+	rt := &Runtime{service: service}
+	rt.cfg.Store(cfg)
+	return rt
+}
+
+// Config возвращает действующую конфигурацию.
+func (rt *Runtime) Config() *config.Config {
+	return rt.cfg.Load()
+}
+
+// Reload применяет параметры из next, которые меняются без перезапуска, и логирует
+// остальные изменения. При ошибке ничего не применяется и остаётся прежняя конфигурация.
+func (rt *Runtime) Reload(next *config.Config) error {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	cur := rt.cfg.Load()
+	reloadable, restart := cur.Changes(next)
+	if len(restart) > 0 {
+		log.Warn().
+			Strs("keys", restart).
+			Msg("config changes require restart and are not applied")
+	}
+	if len(reloadable) == 0 {
+		log.Info().Msg("config reloaded, nothing to apply")
+		return nil
+	}
+
+	// Маршруты /admin регистрируются при старте, поэтому включить или выключить API можно только перезапуском
+	if (cur.AdminToken == "") != (next.AdminToken == "") {
+		return errors.New("enabling or disabling the admin API requires restart")
+	}
+
+	// Сначала проверяем всё, затем применяем, чтобы не применить конфигурацию наполовину
+	level, err := zerolog.ParseLevel(next.LogLevel)
+	if err != nil {
+		return err
+	}
+	if err := rt.service.SetRetryPolicy(urlService.RetryPolicy{
+		MaxAttempts: next.Alias.MaxAttempts,
+		Backoff:     next.Alias.RetryBackoff,
+	}); err != nil {
+		return err
+	}
+	zerolog.SetGlobalLevel(level)
+	rt.cfg.Store(cur.WithReloadable(next))
+
+	log.Info().
+		Strs("keys", reloadable).
+		Msg("config reloaded")
+
+	return nil
+}
+
+// Watch перечитывает конфигурацию через load по SIGHUP и при изменении файла конфигурации,
+// пока не отменён ctx. Неверная конфигурация отклоняется, прежняя продолжает действовать.
+func (rt *Runtime) Watch(ctx context.Context, load func() (*config.Config, error)) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	cfg := rt.Config()
+	var tick <-chan time.Time
+	if cfg.File != "" && cfg.WatchInterval > 0 {
+		t := time.NewTicker(cfg.WatchInterval)
+		defer t.Stop()
+		tick = t.C
+	}
+	last := stat(cfg.File)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			rt.reloadFrom(load, "SIGHUP")
+		case <-tick:
+			if st := stat(cfg.File); st != last {
+				last = st
+				rt.reloadFrom(load, "config file changed")
+			}
+		}
+	}
+}
+
+func (rt *Runtime) reloadFrom(load func() (*config.Config, error), reason string) {
+	log.Info().Str("reason", reason).Msg("reloading config")
+
+	next, err := load()
+	if err == nil {
+		err = rt.Reload(next)
+	}
+	if err != nil {
+		log.Error().
+			Err(err).
+			Msg("config reload rejected, keeping previous config")
+	}
+}
+
+// fileStamp — признаки изменения файла без чтения содержимого.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func stat(path string) fileStamp {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: fi.ModTime(), size: fi.Size()}
+}
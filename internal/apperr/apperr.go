@@ -0,0 +1,85 @@
+// Package apperr описывает ошибки приложения, которые видит клиент: устойчивый код,
+// понятное человеку сообщение, поле запроса и подробности. Класс ошибки (некорректный
+// ввод, не найдено, конфликт) по-прежнему задают ошибки-метки слоёв, например
+// service.ErrInvalidInput; Error оборачивает их, и errors.Is продолжает работать.
+package apperr
+
+import (
+	"errors"
+	"maps"
+)
+
+// Code — машиночитаемый код ошибки. Коды не меняются между версиями, клиенты
+// могут на них полагаться; сообщения могут меняться.
+type Code string
+
+const (
+	CodeInvalidInput     Code = "invalid_input"
+	CodeInvalidJSON      Code = "invalid_json"
+	CodeMissingField     Code = "missing_field"
+	CodeInvalidParameter Code = "invalid_parameter"
+	CodeInvalidURL       Code = "invalid_url"
+	CodeInvalidURLScheme Code = "invalid_url_scheme"
+	CodeInvalidAlias     Code = "invalid_alias"
+	CodeBodyTooLarge     Code = "body_too_large"
+	CodeUnknownDomain    Code = "unknown_domain"
+	CodeUnauthorized     Code = "unauthorized"
+	CodeNotFound         Code = "not_found"
+	CodeDisabled         Code = "disabled"
+	CodeConflict         Code = "conflict"
+	CodeAliasConflict    Code = "alias_conflict"
+	CodeLongURLConflict  Code = "long_url_conflict"
+	CodeUnavailable      Code = "unavailable"
+	// CodeAliasExhausted — все попытки подобрать свободный алиас закончились коллизией.
+	CodeAliasExhausted Code = "alias_retries_exhausted"
+	// CodeAliasSpaceExhausted — генератор выдал все алиасы заданной длины.
+	CodeAliasSpaceExhausted Code = "alias_space_exhausted"
+	CodeInternal            Code = "internal"
+)
+
+// Error — ошибка приложения для клиента.
+type Error struct {
+	Code Code
+	// Message — сообщение для человека, без внутренних подробностей.
+	Message string
+	// Field — поле или параметр запроса, к которому относится ошибка. Пусто — ко всему запросу.
+	Field string
+	// Details — дополнительные сведения, которые отдаются клиенту как есть.
+	Details map[string]any
+	// Err — ошибка-метка или причина, через неё проходят errors.Is и errors.As.
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Field != "" {
+		return e.Field + ": " + e.Message
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// As возвращает первую Error в цепочке err.
+func As(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// WithField возвращает копию Error из цепочки err с полем field. Если в цепочке
+// нет Error, создаётся новая с кодом CodeInvalidInput и текстом err.
+func WithField(err error, field string) *Error {
+	e, ok := As(err)
+	if !ok {
+		return &Error{Code: CodeInvalidInput, Message: err.Error(), Field: field, Err: err}
+	}
+
+	cp := *e
+	cp.Field = field
+	cp.Details = maps.Clone(e.Details)
+	return &cp
+}
@@ -0,0 +1,56 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errKind = errors.New("kind")
+
+func TestError(t *testing.T) {
+	e := &Error{Code: CodeInvalidURL, Message: "url is empty", Err: errKind}
+	assert.Equal(t, "url is empty", e.Error())
+	assert.ErrorIs(t, e, errKind)
+
+	e.Field = "long_url"
+	assert.Equal(t, "long_url: url is empty", e.Error())
+}
+
+func TestAs(t *testing.T) {
+	e := &Error{Code: CodeNotFound, Message: "short link not found"}
+
+	got, ok := As(fmt.Errorf("%w: %w", errKind, e))
+	require.True(t, ok)
+	assert.Same(t, e, got)
+
+	_, ok = As(errKind)
+	assert.False(t, ok)
+}
+
+func TestWithField(t *testing.T) {
+	t.Run("copies app error", func(t *testing.T) {
+		orig := &Error{Code: CodeInvalidURL, Message: "url is malformed", Details: map[string]any{"reason": "x"}, Err: errKind}
+
+		got := WithField(fmt.Errorf("wrapped: %w", orig), "long_url")
+		got.Details["reason"] = "y"
+
+		assert.Equal(t, CodeInvalidURL, got.Code)
+		assert.Equal(t, "long_url", got.Field)
+		assert.ErrorIs(t, got, errKind)
+		assert.Empty(t, orig.Field, "original is not modified")
+		assert.Equal(t, "x", orig.Details["reason"], "details are copied")
+	})
+
+	t.Run("plain error", func(t *testing.T) {
+		got := WithField(errKind, "alias")
+
+		assert.Equal(t, CodeInvalidInput, got.Code)
+		assert.Equal(t, "alias", got.Field)
+		assert.Equal(t, "kind", got.Message)
+		assert.ErrorIs(t, got, errKind)
+	})
+}
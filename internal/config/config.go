@@ -1,15 +1,19 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net"
 	"net/url"
 	"os"
 	"path"
-	"strconv"
+	"slices"
 	"strings"
 	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/utils/domains"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type Storage string
@@ -17,22 +21,85 @@ type Storage string
 const (
 	StoragePostgres Storage = "postgresql"
 	StorageMemory   Storage = "memory"
+	StorageBolt     Storage = "bolt"
+	StorageSQLite   Storage = "sqlite"
 )
 
 func ParseStorage(s string) (Storage, error) {
 	v := Storage(strings.ToLower(strings.TrimSpace(s)))
 	switch v {
-	case StoragePostgres, StorageMemory:
+	case StoragePostgres, StorageMemory, StorageBolt, StorageSQLite:
 		return v, nil
 	default:
 		return "", fmt.Errorf("unknown storage: %q", s)
 	}
 }
 
+type AliasStrategy string
+
+const (
+	AliasStrategyCounter AliasStrategy = "counter"
+	AliasStrategyRandom  AliasStrategy = "random"
+	AliasStrategyHashids AliasStrategy = "hashids"
+	AliasStrategyWord    AliasStrategy = "word"
+	AliasStrategyHash    AliasStrategy = "hash"
+)
+
+// minAliasLength — наименьшая ALIAS_LENGTH для стратегии. Короче алиасы быстро
+// заканчиваются: counter и hashids выдают 63^n алиасов, у word на символ приходится
+// 17 или 5 вариантов, а random и hash упираются в коллизии задолго до конца пространства.
+var minAliasLength = map[AliasStrategy]int{
+	AliasStrategyCounter: 5,
+	AliasStrategyHashids: 5,
+	AliasStrategyRandom:  6,
+	AliasStrategyHash:    6,
+	AliasStrategyWord:    8,
+}
+
+func ParseAliasStrategy(s string) (AliasStrategy, error) {
+	v := AliasStrategy(strings.ToLower(strings.TrimSpace(s)))
+	switch v {
+	case AliasStrategyCounter, AliasStrategyRandom, AliasStrategyHashids, AliasStrategyWord, AliasStrategyHash:
+		return v, nil
+	default:
+		return "", fmt.Errorf("unknown alias strategy: %q", s)
+	}
+}
+
+// FsyncPolicy — когда журнал memory-хранилища сбрасывается на диск.
+type FsyncPolicy string
+
+const (
+	// FsyncAlways — fsync после каждой записи, изменения не теряются.
+	FsyncAlways FsyncPolicy = "always"
+	// FsyncInterval — fsync раз в MEMORY_FSYNC_INTERVAL, при сбое теряется не больше интервала.
+	FsyncInterval FsyncPolicy = "interval"
+	// FsyncNever — сброс на диск остаётся на усмотрение ОС.
+	FsyncNever FsyncPolicy = "never"
+)
+
+func ParseFsyncPolicy(s string) (FsyncPolicy, error) {
+	v := FsyncPolicy(strings.ToLower(strings.TrimSpace(s)))
+	switch v {
+	case FsyncAlways, FsyncInterval, FsyncNever:
+		return v, nil
+	default:
+		return "", fmt.Errorf("unknown fsync policy: %q", s)
+	}
+}
+
 const (
+	keyConfigFile          = "CONFIG_FILE"
+	keyConfigWatchInterval = "CONFIG_WATCH_INTERVAL"
+
 	keyLogLevel = "LOG_LEVEL"
+	keyDevMode  = "DEV_MODE"
 
-	keyBaseURL = "BASE_URL"
+	keyBaseURL          = "BASE_URL"
+	keyRootRedirect     = "ROOT_REDIRECT"
+	keyNotFoundRedirect = "NOT_FOUND_REDIRECT"
+	keyDomains          = "DOMAINS"
+	keyPagesDir         = "PAGES_DIR"
 
 	keyStorage = "STORAGE"
 
@@ -42,6 +109,10 @@ const (
 	keyHTTPWriteTimeout = "HTTP_WRITE_TIMEOUT"
 	keyHTTPIdleTimeout  = "HTTP_IDLE_TIMEOUT"
 
+	keyGRPCEnabled = "GRPC_ENABLED"
+	keyGRPCHost    = "GRPC_HOST"
+	keyGRPCPort    = "GRPC_PORT"
+
 	keyDBHost    = "DB_HOST"
 	keyDBPort    = "DB_PORT"
 	keyDBUser    = "DB_USER"
@@ -49,12 +120,73 @@ const (
 	keyDBName    = "DB_NAME"
 	keyDBSSLMode = "DB_SSLMODE"
 
+	keyDatabaseURL   = "DATABASE_URL"
+	keyDBSSLRootCert = "DB_SSLROOTCERT"
+	keyDBSSLCert     = "DB_SSLCERT"
+	keyDBSSLKey      = "DB_SSLKEY"
+	keyDBParams      = "DB_PARAMS"
+
 	keyPGMinConns        = "PG_MIN_CONNS"
 	keyPGMaxConns        = "PG_MAX_CONNS"
 	keyPGMaxConnLifeTime = "PG_MAX_CONN_LIFETIME"
 	keyPGMaxIdleTime     = "PG_MAX_CONN_IDLE_TIME"
 
-	keyAliasSecret = "ALIAS_SECRET"
+	keyIDBlockSize = "ID_BLOCK_SIZE"
+
+	keyDBAutoMigrate = "DB_AUTO_MIGRATE"
+
+	keyDBReplicaDSNs           = "DB_REPLICA_DSNS"
+	keyDBReplicaHealthInterval = "DB_REPLICA_HEALTH_INTERVAL"
+	keyDBReplicaStickyWindow   = "DB_REPLICA_STICKY_WINDOW"
+
+	keyMemoryDataDir          = "MEMORY_DATA_DIR"
+	keyMemoryFsync            = "MEMORY_FSYNC"
+	keyMemoryFsyncInterval    = "MEMORY_FSYNC_INTERVAL"
+	keyMemorySnapshotInterval = "MEMORY_SNAPSHOT_INTERVAL"
+	keyMemoryLockTimeout      = "MEMORY_LOCK_TIMEOUT"
+
+	keyBoltPath    = "BOLT_PATH"
+	keyBoltTimeout = "BOLT_TIMEOUT"
+
+	keySQLitePath        = "SQLITE_PATH"
+	keySQLiteBusyTimeout = "SQLITE_BUSY_TIMEOUT"
+
+	keyAliasSecret   = "ALIAS_SECRET"
+	keyAliasStrategy = "ALIAS_STRATEGY"
+	keyAliasLength   = "ALIAS_LENGTH"
+
+	keyAliasMaxAttempts  = "ALIAS_MAX_ATTEMPTS"
+	keyAliasRetryBackoff = "ALIAS_RETRY_BACKOFF"
+
+	keyAdminToken          = "ADMIN_TOKEN"
+	keyAdminImportMaxBytes = "ADMIN_IMPORT_MAX_BYTES"
+
+	keyUnfurlEnabled      = "UNFURL_ENABLED"
+	keyUnfurlWorkers      = "UNFURL_WORKERS"
+	keyUnfurlQueueSize    = "UNFURL_QUEUE_SIZE"
+	keyUnfurlTimeout      = "UNFURL_TIMEOUT"
+	keyUnfurlMaxBytes     = "UNFURL_MAX_BYTES"
+	keyUnfurlAllowPrivate = "UNFURL_ALLOW_PRIVATE"
+
+	keyHealthCheckEnabled          = "HEALTH_CHECK_ENABLED"
+	keyHealthCheckInterval         = "HEALTH_CHECK_INTERVAL"
+	keyHealthCheckWorkers          = "HEALTH_CHECK_WORKERS"
+	keyHealthCheckTimeout          = "HEALTH_CHECK_TIMEOUT"
+	keyHealthCheckHostDelay        = "HEALTH_CHECK_HOST_DELAY"
+	keyHealthCheckFailureThreshold = "HEALTH_CHECK_FAILURE_THRESHOLD"
+	keyHealthCheckAllowPrivate     = "HEALTH_CHECK_ALLOW_PRIVATE"
+
+	keyWebhookEndpoints       = "WEBHOOK_ENDPOINTS"
+	keyWebhookSecret          = "WEBHOOK_SECRET"
+	keyWebhookClickThresholds = "WEBHOOK_CLICK_THRESHOLDS"
+	keyWebhookPollInterval    = "WEBHOOK_POLL_INTERVAL"
+	keyWebhookWorkers         = "WEBHOOK_WORKERS"
+	keyWebhookTimeout         = "WEBHOOK_TIMEOUT"
+	keyWebhookMaxAttempts     = "WEBHOOK_MAX_ATTEMPTS"
+	keyWebhookRetryBackoff    = "WEBHOOK_RETRY_BACKOFF"
+
+	// Суффикс переменной с путём к файлу, из которого читается секрет.
+	fileSuffix = "_FILE"
 )
 
 type HTTPConfig struct {
@@ -65,7 +197,18 @@ type HTTPConfig struct {
 	IdleTimeout  time.Duration
 }
 
+// GRPCConfig — gRPC API на отдельном порту.
+type GRPCConfig struct {
+	Enabled bool
+	Host    string
+	Port    string
+}
+
 type DBConfig struct {
+	// URL — полная строка подключения. Если задана, Host, Port, User, Pass, Name и SSLMode не используются.
+	URL string
+
+	// Host — хост, несколько хостов через запятую (host1:5432,host2) или путь к каталогу Unix-сокета.
 	Host    string
 	Port    string
 	User    string
@@ -73,185 +216,330 @@ type DBConfig struct {
 	Name    string
 	SSLMode string
 
+	// Пути к корневому сертификату, сертификату и ключу клиента для TLS.
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	// Params — дополнительные параметры подключения, например target_session_attrs.
+	Params url.Values
+
 	MinConns        int
 	MaxConns        int
 	MaxConnLifetime time.Duration
 	MaxConnIdleTime time.Duration
+
+	// IDBlockSize — сколько ID экземпляр арендует за одно обращение к БД.
+	IDBlockSize uint64
+
+	// AutoMigrate — применять встроенные миграции при старте сервиса.
+	AutoMigrate bool
+
+	// ReplicaDSNs — строки подключения к репликам для чтения. Если пусто, всё идёт в основную БД.
+	ReplicaDSNs []string
+	// ReplicaHealthInterval — как часто проверять доступность реплик.
+	ReplicaHealthInterval time.Duration
+	// ReplicaStickyWindow — сколько после записи читать алиас из основной БД, пока реплика догоняет.
+	ReplicaStickyWindow time.Duration
 }
 
+// DSN возвращает строку подключения: URL, если он задан, иначе собранную из полей.
+// Сертификаты и дополнительные параметры добавляются в обоих случаях.
 func (cfg DBConfig) DSN() string {
-	u := &url.URL{
-		Scheme: "postgres",
-		User:   url.UserPassword(cfg.User, cfg.Pass),
-		Host:   fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
-		Path:   path.Join("/", cfg.Name),
+	var u *url.URL
+	if cfg.URL != "" {
+		parsed, err := url.Parse(cfg.URL)
+		if err != nil {
+			// New проверяет URL заранее, сюда попадает только конфигурация, собранная вручную
+			return cfg.URL
+		}
+		u = parsed
+	} else {
+		u = &url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(cfg.User, cfg.Pass),
+			Path:   path.Join("/", cfg.Name),
+		}
 	}
+
 	q := u.Query()
-	q.Set("sslmode", cfg.SSLMode)
+	for k, vs := range cfg.Params {
+		for _, v := range vs {
+			q.Add(k, v)
+		}
+	}
+
+	if cfg.URL == "" {
+		if isSocketDir(cfg.Host) {
+			// Путь к сокету не помещается в host URL, pgx принимает его параметром
+			q.Set("host", cfg.Host)
+			q.Set("port", cfg.Port)
+		} else {
+			u.Host = joinHosts(cfg.Host, cfg.Port)
+		}
+		q.Set("sslmode", cfg.SSLMode)
+	}
+
+	setNotEmpty(q, "sslrootcert", cfg.SSLRootCert)
+	setNotEmpty(q, "sslcert", cfg.SSLCert)
+	setNotEmpty(q, "sslkey", cfg.SSLKey)
+
 	u.RawQuery = q.Encode()
 	return u.String()
 }
 
-type Config struct {
-	LogLevel string
-	BaseURL  string
-	Storage  Storage // memory|postgresql
-
-	HTTP HTTPConfig
-	DB   *DBConfig
+// ReplicaDSN дополняет строку подключения к реплике теми же DB_PARAMS и сертификатами,
+// что и строку основной БД.
+func (cfg DBConfig) ReplicaDSN(dsn string) string {
+	cfg.URL = dsn
+	return cfg.DSN()
+}
 
-	AliasSecret uint64
+func isSocketDir(host string) bool {
+	return strings.HasPrefix(host, "/")
 }
 
-func getEnv(key string) (string, error) {
-	value, ok := os.LookupEnv(key)
-	if !ok || value == "" {
-		return "", fmt.Errorf("environment variable %s not set", key)
+// joinHosts дописывает порт по умолчанию к хостам из списка через запятую, у которых его нет.
+func joinHosts(hosts, port string) string {
+	list := strings.Split(hosts, ",")
+	for i, h := range list {
+		h = strings.TrimSpace(h)
+		if _, _, err := net.SplitHostPort(h); err != nil {
+			h = net.JoinHostPort(h, port)
+		}
+		list[i] = h
 	}
-	return value, nil
+	return strings.Join(list, ",")
 }
 
-func getEnvInt(key string) (int, error) {
-	value, err := getEnv(key)
-	if err != nil {
-		return 0, err
+func setNotEmpty(q url.Values, key, value string) {
+	if value != "" {
+		q.Set(key, value)
 	}
-	num, err := strconv.Atoi(value)
-	if err != nil {
-		return 0, fmt.Errorf("environment variable %s is not an integer: %q: %w", key, value, err)
-	}
-	return num, nil
 }
 
-func getEnvUint64(key string) (uint64, error) {
-	value, err := getEnv(key)
-	if err != nil {
-		return 0, err
+// sslModes — значения sslmode, которые понимает pgx.
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// reservedDBParams — параметры, которые задаются отдельными переменными, а не через DB_PARAMS.
+var reservedDBParams = map[string]string{
+	"host":        keyDBHost,
+	"port":        keyDBPort,
+	"user":        keyDBUser,
+	"password":    keyDBPass,
+	"dbname":      keyDBName,
+	"sslmode":     keyDBSSLMode,
+	"sslrootcert": keyDBSSLRootCert,
+	"sslcert":     keyDBSSLCert,
+	"sslkey":      keyDBSSLKey,
+}
+
+// validate проверяет настройки подключения и называет параметр, в котором ошибка.
+func (cfg DBConfig) validate() error {
+	var errs []error
+
+	if cfg.URL != "" {
+		u, err := url.Parse(cfg.URL)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: not a valid URL", keyDatabaseURL))
+		} else if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+			errs = append(errs, fmt.Errorf("%s: must start with postgres:// or postgresql://", keyDatabaseURL))
+		}
+	} else if !slices.Contains(sslModes, cfg.SSLMode) {
+		errs = append(errs, fmt.Errorf("%s: must be one of %s: %q", keyDBSSLMode, strings.Join(sslModes, ", "), cfg.SSLMode))
 	}
 
-	n, err := strconv.ParseUint(value, 10, 64)
-	if err != nil {
-		return 0, fmt.Errorf("environment variable %s is not a valid uint64: %q: %w", key, value, err)
+	for i, dsn := range cfg.ReplicaDSNs {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: replica %d: not a valid URL", keyDBReplicaDSNs, i+1))
+		} else if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+			errs = append(errs, fmt.Errorf("%s: replica %d: must start with postgres:// or postgresql://", keyDBReplicaDSNs, i+1))
+		}
 	}
 
-	return n, nil
-}
+	for k := range cfg.Params {
+		if key, ok := reservedDBParams[k]; ok {
+			errs = append(errs, fmt.Errorf("%s: parameter %q must be set via %s", keyDBParams, k, key))
+		}
+	}
 
-func getEnvDuration(key string) (time.Duration, error) {
-	value, err := getEnv(key)
-	if err != nil {
-		return 0, err
+	if (cfg.SSLCert == "") != (cfg.SSLKey == "") {
+		errs = append(errs, fmt.Errorf("%s and %s must be set together", keyDBSSLCert, keyDBSSLKey))
 	}
-	d, err := time.ParseDuration(value)
-	if err != nil {
-		return 0, fmt.Errorf("environment variable %s is not a valid duration: %s: %w", key, value, err)
+	for _, f := range []struct{ key, path string }{
+		{keyDBSSLRootCert, cfg.SSLRootCert},
+		{keyDBSSLCert, cfg.SSLCert},
+		{keyDBSSLKey, cfg.SSLKey},
+	} {
+		if f.path == "" {
+			continue
+		}
+		if _, err := os.Stat(f.path); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.key, err))
+		}
 	}
-	return d, nil
+	return errors.Join(errs...)
 }
 
-func New() (*Config, error) {
-	if err := godotenv.Load(); err != nil {
-		if !os.IsNotExist(err) {
-			return nil, fmt.Errorf("error loading .env file: %w", err)
+// validateDSN проверяет собранную строку подключения так же, как её потом разберёт pgx.
+func (cfg DBConfig) validateDSN() error {
+	// Остальное (формат хостов, значения параметров, содержимое сертификатов) проверяет сам pgx.
+	// Его ошибки не содержат пароль.
+	if _, err := pgconn.ParseConfig(cfg.DSN()); err != nil {
+		key := "DB_*"
+		if cfg.URL != "" {
+			key = keyDatabaseURL
+		}
+		return fmt.Errorf("invalid postgres connection settings in %s: %w", key, err)
+	}
+	for i, dsn := range cfg.ReplicaDSNs {
+		if _, err := pgconn.ParseConfig(cfg.ReplicaDSN(dsn)); err != nil {
+			return fmt.Errorf("invalid postgres connection settings in %s: replica %d: %w", keyDBReplicaDSNs, i+1, err)
 		}
 	}
 
-	cfg := new(Config)
-	var err error
+	return nil
+}
 
-	cfg.LogLevel, err = getEnv(keyLogLevel)
-	if err != nil {
-		return nil, err
-	}
+// MemoryConfig — настройки сохранения memory-хранилища на диск.
+type MemoryConfig struct {
+	// DataDir — каталог журнала и снапшотов. Если пуст, данные живут только в памяти.
+	DataDir string
 
-	cfg.BaseURL, err = getEnv(keyBaseURL)
-	if err != nil {
-		return nil, err
-	}
+	Fsync         FsyncPolicy // always|interval|never
+	FsyncInterval time.Duration
 
-	st, err := getEnv(keyStorage)
-	if err != nil {
-		return nil, err
-	}
-	cfg.Storage, err = ParseStorage(st)
-	if err != nil {
-		return nil, err
-	}
+	// SnapshotInterval — как часто сохранять снапшот и обрезать журнал. 0 — только при остановке.
+	SnapshotInterval time.Duration
 
-	cfg.HTTP.Host, err = getEnv(keyHTTPHost)
-	if err != nil {
-		return nil, err
-	}
-	cfg.HTTP.Port, err = getEnv(keyHTTPPort)
-	if err != nil {
-		return nil, err
-	}
-	cfg.HTTP.ReadTimeout, err = getEnvDuration(keyHTTPReadTimeout)
-	if err != nil {
-		return nil, err
-	}
-	cfg.HTTP.WriteTimeout, err = getEnvDuration(keyHTTPWriteTimeout)
-	if err != nil {
-		return nil, err
-	}
-	cfg.HTTP.IdleTimeout, err = getEnvDuration(keyHTTPIdleTimeout)
-	if err != nil {
-		return nil, err
-	}
+	// LockTimeout — сколько ждать, если каталог открыт другим процессом.
+	LockTimeout time.Duration
+}
 
-	switch cfg.Storage {
-	case StorageMemory:
-	case StoragePostgres:
-		cfg.DB = new(DBConfig)
-		cfg.DB.Host, err = getEnv(keyDBHost)
-		if err != nil {
-			return nil, err
-		}
-		cfg.DB.Port, err = getEnv(keyDBPort)
-		if err != nil {
-			return nil, err
-		}
-		cfg.DB.User, err = getEnv(keyDBUser)
-		if err != nil {
-			return nil, err
-		}
-		cfg.DB.Pass, err = getEnv(keyDBPass)
-		if err != nil {
-			return nil, err
-		}
-		cfg.DB.Name, err = getEnv(keyDBName)
-		if err != nil {
-			return nil, err
-		}
-		cfg.DB.SSLMode, err = getEnv(keyDBSSLMode)
-		if err != nil {
-			return nil, err
-		}
+// BoltConfig — настройки встроенного хранилища bbolt.
+type BoltConfig struct {
+	// Path — путь к файлу базы.
+	Path string
+	// Timeout — сколько ждать блокировку файла, если его держит другой процесс.
+	Timeout time.Duration
+}
 
-		cfg.DB.MinConns, err = getEnvInt(keyPGMinConns)
-		if err != nil {
-			return nil, err
-		}
-		cfg.DB.MaxConns, err = getEnvInt(keyPGMaxConns)
-		if err != nil {
-			return nil, err
-		}
-		cfg.DB.MaxConnLifetime, err = getEnvDuration(keyPGMaxConnLifeTime)
-		if err != nil {
-			return nil, err
-		}
-		cfg.DB.MaxConnIdleTime, err = getEnvDuration(keyPGMaxIdleTime)
-		if err != nil {
-			return nil, err
-		}
-	default:
-		return nil, fmt.Errorf("unknown storage type, expected memory or postgresql: %s", cfg.Storage)
-	}
+// SQLiteConfig — настройки хранилища SQLite.
+type SQLiteConfig struct {
+	// Path — путь к файлу базы.
+	Path string
+	// BusyTimeout — сколько ждать, пока другой процесс держит блокировку записи.
+	BusyTimeout time.Duration
+	// IDBlockSize — сколько ID резервируется за одно обращение к базе.
+	IDBlockSize uint64
+}
 
-	cfg.AliasSecret, err = getEnvUint64(keyAliasSecret)
-	if err != nil {
-		return nil, err
-	}
+type AliasConfig struct {
+	Strategy AliasStrategy // counter|random|hashids|word|hash
+	Length   int
+	Secret   uint64
 
-	return cfg, nil
+	MaxAttempts  int
+	RetryBackoff time.Duration
+}
+
+// UnfurlConfig — фоновая загрузка заголовка, описания и favicon страниц назначения.
+type UnfurlConfig struct {
+	Enabled   bool
+	Workers   int
+	QueueSize int
+	// Timeout ограничивает загрузку одной страницы вместе с редиректами.
+	Timeout time.Duration
+	// MaxBytes — сколько байт страницы читается.
+	MaxBytes int64
+	// AllowPrivate разрешает загружать страницы из внутренней сети.
+	AllowPrivate bool
+}
+
+// HealthCheckConfig — периодическая проверка доступности страниц назначения.
+type HealthCheckConfig struct {
+	Enabled bool
+	// Interval — как часто проверять каждую ссылку.
+	Interval time.Duration
+	Workers  int
+	// Timeout ограничивает одну проверку вместе с редиректами.
+	Timeout time.Duration
+	// HostDelay — минимальная пауза между запросами к одному хосту.
+	HostDelay time.Duration
+	// FailureThreshold — после скольких неудачных проверок подряд ссылка считается неработающей.
+	FailureThreshold int
+	// AllowPrivate разрешает проверять страницы из внутренней сети.
+	AllowPrivate bool
+}
+
+// WebhookConfig — рассылка событий ссылок подписчикам.
+type WebhookConfig struct {
+	Endpoints []model.Webhook
+	// Secret — ключ HMAC-подписи запросов, обязателен, если есть подписки.
+	Secret string
+	// ClickThresholds — при каком числе переходов отправлять url.clicks.
+	// Пусто — переходы не считаются.
+	ClickThresholds []int64
+	// PollInterval — как часто проверять outbox и очередь доставок.
+	PollInterval time.Duration
+	Workers      int
+	// Timeout ограничивает один запрос к подписчику.
+	Timeout time.Duration
+	// MaxAttempts — после скольких неудачных попыток доставка переходит в dead.
+	MaxAttempts int
+	// RetryBackoff — пауза перед второй попыткой, удваивается с каждой следующей.
+	RetryBackoff time.Duration
+}
+
+type Config struct {
+	LogLevel string
+	BaseURL  string
+	Storage  Storage // memory|postgresql|bolt|sqlite
+
+	// DevMode — режим разработки: запросы и ответы HTTP API проверяются по спецификации OpenAPI.
+	DevMode bool
+
+	// RootRedirect — куда перенаправлять запрос к корню домена по умолчанию. Пусто — 404.
+	RootRedirect string
+	// NotFoundRedirect — куда перенаправлять браузер с неизвестного алиаса домена по умолчанию.
+	// Пусто — показывается страница 404.
+	NotFoundRedirect string
+	// Domains — дополнительные короткие домены со своими пространствами алиасов.
+	Domains []domains.Domain
+	// PagesDir — каталог с шаблонами страниц ошибок, заменяющими встроенные. Пусто — только встроенные.
+	PagesDir string
+
+	HTTP   HTTPConfig
+	GRPC   GRPCConfig
+	DB     *DBConfig
+	Memory *MemoryConfig
+	Bolt   *BoltConfig
+	SQLite *SQLiteConfig
+
+	Alias       AliasConfig
+	Unfurl      UnfurlConfig
+	HealthCheck HealthCheckConfig
+	Webhook     WebhookConfig
+
+	// AdminToken — bearer-токен для /admin. Если пуст, административный API не регистрируется.
+	AdminToken string
+	// AdminImportMaxBytes — наибольший размер тела POST /admin/import.
+	AdminImportMaxBytes int64
+
+	// File — файл конфигурации, из которого загружены значения. Пусто, если файла нет.
+	File string
+	// WatchInterval — как часто проверять File на изменения. 0 — только по SIGHUP.
+	WatchInterval time.Duration
+
+	// values — исходные значения параметров с их источниками, для Print и Changes.
+	values map[string]value
+}
+
+// DefaultDomain возвращает редиректы домена по умолчанию.
+func (c *Config) DefaultDomain() domains.Domain {
+	return domains.Domain{
+		RootRedirect:     c.RootRedirect,
+		NotFoundRedirect: c.NotFoundRedirect,
+	}
 }
@@ -0,0 +1,584 @@
+package config
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/utils/domains"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBConfig_DSN(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  DBConfig
+		want string
+	}{
+		{
+			name: "single host",
+			cfg:  DBConfig{Host: "db", Port: "5432", User: "u", Pass: "p@ss", Name: "app", SSLMode: "disable"},
+			want: "postgres://u:p%40ss@db:5432/app?sslmode=disable",
+		},
+		{
+			name: "multiple hosts",
+			cfg: DBConfig{
+				Host: "db1,db2:5433", Port: "5432", User: "u", Pass: "p", Name: "app", SSLMode: "prefer",
+				Params: url.Values{"target_session_attrs": {"read-write"}},
+			},
+			want: "postgres://u:p@db1:5432,db2:5433/app?sslmode=prefer&target_session_attrs=read-write",
+		},
+		{
+			name: "unix socket",
+			cfg:  DBConfig{Host: "/var/run/postgresql", Port: "5432", User: "u", Pass: "p", Name: "app", SSLMode: "disable"},
+			want: "postgres://u:p@/app?host=%2Fvar%2Frun%2Fpostgresql&port=5432&sslmode=disable",
+		},
+		{
+			name: "tls",
+			cfg: DBConfig{
+				Host: "db", Port: "5432", User: "u", Pass: "p", Name: "app", SSLMode: "verify-full",
+				SSLRootCert: "/certs/ca.pem", SSLCert: "/certs/client.pem", SSLKey: "/certs/client.key",
+			},
+			want: "postgres://u:p@db:5432/app?sslcert=%2Fcerts%2Fclient.pem&sslkey=%2Fcerts%2Fclient.key&sslmode=verify-full&sslrootcert=%2Fcerts%2Fca.pem",
+		},
+		{
+			name: "url override",
+			cfg: DBConfig{
+				URL:    "postgres://u:p@db:5432/app?sslmode=require",
+				Host:   "ignored",
+				Params: url.Values{"application_name": {"shortener"}},
+			},
+			want: "postgres://u:p@db:5432/app?application_name=shortener&sslmode=require",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.cfg.DSN())
+		})
+	}
+}
+
+func TestDBConfig_ReplicaDSN(t *testing.T) {
+	cfg := DBConfig{
+		Host: "db", Port: "5432", User: "u", Pass: "p", Name: "app", SSLMode: "verify-full",
+		SSLRootCert: "/certs/ca.pem", SSLCert: "/certs/client.pem", SSLKey: "/certs/client.key",
+		Params: url.Values{"application_name": {"shortener"}},
+	}
+
+	assert.Equal(t,
+		"postgres://r:p@replica:5432/app?application_name=shortener&sslcert=%2Fcerts%2Fclient.pem&sslkey=%2Fcerts%2Fclient.key&sslmode=verify-full&sslrootcert=%2Fcerts%2Fca.pem",
+		cfg.ReplicaDSN("postgres://r:p@replica:5432/app?sslmode=verify-full"))
+}
+
+// setBaseEnv задаёт обязательные переменные для STORAGE=postgresql.
+func setBaseEnv(t *testing.T) {
+	t.Helper()
+
+	for k, v := range map[string]string{
+		keyLogLevel:          "info",
+		keyBaseURL:           "http://localhost",
+		keyStorage:           string(StoragePostgres),
+		keyHTTPHost:          "0.0.0.0",
+		keyHTTPPort:          "8080",
+		keyHTTPReadTimeout:   "1s",
+		keyHTTPWriteTimeout:  "1s",
+		keyHTTPIdleTimeout:   "1s",
+		keyDBHost:            "db",
+		keyDBPort:            "5432",
+		keyDBUser:            "u",
+		keyDBPass:            "p",
+		keyDBName:            "app",
+		keyDBSSLMode:         "disable",
+		keyPGMinConns:        "1",
+		keyPGMaxConns:        "2",
+		keyPGMaxConnLifeTime: "1m",
+		keyPGMaxIdleTime:     "1m",
+		keyAliasSecret:       "1",
+	} {
+		t.Setenv(k, v)
+	}
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+
+	p := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(p, []byte(content), 0o600))
+	return p
+}
+
+func TestNew_DB(t *testing.T) {
+	t.Run("pass file", func(t *testing.T) {
+		setBaseEnv(t)
+		t.Setenv(keyDBPass, "")
+		t.Setenv(keyDBPass+"_FILE", writeFile(t, "from-file\n"))
+
+		cfg, _, err := Load(nil)
+		require.NoError(t, err)
+		assert.Equal(t, "from-file", cfg.DB.Pass)
+	})
+
+	t.Run("database url file", func(t *testing.T) {
+		setBaseEnv(t)
+		t.Setenv(keyDBHost, "")
+		t.Setenv(keyDatabaseURL+"_FILE", writeFile(t, "postgres://u:p@db/app"))
+
+		cfg, _, err := Load(nil)
+		require.NoError(t, err)
+		assert.Equal(t, "postgres://u:p@db/app", cfg.DB.DSN())
+	})
+
+	errTests := []struct {
+		name string
+		env  map[string]string
+		key  string
+	}{
+		{
+			name: "pass and pass file",
+			env:  map[string]string{keyDBPass + "_FILE": "/run/secrets/db"},
+			key:  keyDBPass + "_FILE",
+		},
+		{
+			name: "missing pass file",
+			env:  map[string]string{keyDBPass: "", keyDBPass + "_FILE": "/nonexistent/secret"},
+			key:  keyDBPass + "_FILE",
+		},
+		{
+			name: "bad sslmode",
+			env:  map[string]string{keyDBSSLMode: "on"},
+			key:  keyDBSSLMode,
+		},
+		{
+			name: "url scheme",
+			env:  map[string]string{keyDatabaseURL: "mysql://db/app"},
+			key:  keyDatabaseURL,
+		},
+		{
+			name: "invalid url",
+			env:  map[string]string{keyDatabaseURL: "postgres://db:port/app"},
+			key:  keyDatabaseURL,
+		},
+		{
+			name: "replica url scheme",
+			env:  map[string]string{keyDBReplicaDSNs: "postgres://u:p@replica/app,mysql://replica/app"},
+			key:  keyDBReplicaDSNs,
+		},
+		{
+			name: "invalid replica url",
+			env:  map[string]string{keyDBReplicaDSNs: "postgres://replica:port/app"},
+			key:  keyDBReplicaDSNs,
+		},
+		{
+			name: "reserved param",
+			env:  map[string]string{keyDBParams: "sslmode=require"},
+			key:  keyDBSSLMode,
+		},
+		{
+			name: "bad params",
+			env:  map[string]string{keyDBParams: "a=%zz"},
+			key:  keyDBParams,
+		},
+		{
+			name: "cert without key",
+			env:  map[string]string{keyDBSSLCert: "/certs/client.pem"},
+			key:  keyDBSSLKey,
+		},
+		{
+			name: "missing root cert",
+			env:  map[string]string{keyDBSSLRootCert: "/nonexistent/ca.pem"},
+			key:  keyDBSSLRootCert,
+		},
+	}
+
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			setBaseEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			_, _, err := Load(nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.key)
+		})
+	}
+}
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	p := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(p, []byte(content), 0o600))
+	return p
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	t.Setenv(keyAliasSecret, "1")
+
+	cfg, cmd, err := Load(nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, StorageMemory, cfg.Storage)
+	assert.Equal(t, "8081", cfg.HTTP.Port)
+	assert.Equal(t, 5*time.Second, cfg.HTTP.ReadTimeout)
+	assert.Equal(t, FsyncInterval, cfg.Memory.Fsync)
+	assert.Equal(t, AliasStrategyCounter, cfg.Alias.Strategy)
+	assert.Equal(t, 10, cfg.Alias.Length)
+	assert.Equal(t, UnfurlConfig{
+		Workers:   4,
+		QueueSize: 1000,
+		Timeout:   5 * time.Second,
+		MaxBytes:  1 << 20,
+	}, cfg.Unfurl)
+	assert.False(t, cfg.HealthCheck.Enabled)
+	assert.Equal(t, 24*time.Hour, cfg.HealthCheck.Interval)
+	assert.Equal(t, 3, cfg.HealthCheck.FailureThreshold)
+	assert.Empty(t, cmd.Args)
+	assert.False(t, cmd.PrintConfig)
+}
+
+func TestLoad_Layers(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": `
+storage: sqlite
+http:
+  port: 9000
+  host: 127.0.0.1
+sqlite:
+  path: /tmp/from-file.sqlite
+alias:
+  secret: 42
+  length: 7
+`,
+		"config.toml": `
+storage = "sqlite"
+
+[http]
+port = 9000
+host = "127.0.0.1"
+
+[sqlite]
+path = "/tmp/from-file.sqlite"
+
+[alias]
+secret = 42
+length = 7
+`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := writeConfigFile(t, name, content)
+			t.Setenv(keyHTTPPort, "9100")
+
+			cfg, cmd, err := Load([]string{"--config", path, "--alias-length", "12", "links", "list"})
+			require.NoError(t, err)
+
+			assert.Equal(t, StorageSQLite, cfg.Storage, "file overrides default")
+			assert.Equal(t, "127.0.0.1", cfg.HTTP.Host, "file overrides default")
+			assert.Equal(t, "/tmp/from-file.sqlite", cfg.SQLite.Path)
+			assert.Equal(t, uint64(42), cfg.Alias.Secret)
+			assert.Equal(t, "9100", cfg.HTTP.Port, "env overrides file")
+			assert.Equal(t, 12, cfg.Alias.Length, "flag overrides file")
+			assert.Equal(t, []string{"links", "list"}, cmd.Args)
+		})
+	}
+}
+
+func TestLoad_ConfigFileEnv(t *testing.T) {
+	t.Setenv(keyConfigFile, writeConfigFile(t, "config.yml", "alias:\n  secret: 7\n"))
+
+	cfg, _, err := Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), cfg.Alias.Secret)
+}
+
+func TestLoad_SecretLayers(t *testing.T) {
+	// Секрет из окружения перекрывает файл с секретом, заданный в файле конфигурации
+	path := writeConfigFile(t, "config.yaml", "admin:\n  token_file: "+writeFile(t, "from-file\n")+"\n")
+	t.Setenv(keyAliasSecret, "1")
+	t.Setenv(keyAdminToken, "from-env")
+
+	cfg, _, err := Load([]string{"--config", path})
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", cfg.AdminToken)
+
+	cfg, _, err = Load([]string{"--config", path, "--admin-token-file", writeFile(t, "from-flag")})
+	require.NoError(t, err)
+	assert.Equal(t, "from-flag", cfg.AdminToken)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		args []string
+		want []string
+	}{
+		{
+			name: "all errors at once",
+			args: []string{"--http-read-timeout", "5x", "--alias-length", "0", "--alias-strategy", "nope"},
+			want: []string{
+				keyHTTPReadTimeout + `: not a valid duration: "5x" (from flag --http-read-timeout)`,
+				keyAliasLength + ": must be positive",
+				keyAliasStrategy + ": unknown alias strategy",
+				keyAliasSecret + ": not set",
+			},
+		},
+		{
+			name: "only selected storage is validated",
+			args: []string{"--storage", "bolt", "--memory-fsync", "nope", "--bolt-timeout", "soon"},
+			want: []string{keyBoltTimeout + ": not a valid duration"},
+		},
+		{
+			name: "unknown file key",
+			file: "http:\n  prot: 8080\nstorag: memory\n",
+			want: []string{`unknown key "http.prot"`, `unknown key "storag"`},
+		},
+		{
+			name: "invalid domains",
+			args: []string{
+				"--base-url", "localhost:8081",
+				"--root-redirect", "/home",
+				"--not-found-redirect", "/missing",
+				"--domains", "brand.link=ftp://brand.com,go.link|missing",
+			},
+			want: []string{
+				keyBaseURL + `: not an absolute http(s) URL: "localhost:8081"`,
+				keyRootRedirect + `: not an absolute http(s) URL: "/home"`,
+				keyNotFoundRedirect + `: not an absolute http(s) URL: "/missing"`,
+				keyDomains + `: domain brand.link: root redirect is not an absolute http(s) URL: "ftp://brand.com"`,
+				keyDomains + `: domain go.link: not found redirect is not an absolute http(s) URL: "missing"`,
+			},
+		},
+		{
+			name: "domain equals base url",
+			args: []string{"--base-url", "https://sho.rt", "--domains", "go.link,SHO.RT"},
+			want: []string{keyDomains + `: domains: "SHO.RT" is the default domain from base url`},
+		},
+		{
+			name: "invalid unfurl",
+			args: []string{"--unfurl-enabled", "true", "--unfurl-workers", "0", "--unfurl-max-bytes", "-1"},
+			want: []string{keyUnfurlWorkers + ": must be positive", keyUnfurlMaxBytes + ": must be positive"},
+		},
+		{
+			name: "invalid health check",
+			args: []string{"--health-check-enabled", "true", "--health-check-failure-threshold", "0", "--health-check-host-delay", "-1s"},
+			want: []string{
+				keyHealthCheckFailureThreshold + ": must be positive",
+				keyHealthCheckHostDelay + ": must not be negative",
+			},
+		},
+		{
+			name: "invalid dev mode",
+			args: []string{"--dev-mode", "maybe"},
+			want: []string{keyDevMode + `: not a valid bool: "maybe"`},
+		},
+		{
+			name: "alias length too long",
+			args: []string{"--alias-length", "65"},
+			want: []string{keyAliasLength + ": must be between 5 and 64 for counter strategy"},
+		},
+		{
+			name: "alias length too short for strategy",
+			args: []string{"--alias-strategy", "word", "--alias-length", "6"},
+			want: []string{keyAliasLength + ": must be between 8 and 64 for word strategy"},
+		},
+		{
+			name: "invalid admin import limit",
+			args: []string{"--admin-import-max-bytes", "0"},
+			want: []string{keyAdminImportMaxBytes + ": must be positive"},
+		},
+		{
+			name: "grpc on http port",
+			args: []string{"--grpc-enabled", "true", "--http-port", "9000", "--grpc-port", "9000"},
+			want: []string{keyGRPCPort + ": must differ from " + keyHTTPPort},
+		},
+		{
+			name: "invalid webhooks",
+			args: []string{
+				"--webhook-endpoints", "crm=https://crm.com/hook|url.moved,=https://x.com,ftp=ftp://x.com,crm=https://other.com",
+				"--webhook-click-thresholds", "10,0,many",
+				"--webhook-max-attempts", "0",
+			},
+			want: []string{
+				keyWebhookEndpoints + `: webhook crm: unknown event "url.moved"`,
+				keyWebhookEndpoints + `: webhook without name: "=https://x.com"`,
+				keyWebhookEndpoints + `: webhook ftp: not an absolute http(s) URL: "ftp://x.com"`,
+				keyWebhookEndpoints + `: duplicate webhook crm`,
+				keyWebhookClickThresholds + `: not a positive integer: "0"`,
+				keyWebhookClickThresholds + `: not a positive integer: "many"`,
+				keyWebhookMaxAttempts + ": must be positive",
+				keyWebhookSecret + ": required when " + keyWebhookEndpoints + " is set",
+			},
+		},
+		{
+			name: "unknown flag",
+			args: []string{"--no-such-flag"},
+			want: []string{"no-such-flag"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"--config", writeConfigFile(t, "config.yaml", tt.file)}, args...)
+			}
+
+			_, _, err := Load(args)
+			require.Error(t, err)
+			for _, want := range tt.want {
+				assert.Contains(t, err.Error(), want)
+			}
+			assert.NotContains(t, err.Error(), keyMemoryFsync)
+		})
+	}
+}
+
+func TestRedactDSN(t *testing.T) {
+	cases := []struct {
+		name string
+		dsn  string
+		want string
+	}{
+		{"userinfo password", "postgres://u:secret@h/db", "postgres://u:REDACTED@h/db"},
+		{"query password", "postgres://u@h/db?password=secret&sslmode=require", "postgres://u@h/db?password=REDACTED&sslmode=require"},
+		{"ssl password", "postgres://u@h/db?sslpassword=secret", "postgres://u@h/db?sslpassword=REDACTED"},
+		{"no password", "postgres://u@h/db?sslmode=disable", "postgres://u@h/db?sslmode=disable"},
+		{"no user", "postgres://h/db?password=secret", "REDACTED"},
+		{"invalid query", "postgres://u@h/db?password=%zz", "REDACTED"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, redactDSN(tc.dsn))
+		})
+	}
+}
+
+func TestConfig_Print(t *testing.T) {
+	setBaseEnv(t)
+	t.Setenv(keyDBPass, "db-secret")
+	t.Setenv(keyAliasSecret, "123456")
+	t.Setenv(keyDBReplicaDSNs, "postgres://u:replica-secret@r1/app,postgres://u@r2/app?password=query-secret")
+
+	cfg, _, err := Load([]string{"--http-port", "9000"})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, cfg.Print(&buf))
+	out := buf.String()
+
+	assert.NotContains(t, out, "db-secret")
+	assert.NotContains(t, out, "replica-secret")
+	assert.NotContains(t, out, "query-secret")
+	assert.NotContains(t, out, "123456")
+	assert.Contains(t, out, "pass: REDACTED # env")
+	assert.Contains(t, out, "replica_dsns: postgres://u:REDACTED@r1/app,postgres://u@r2/app?password=REDACTED # env")
+	assert.Contains(t, out, "port: 9000 # flag --http-port")
+	assert.Contains(t, out, "read_timeout: 1s # env")
+	assert.Contains(t, out, "strategy: counter # default")
+
+	// Вывод читается обратно как файл конфигурации
+	values, err := readFile(writeConfigFile(t, "printed.yaml", out))
+	require.NoError(t, err)
+	assert.Equal(t, "9000", values[keyHTTPPort])
+	assert.Equal(t, "", values[keyMemoryDataDir])
+}
+
+func TestConfig_Reloadable(t *testing.T) {
+	tokenFile := writeFile(t, "old-token")
+	t.Setenv(keyAliasSecret, "1")
+	t.Setenv(keyAdminToken+fileSuffix, tokenFile)
+
+	cur, _, err := Load(nil)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(tokenFile, []byte("new-token"), 0o600))
+	next, _, err := Load([]string{"--log-level", "debug", "--alias-max-attempts", "9", "--http-port", "9999"})
+	require.NoError(t, err)
+
+	reloadable, restart := cur.Changes(next)
+	assert.Equal(t, []string{keyLogLevel, keyAliasMaxAttempts, keyAdminToken}, reloadable)
+	assert.Equal(t, []string{keyHTTPPort}, restart)
+
+	merged := cur.WithReloadable(next)
+	assert.Equal(t, "debug", merged.LogLevel)
+	assert.Equal(t, 9, merged.Alias.MaxAttempts)
+	assert.Equal(t, "new-token", merged.AdminToken)
+	assert.Equal(t, "8081", merged.HTTP.Port, "restart-only changes are not applied")
+	assert.Equal(t, "info", cur.LogLevel, "original config is not modified")
+
+	// Повторная перезагрузка той же конфигурации ничего не меняет, кроме параметров, требующих перезапуска
+	reloadable, restart = merged.Changes(next)
+	assert.Empty(t, reloadable)
+	assert.Equal(t, []string{keyHTTPPort}, restart)
+}
+
+func TestLoad_InvalidLogLevel(t *testing.T) {
+	t.Setenv(keyAliasSecret, "1")
+
+	_, _, err := Load([]string{"--log-level", "loud"})
+	assert.ErrorContains(t, err, keyLogLevel)
+}
+
+func TestLoad_Domains(t *testing.T) {
+	file := writeConfigFile(t, "config.yaml", `
+base_url: https://sho.rt
+root_redirect: https://example.com
+not_found_redirect: https://example.com/404
+domains:
+  - brand.link=https://brand.com|https://brand.com/?ref=short
+  - go.brand.link
+  - promo.link|https://promo.com
+`)
+
+	cfg, _, err := Load([]string{"--config", file, "--alias-secret", "1"})
+	require.NoError(t, err)
+
+	assert.Equal(t, domains.Domain{
+		RootRedirect:     "https://example.com",
+		NotFoundRedirect: "https://example.com/404",
+	}, cfg.DefaultDomain())
+	assert.Equal(t, []domains.Domain{
+		{Name: "brand.link", RootRedirect: "https://brand.com", NotFoundRedirect: "https://brand.com/?ref=short"},
+		{Name: "go.brand.link"},
+		{Name: "promo.link", NotFoundRedirect: "https://promo.com"},
+	}, cfg.Domains)
+}
+
+func TestLoad_Webhooks(t *testing.T) {
+	file := writeConfigFile(t, "config.yaml", `
+webhook:
+  endpoints:
+    - crm=https://crm.com/hook
+    - stats=https://stats.com/hook|url.clicks; url.deleted
+  click_thresholds: 100,1000
+  max_attempts: 5
+`)
+	t.Setenv(keyWebhookSecret+fileSuffix, writeFile(t, "hook-secret\n"))
+
+	cfg, _, err := Load([]string{"--config", file, "--alias-secret", "1"})
+	require.NoError(t, err)
+
+	assert.Equal(t, WebhookConfig{
+		Endpoints: []model.Webhook{
+			{Name: "crm", URL: "https://crm.com/hook"},
+			{Name: "stats", URL: "https://stats.com/hook", Events: []model.EventType{model.EventClicks, model.EventDeleted}},
+		},
+		Secret:          "hook-secret",
+		ClickThresholds: []int64{100, 1000},
+		PollInterval:    time.Second,
+		Workers:         4,
+		Timeout:         10 * time.Second,
+		MaxAttempts:     5,
+		RetryBackoff:    30 * time.Second,
+	}, cfg.Webhook)
+}
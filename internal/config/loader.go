@@ -0,0 +1,207 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// layer — источник значения. Каждый следующий слой перекрывает предыдущие.
+type layer int
+
+const (
+	layerDefault layer = iota
+	layerFile
+	layerEnv
+	layerFlag
+)
+
+// value — значение параметра до разбора и его источник.
+type value struct {
+	raw   string
+	layer layer
+	// from описывает источник для ошибок и --print-config, например "file config.yaml".
+	from string
+}
+
+// Command — аргументы командной строки, оставшиеся после глобальных флагов.
+type Command struct {
+	// Args — команда и её аргументы.
+	Args []string
+	// PrintConfig — вывести итоговую конфигурацию и выйти.
+	PrintConfig bool
+}
+
+// Load собирает конфигурацию по слоям: значения по умолчанию, файл конфигурации
+// (--config или CONFIG_FILE), переменные окружения и .env, флаги из args.
+// Ошибки всех параметров возвращаются вместе.
+func Load(args []string) (*Config, *Command, error) {
+	if err := godotenv.Load(); err != nil {
+		if !os.IsNotExist(err) {
+			return nil, nil, fmt.Errorf("error loading .env file: %w", err)
+		}
+	}
+
+	f := newFlags()
+	if err := f.fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	values := make(map[string]value, len(settings))
+	for _, s := range settings {
+		if s.def != "" {
+			values[s.key] = value{raw: s.def, layer: layerDefault, from: "default"}
+		}
+	}
+
+	file := *f.configFile
+	if file == "" {
+		file = os.Getenv(keyConfigFile)
+	}
+	if file != "" {
+		fileValues, err := readFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		for k, v := range fileValues {
+			values[k] = value{raw: v, layer: layerFile, from: "file " + file}
+		}
+	}
+
+	for _, s := range settings {
+		if v, ok := os.LookupEnv(s.key); ok && v != "" {
+			values[s.key] = value{raw: v, layer: layerEnv, from: "env"}
+		}
+	}
+
+	f.fs.Visit(func(fl *flag.Flag) {
+		if key, ok := f.keys[fl.Name]; ok {
+			values[key] = value{raw: fl.Value.String(), layer: layerFlag, from: "flag --" + fl.Name}
+		}
+	})
+
+	cfg, err := build(values)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg.File = file
+
+	return cfg, &Command{Args: f.fs.Args(), PrintConfig: *f.printConfig}, nil
+}
+
+// flags — глобальные флаги командной строки.
+type flags struct {
+	fs *flag.FlagSet
+	// keys — соответствие имени флага параметра его переменной окружения.
+	keys map[string]string
+
+	configFile  *string
+	printConfig *bool
+}
+
+// newFlags создаёт флаги для всех параметров, --config и --print-config.
+func newFlags() *flags {
+	f := &flags{
+		fs:   flag.NewFlagSet("url-shortener", flag.ContinueOnError),
+		keys: make(map[string]string, len(settings)),
+	}
+	f.fs.SetOutput(io.Discard)
+
+	f.configFile = f.fs.String("config", "", "файл конфигурации YAML или TOML (то же, что "+keyConfigFile+")")
+	f.printConfig = f.fs.Bool("print-config", false, "вывести итоговую конфигурацию и выйти")
+	for _, s := range settings {
+		f.fs.String(s.flag(), "", "то же, что "+s.key)
+		f.keys[s.flag()] = s.key
+	}
+	return f
+}
+
+// PrintFlags выводит список глобальных флагов.
+func PrintFlags(w io.Writer) {
+	f := newFlags()
+	f.fs.SetOutput(w)
+	f.fs.PrintDefaults()
+}
+
+// readFile читает файл конфигурации и возвращает значения по именам переменных окружения.
+// Формат определяется по расширению: .yaml, .yml или .toml.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	tree := make(map[string]any)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format %q, expected .yaml, .yml or .toml", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	byPath := make(map[string]string, len(settings))
+	for _, s := range settings {
+		byPath[s.path] = s.key
+	}
+
+	var (
+		values = make(map[string]string)
+		errs   []error
+	)
+	flat := make(map[string]string)
+	flatten("", tree, flat)
+	for _, p := range slices.Sorted(maps.Keys(flat)) {
+		key, ok := byPath[p]
+		if !ok {
+			errs = append(errs, fmt.Errorf("config file %s: unknown key %q", path, p))
+			continue
+		}
+		values[key] = flat[p]
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return values, nil
+}
+
+// flatten раскладывает вложенные таблицы в ключи через точку: http: {port: 8081} -> http.port.
+// Списки склеиваются через запятую, как в переменных окружения.
+func flatten(prefix string, tree map[string]any, out map[string]string) {
+	for k, v := range tree {
+		p := k
+		if prefix != "" {
+			p = prefix + "." + k
+		}
+
+		switch v := v.(type) {
+		case map[string]any:
+			flatten(p, v, out)
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			out[p] = strings.Join(items, ",")
+		case nil:
+			out[p] = ""
+		default:
+			out[p] = fmt.Sprint(v)
+		}
+	}
+}
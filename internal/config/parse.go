@@ -0,0 +1,426 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/utils/domains"
+	"github.com/Rasulikus/url-shortener/internal/utils/validate"
+	"github.com/rs/zerolog"
+)
+
+// parser разбирает значения слоёв в типы и копит ошибки, чтобы сообщить обо всех сразу.
+type parser struct {
+	values map[string]value
+	errs   []error
+	// failed — параметры, для которых ошибка уже есть; дальнейшие проверки их пропускают.
+	failed map[string]bool
+}
+
+// errorf добавляет ошибку параметра key с указанием источника значения.
+func (p *parser) errorf(key, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if v, ok := p.values[key]; ok && v.raw != "" {
+		msg += " (from " + v.from + ")"
+	}
+	p.errs = append(p.errs, fmt.Errorf("%s: %s", key, msg))
+	p.failed[key] = true
+}
+
+// check добавляет ошибку разбора err, если она есть.
+func (p *parser) check(key string, err error) {
+	if err != nil {
+		p.errorf(key, "%v", err)
+	}
+}
+
+func (p *parser) str(key string) string {
+	return p.values[key].raw
+}
+
+func (p *parser) required(key string) string {
+	v := p.str(key)
+	if v == "" {
+		p.errorf(key, "not set")
+	}
+	return v
+}
+
+func (p *parser) int(key string) int {
+	v := p.required(key)
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		p.errorf(key, "not an integer: %q", v)
+	}
+	return n
+}
+
+func (p *parser) uint64(key string) uint64 {
+	v := p.required(key)
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		p.errorf(key, "not a valid uint64: %q", v)
+	}
+	return n
+}
+
+func (p *parser) bool(key string) bool {
+	v := p.required(key)
+	if v == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		p.errorf(key, "not a valid bool: %q", v)
+	}
+	return b
+}
+
+func (p *parser) duration(key string) time.Duration {
+	v := p.required(key)
+	if v == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		p.errorf(key, "not a valid duration: %q", v)
+	}
+	return d
+}
+
+func (p *parser) positive(key string, n int64) {
+	if n <= 0 && !p.failed[key] {
+		p.errorf(key, "must be positive")
+	}
+}
+
+// secret возвращает значение key или содержимое файла из key_FILE (например, Docker secret).
+// Если заданы оба, побеждает более поздний слой; оба в одном слое — ошибка.
+// Завершающий перевод строки в файле отбрасывается.
+func (p *parser) secret(key string) string {
+	fileKey := key + fileSuffix
+	v, f := p.values[key], p.values[fileKey]
+	switch {
+	case f.raw == "":
+		return v.raw
+	case v.raw != "" && v.layer == f.layer:
+		p.errorf(fileKey, "mutually exclusive with %s", key)
+		return ""
+	case v.raw != "" && v.layer > f.layer:
+		return v.raw
+	}
+
+	b, err := os.ReadFile(f.raw)
+	if err != nil {
+		p.check(fileKey, err)
+		return ""
+	}
+	return strings.TrimRight(string(b), "\r\n")
+}
+
+// domains разбирает список доменов вида host[=root_redirect_url][|not_found_url] через запятую.
+// Символ | в URL должен быть закодирован, поэтому он разделяет адреса однозначно.
+func (p *parser) domains(key string) []domains.Domain {
+	var list []domains.Domain
+	for _, item := range splitList(p.str(key)) {
+		item, notFound, _ := strings.Cut(item, "|")
+		host, redirect, _ := strings.Cut(item, "=")
+		d := domains.Domain{
+			Name:             strings.TrimSpace(host),
+			RootRedirect:     strings.TrimSpace(redirect),
+			NotFoundRedirect: strings.TrimSpace(notFound),
+		}
+		if d.RootRedirect != "" && !isAbsoluteURL(d.RootRedirect) {
+			p.errorf(key, "domain %s: root redirect is not an absolute http(s) URL: %q", d.Name, d.RootRedirect)
+			continue
+		}
+		if d.NotFoundRedirect != "" && !isAbsoluteURL(d.NotFoundRedirect) {
+			p.errorf(key, "domain %s: not found redirect is not an absolute http(s) URL: %q", d.Name, d.NotFoundRedirect)
+			continue
+		}
+		list = append(list, d)
+	}
+	return list
+}
+
+// webhooks разбирает подписки в формате name=url или name=url|event;event.
+// Без списка событий подписка получает все события.
+func (p *parser) webhooks(key string) []model.Webhook {
+	var (
+		list  []model.Webhook
+		names = make(map[string]bool)
+	)
+	for _, item := range splitList(p.str(key)) {
+		item, events, _ := strings.Cut(item, "|")
+		name, u, _ := strings.Cut(item, "=")
+		w := model.Webhook{Name: strings.TrimSpace(name), URL: strings.TrimSpace(u)}
+		if w.Name == "" {
+			p.errorf(key, "webhook without name: %q", item)
+			continue
+		}
+		if names[w.Name] {
+			p.errorf(key, "duplicate webhook %s", w.Name)
+			continue
+		}
+		names[w.Name] = true
+		if !isAbsoluteURL(w.URL) {
+			p.errorf(key, "webhook %s: not an absolute http(s) URL: %q", w.Name, w.URL)
+			continue
+		}
+		for _, e := range strings.Split(events, ";") {
+			if e = strings.TrimSpace(e); e == "" {
+				continue
+			}
+			if !slices.Contains(model.EventTypes, model.EventType(e)) {
+				p.errorf(key, "webhook %s: unknown event %q", w.Name, e)
+				continue
+			}
+			w.Events = append(w.Events, model.EventType(e))
+		}
+		list = append(list, w)
+	}
+	return list
+}
+
+// thresholds разбирает список положительных целых через запятую.
+func (p *parser) thresholds(key string) []int64 {
+	var list []int64
+	for _, v := range splitList(p.str(key)) {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			p.errorf(key, "not a positive integer: %q", v)
+			continue
+		}
+		list = append(list, n)
+	}
+	return list
+}
+
+// isAbsoluteURL сообщает, что s — URL со схемой http или https и хостом.
+func isAbsoluteURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// build разбирает значения в Config. Параметры хранилищ, кроме выбранного, не проверяются.
+func build(values map[string]value) (*Config, error) {
+	p := &parser{values: values, failed: make(map[string]bool)}
+	cfg := &Config{values: values}
+	var err error
+
+	cfg.LogLevel = p.required(keyLogLevel)
+	if cfg.LogLevel != "" {
+		if _, err := zerolog.ParseLevel(cfg.LogLevel); err != nil {
+			p.errorf(keyLogLevel, "unknown level %q", cfg.LogLevel)
+		}
+	}
+	cfg.DevMode = p.bool(keyDevMode)
+	cfg.WatchInterval = p.duration(keyConfigWatchInterval)
+	if cfg.WatchInterval < 0 {
+		p.errorf(keyConfigWatchInterval, "must not be negative")
+	}
+	cfg.BaseURL = p.required(keyBaseURL)
+	if cfg.BaseURL != "" && !isAbsoluteURL(cfg.BaseURL) {
+		p.errorf(keyBaseURL, "not an absolute http(s) URL: %q", cfg.BaseURL)
+	}
+	cfg.RootRedirect = p.str(keyRootRedirect)
+	if cfg.RootRedirect != "" && !isAbsoluteURL(cfg.RootRedirect) {
+		p.errorf(keyRootRedirect, "not an absolute http(s) URL: %q", cfg.RootRedirect)
+	}
+	cfg.NotFoundRedirect = p.str(keyNotFoundRedirect)
+	if cfg.NotFoundRedirect != "" && !isAbsoluteURL(cfg.NotFoundRedirect) {
+		p.errorf(keyNotFoundRedirect, "not an absolute http(s) URL: %q", cfg.NotFoundRedirect)
+	}
+	cfg.Domains = p.domains(keyDomains)
+	if !p.failed[keyBaseURL] && !p.failed[keyDomains] {
+		_, err := domains.New(cfg.BaseURL, cfg.DefaultDomain(), cfg.Domains)
+		p.check(keyDomains, err)
+	}
+	cfg.PagesDir = p.str(keyPagesDir)
+
+	cfg.Storage, err = ParseStorage(p.str(keyStorage))
+	p.check(keyStorage, err)
+
+	cfg.HTTP.Host = p.required(keyHTTPHost)
+	cfg.HTTP.Port = p.required(keyHTTPPort)
+	cfg.HTTP.ReadTimeout = p.duration(keyHTTPReadTimeout)
+	cfg.HTTP.WriteTimeout = p.duration(keyHTTPWriteTimeout)
+	cfg.HTTP.IdleTimeout = p.duration(keyHTTPIdleTimeout)
+
+	cfg.GRPC = GRPCConfig{
+		Enabled: p.bool(keyGRPCEnabled),
+		Host:    p.required(keyGRPCHost),
+		Port:    p.required(keyGRPCPort),
+	}
+	if cfg.GRPC.Enabled && cfg.GRPC.Port == cfg.HTTP.Port && cfg.GRPC.Host == cfg.HTTP.Host {
+		p.errorf(keyGRPCPort, "must differ from %s", keyHTTPPort)
+	}
+
+	switch cfg.Storage {
+	case StorageMemory:
+		cfg.Memory = &MemoryConfig{
+			DataDir:          p.str(keyMemoryDataDir),
+			FsyncInterval:    p.duration(keyMemoryFsyncInterval),
+			SnapshotInterval: p.duration(keyMemorySnapshotInterval),
+			LockTimeout:      p.duration(keyMemoryLockTimeout),
+		}
+		cfg.Memory.Fsync, err = ParseFsyncPolicy(p.str(keyMemoryFsync))
+		p.check(keyMemoryFsync, err)
+		if cfg.Memory.Fsync == FsyncInterval {
+			p.positive(keyMemoryFsyncInterval, int64(cfg.Memory.FsyncInterval))
+		}
+		if cfg.Memory.SnapshotInterval < 0 {
+			p.errorf(keyMemorySnapshotInterval, "must not be negative")
+		}
+	case StoragePostgres:
+		cfg.DB = &DBConfig{
+			URL:         p.secret(keyDatabaseURL),
+			SSLRootCert: p.str(keyDBSSLRootCert),
+			SSLCert:     p.str(keyDBSSLCert),
+			SSLKey:      p.str(keyDBSSLKey),
+
+			MinConns:        p.int(keyPGMinConns),
+			MaxConns:        p.int(keyPGMaxConns),
+			MaxConnLifetime: p.duration(keyPGMaxConnLifeTime),
+			MaxConnIdleTime: p.duration(keyPGMaxIdleTime),
+
+			IDBlockSize: p.uint64(keyIDBlockSize),
+			AutoMigrate: p.bool(keyDBAutoMigrate),
+
+			ReplicaDSNs:           splitList(p.str(keyDBReplicaDSNs)),
+			ReplicaHealthInterval: p.duration(keyDBReplicaHealthInterval),
+			ReplicaStickyWindow:   p.duration(keyDBReplicaStickyWindow),
+		}
+		if cfg.DB.URL == "" {
+			cfg.DB.Host = p.required(keyDBHost)
+			cfg.DB.Port = p.required(keyDBPort)
+			cfg.DB.User = p.required(keyDBUser)
+			cfg.DB.Pass = p.secret(keyDBPass)
+			if cfg.DB.Pass == "" && p.str(keyDBPass+fileSuffix) == "" {
+				p.errorf(keyDBPass, "not set (or set %s%s)", keyDBPass, fileSuffix)
+			}
+			cfg.DB.Name = p.required(keyDBName)
+			cfg.DB.SSLMode = p.required(keyDBSSLMode)
+		}
+		cfg.DB.Params, err = url.ParseQuery(p.str(keyDBParams))
+		if err != nil {
+			p.errorf(keyDBParams, "not a valid query string: %v", err)
+		}
+		p.positive(keyIDBlockSize, int64(cfg.DB.IDBlockSize))
+		p.positive(keyDBReplicaHealthInterval, int64(cfg.DB.ReplicaHealthInterval))
+
+		if err := cfg.DB.validate(); err != nil {
+			p.errs = append(p.errs, err)
+		}
+		// Строку подключения целиком проверяем, только если каждый параметр разобрался
+		if len(p.errs) == 0 {
+			if err := cfg.DB.validateDSN(); err != nil {
+				p.errs = append(p.errs, err)
+			}
+		}
+	case StorageBolt:
+		cfg.Bolt = &BoltConfig{
+			Path:    p.required(keyBoltPath),
+			Timeout: p.duration(keyBoltTimeout),
+		}
+	case StorageSQLite:
+		cfg.SQLite = &SQLiteConfig{
+			Path:        p.required(keySQLitePath),
+			BusyTimeout: p.duration(keySQLiteBusyTimeout),
+			IDBlockSize: p.uint64(keyIDBlockSize),
+		}
+		p.positive(keyIDBlockSize, int64(cfg.SQLite.IDBlockSize))
+	}
+
+	cfg.Alias.Secret = p.uint64(keyAliasSecret)
+	cfg.Alias.Strategy, err = ParseAliasStrategy(p.str(keyAliasStrategy))
+	p.check(keyAliasStrategy, err)
+	cfg.Alias.Length = p.int(keyAliasLength)
+	// Алиасы длиннее validate.MaxAliasLength не прошли бы экспорт и импорт
+	if minLen, ok := minAliasLength[cfg.Alias.Strategy]; ok && !p.failed[keyAliasLength] {
+		if cfg.Alias.Length < minLen || cfg.Alias.Length > validate.MaxAliasLength {
+			p.errorf(keyAliasLength, "must be between %d and %d for %s strategy", minLen, validate.MaxAliasLength, cfg.Alias.Strategy)
+		}
+	} else {
+		p.positive(keyAliasLength, int64(cfg.Alias.Length))
+	}
+	cfg.Alias.MaxAttempts = p.int(keyAliasMaxAttempts)
+	p.positive(keyAliasMaxAttempts, int64(cfg.Alias.MaxAttempts))
+	cfg.Alias.RetryBackoff = p.duration(keyAliasRetryBackoff)
+
+	cfg.AdminToken = p.secret(keyAdminToken)
+	cfg.AdminImportMaxBytes = int64(p.int(keyAdminImportMaxBytes))
+	p.positive(keyAdminImportMaxBytes, cfg.AdminImportMaxBytes)
+
+	cfg.Unfurl = UnfurlConfig{
+		Enabled:      p.bool(keyUnfurlEnabled),
+		Workers:      p.int(keyUnfurlWorkers),
+		QueueSize:    p.int(keyUnfurlQueueSize),
+		Timeout:      p.duration(keyUnfurlTimeout),
+		MaxBytes:     int64(p.int(keyUnfurlMaxBytes)),
+		AllowPrivate: p.bool(keyUnfurlAllowPrivate),
+	}
+	if cfg.Unfurl.Enabled {
+		p.positive(keyUnfurlWorkers, int64(cfg.Unfurl.Workers))
+		p.positive(keyUnfurlQueueSize, int64(cfg.Unfurl.QueueSize))
+		p.positive(keyUnfurlTimeout, int64(cfg.Unfurl.Timeout))
+		p.positive(keyUnfurlMaxBytes, cfg.Unfurl.MaxBytes)
+	}
+
+	cfg.HealthCheck = HealthCheckConfig{
+		Enabled:          p.bool(keyHealthCheckEnabled),
+		Interval:         p.duration(keyHealthCheckInterval),
+		Workers:          p.int(keyHealthCheckWorkers),
+		Timeout:          p.duration(keyHealthCheckTimeout),
+		HostDelay:        p.duration(keyHealthCheckHostDelay),
+		FailureThreshold: p.int(keyHealthCheckFailureThreshold),
+		AllowPrivate:     p.bool(keyHealthCheckAllowPrivate),
+	}
+	if cfg.HealthCheck.Enabled {
+		p.positive(keyHealthCheckInterval, int64(cfg.HealthCheck.Interval))
+		p.positive(keyHealthCheckWorkers, int64(cfg.HealthCheck.Workers))
+		p.positive(keyHealthCheckTimeout, int64(cfg.HealthCheck.Timeout))
+		p.positive(keyHealthCheckFailureThreshold, int64(cfg.HealthCheck.FailureThreshold))
+		if cfg.HealthCheck.HostDelay < 0 {
+			p.errorf(keyHealthCheckHostDelay, "must not be negative")
+		}
+	}
+
+	cfg.Webhook = WebhookConfig{
+		Endpoints:       p.webhooks(keyWebhookEndpoints),
+		Secret:          p.secret(keyWebhookSecret),
+		ClickThresholds: p.thresholds(keyWebhookClickThresholds),
+		PollInterval:    p.duration(keyWebhookPollInterval),
+		Workers:         p.int(keyWebhookWorkers),
+		Timeout:         p.duration(keyWebhookTimeout),
+		MaxAttempts:     p.int(keyWebhookMaxAttempts),
+		RetryBackoff:    p.duration(keyWebhookRetryBackoff),
+	}
+	// Рассылка работает и без подписок: она очищает outbox
+	p.positive(keyWebhookPollInterval, int64(cfg.Webhook.PollInterval))
+	p.positive(keyWebhookWorkers, int64(cfg.Webhook.Workers))
+	p.positive(keyWebhookTimeout, int64(cfg.Webhook.Timeout))
+	p.positive(keyWebhookMaxAttempts, int64(cfg.Webhook.MaxAttempts))
+	p.positive(keyWebhookRetryBackoff, int64(cfg.Webhook.RetryBackoff))
+	if len(cfg.Webhook.Endpoints) > 0 && cfg.Webhook.Secret == "" && !p.failed[keyWebhookSecret+fileSuffix] {
+		p.errorf(keyWebhookSecret, "required when %s is set", keyWebhookEndpoints)
+	}
+
+	if len(p.errs) > 0 {
+		return nil, errors.Join(p.errs...)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Print выводит итоговую конфигурацию в формате файла конфигурации YAML.
+// У каждого значения в комментарии указан источник, секреты скрыты.
+func (c *Config) Print(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	sections := make(map[string]*yaml.Node)
+
+	for _, s := range settings {
+		parent, name := root, s.path
+		if section, field, ok := strings.Cut(s.path, "."); ok {
+			if sections[section] == nil {
+				sections[section] = &yaml.Node{Kind: yaml.MappingNode}
+				root.Content = append(root.Content,
+					&yaml.Node{Kind: yaml.ScalarNode, Value: section},
+					sections[section],
+				)
+			}
+			parent, name = sections[section], field
+		}
+
+		v := c.values[s.key]
+		node := &yaml.Node{Kind: yaml.ScalarNode, Value: v.raw}
+		if v.raw != "" {
+			if s.redact != nil {
+				node.Value = s.redact(v.raw)
+			}
+			node.LineComment = v.from
+		}
+
+		parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, node)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	return enc.Close()
+}
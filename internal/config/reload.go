@@ -0,0 +1,44 @@
+package config
+
+// Changes возвращает параметры, значения которых в next отличаются от c:
+// reloadable применяются без перезапуска, restart — только после него.
+func (c *Config) Changes(next *Config) (reloadable, restart []string) {
+	for _, s := range settings {
+		changed := c.values[s.key].raw != next.values[s.key].raw
+		// Токен из ADMIN_TOKEN_FILE меняется при том же пути к файлу
+		if s.key == keyAdminToken && c.AdminToken != next.AdminToken {
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		if s.reload {
+			reloadable = append(reloadable, s.key)
+		} else {
+			restart = append(restart, s.key)
+		}
+	}
+	return reloadable, restart
+}
+
+// WithReloadable возвращает копию c, в которой параметры, применяемые без перезапуска,
+// взяты из next. Остальные параметры остаются как в c.
+func (c *Config) WithReloadable(next *Config) *Config {
+	merged := *c
+	merged.LogLevel = next.LogLevel
+	merged.Alias.MaxAttempts = next.Alias.MaxAttempts
+	merged.Alias.RetryBackoff = next.Alias.RetryBackoff
+	merged.AdminToken = next.AdminToken
+
+	merged.values = make(map[string]value, len(c.values))
+	for _, s := range settings {
+		src := c.values
+		if s.reload {
+			src = next.values
+		}
+		if v, ok := src[s.key]; ok {
+			merged.values[s.key] = v
+		}
+	}
+	return &merged
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+)
+
+// setting — параметр конфигурации и его имена в разных источниках:
+// переменная окружения key, ключ path в файле и флаг, производный от path.
+type setting struct {
+	key  string
+	path string
+	def  string
+
+	// redact скрывает секрет при выводе конфигурации. nil — значение выводится как есть.
+	redact func(string) string
+	// reload — параметр применяется без перезапуска, см. Config.WithReloadable.
+	reload bool
+}
+
+// flag возвращает имя флага командной строки: http.read_timeout -> http-read-timeout.
+func (s setting) flag() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(s.path)
+}
+
+// settings — все параметры в порядке вывода --print-config.
+var settings = []setting{
+	{key: keyLogLevel, path: "log_level", def: "info", reload: true},
+	{key: keyDevMode, path: "dev_mode", def: "false"},
+	{key: keyBaseURL, path: "base_url", def: "http://localhost:8081"},
+	{key: keyRootRedirect, path: "root_redirect"},
+	{key: keyNotFoundRedirect, path: "not_found_redirect"},
+	{key: keyDomains, path: "domains"},
+	{key: keyPagesDir, path: "pages_dir"},
+	{key: keyStorage, path: "storage", def: string(StorageMemory)},
+	{key: keyIDBlockSize, path: "id_block_size", def: "1000"},
+	{key: keyConfigWatchInterval, path: "config_watch_interval", def: "5s"},
+
+	{key: keyHTTPHost, path: "http.host", def: "0.0.0.0"},
+	{key: keyHTTPPort, path: "http.port", def: "8081"},
+	{key: keyHTTPReadTimeout, path: "http.read_timeout", def: "5s"},
+	{key: keyHTTPWriteTimeout, path: "http.write_timeout", def: "10s"},
+	{key: keyHTTPIdleTimeout, path: "http.idle_timeout", def: "60s"},
+
+	{key: keyGRPCEnabled, path: "grpc.enabled", def: "false"},
+	{key: keyGRPCHost, path: "grpc.host", def: "0.0.0.0"},
+	{key: keyGRPCPort, path: "grpc.port", def: "9090"},
+
+	{key: keyDatabaseURL, path: "db.url", redact: redactDSN},
+	{key: keyDatabaseURL + fileSuffix, path: "db.url_file"},
+	{key: keyDBHost, path: "db.host", def: "localhost"},
+	{key: keyDBPort, path: "db.port", def: "5432"},
+	{key: keyDBUser, path: "db.user", def: "postgres"},
+	{key: keyDBPass, path: "db.pass", redact: redactSecret},
+	{key: keyDBPass + fileSuffix, path: "db.pass_file"},
+	{key: keyDBName, path: "db.name", def: "url-shortener"},
+	{key: keyDBSSLMode, path: "db.sslmode", def: "prefer"},
+	{key: keyDBSSLRootCert, path: "db.sslrootcert"},
+	{key: keyDBSSLCert, path: "db.sslcert"},
+	{key: keyDBSSLKey, path: "db.sslkey"},
+	{key: keyDBParams, path: "db.params"},
+	{key: keyPGMinConns, path: "db.min_conns", def: "5"},
+	{key: keyPGMaxConns, path: "db.max_conns", def: "30"},
+	{key: keyPGMaxConnLifeTime, path: "db.max_conn_lifetime", def: "30m"},
+	{key: keyPGMaxIdleTime, path: "db.max_conn_idle_time", def: "5m"},
+	{key: keyDBAutoMigrate, path: "db.auto_migrate", def: "false"},
+	{key: keyDBReplicaDSNs, path: "db.replica_dsns", redact: redactDSNList},
+	{key: keyDBReplicaHealthInterval, path: "db.replica_health_interval", def: "5s"},
+	{key: keyDBReplicaStickyWindow, path: "db.replica_sticky_window", def: "5s"},
+
+	{key: keyMemoryDataDir, path: "memory.data_dir"},
+	{key: keyMemoryFsync, path: "memory.fsync", def: string(FsyncInterval)},
+	{key: keyMemoryFsyncInterval, path: "memory.fsync_interval", def: "1s"},
+	{key: keyMemorySnapshotInterval, path: "memory.snapshot_interval", def: "5m"},
+	{key: keyMemoryLockTimeout, path: "memory.lock_timeout", def: "5s"},
+
+	{key: keyBoltPath, path: "bolt.path", def: "data/url-shortener.db"},
+	{key: keyBoltTimeout, path: "bolt.timeout", def: "5s"},
+
+	{key: keySQLitePath, path: "sqlite.path", def: "data/url-shortener.sqlite"},
+	{key: keySQLiteBusyTimeout, path: "sqlite.busy_timeout", def: "5s"},
+
+	{key: keyAliasSecret, path: "alias.secret", redact: redactSecret},
+	{key: keyAliasStrategy, path: "alias.strategy", def: string(AliasStrategyCounter)},
+	{key: keyAliasLength, path: "alias.length", def: "10"},
+	{key: keyAliasMaxAttempts, path: "alias.max_attempts", def: "5", reload: true},
+	{key: keyAliasRetryBackoff, path: "alias.retry_backoff", def: "10ms", reload: true},
+
+	{key: keyAdminToken, path: "admin.token", redact: redactSecret, reload: true},
+	{key: keyAdminToken + fileSuffix, path: "admin.token_file", reload: true},
+	{key: keyAdminImportMaxBytes, path: "admin.import_max_bytes", def: "67108864"},
+
+	{key: keyUnfurlEnabled, path: "unfurl.enabled", def: "false"},
+	{key: keyUnfurlWorkers, path: "unfurl.workers", def: "4"},
+	{key: keyUnfurlQueueSize, path: "unfurl.queue_size", def: "1000"},
+	{key: keyUnfurlTimeout, path: "unfurl.timeout", def: "5s"},
+	{key: keyUnfurlMaxBytes, path: "unfurl.max_bytes", def: "1048576"},
+	{key: keyUnfurlAllowPrivate, path: "unfurl.allow_private", def: "false"},
+
+	{key: keyHealthCheckEnabled, path: "health_check.enabled", def: "false"},
+	{key: keyHealthCheckInterval, path: "health_check.interval", def: "24h"},
+	{key: keyHealthCheckWorkers, path: "health_check.workers", def: "4"},
+	{key: keyHealthCheckTimeout, path: "health_check.timeout", def: "10s"},
+	{key: keyHealthCheckHostDelay, path: "health_check.host_delay", def: "1s"},
+	{key: keyHealthCheckFailureThreshold, path: "health_check.failure_threshold", def: "3"},
+	{key: keyHealthCheckAllowPrivate, path: "health_check.allow_private", def: "false"},
+
+	{key: keyWebhookEndpoints, path: "webhook.endpoints"},
+	{key: keyWebhookSecret, path: "webhook.secret", redact: redactSecret},
+	{key: keyWebhookSecret + fileSuffix, path: "webhook.secret_file"},
+	{key: keyWebhookClickThresholds, path: "webhook.click_thresholds"},
+	{key: keyWebhookPollInterval, path: "webhook.poll_interval", def: "1s"},
+	{key: keyWebhookWorkers, path: "webhook.workers", def: "4"},
+	{key: keyWebhookTimeout, path: "webhook.timeout", def: "10s"},
+	{key: keyWebhookMaxAttempts, path: "webhook.max_attempts", def: "10"},
+	{key: keyWebhookRetryBackoff, path: "webhook.retry_backoff", def: "30s"},
+}
+
+const redacted = "REDACTED"
+
+func redactSecret(string) string {
+	return redacted
+}
+
+// dsnSecretParams — параметры строки подключения, в которых передаются пароли.
+var dsnSecretParams = []string{"password", "sslpassword"}
+
+// redactDSN скрывает пароль в URL подключения, в том числе в параметрах запроса,
+// оставляя хост и базу для отладки.
+func redactDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || u.User == nil {
+		return redacted
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+
+	if u.RawQuery != "" {
+		q, err := url.ParseQuery(u.RawQuery)
+		if err != nil {
+			return redacted
+		}
+		for _, p := range dsnSecretParams {
+			if q.Has(p) {
+				q.Set(p, redacted)
+			}
+		}
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
+
+func redactDSNList(list string) string {
+	dsns := splitList(list)
+	for i, dsn := range dsns {
+		dsns[i] = redactDSN(dsn)
+	}
+	return strings.Join(dsns, ",")
+}
+
+// splitList возвращает непустые элементы списка через запятую.
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
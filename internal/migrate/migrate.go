@@ -0,0 +1,334 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+// lockKey — ключ advisory lock, под которым выполняются миграции, чтобы
+// несколько экземпляров не применяли их одновременно.
+const lockKey int64 = 7_368_412_905
+
+var (
+	ErrDirty      = errors.New("migrate: database is dirty")
+	ErrNoChange   = errors.New("migrate: no change")
+	ErrNoDownFile = errors.New("migrate: down migration not found")
+)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	// Version — текущая версия схемы, 0 если миграции не применялись.
+	Version int64
+	Dirty   bool
+	// Applied и Pending — применённые и ожидающие миграции по возрастанию версии.
+	Applied []Migration
+	Pending []Migration
+}
+
+// Migrator применяет миграции к Postgres. Версия хранится в таблице
+// schema_migrations в том же формате, что и у golang-migrate, поэтому его
+// можно использовать вместе с контейнером migrate из docker-compose.
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+func New(pool *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	if pool == nil {
+		return nil, errors.New("migrate: pgx pool is nil")
+	}
+
+	migrations, err := Parse(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		pool:       pool,
+		migrations: migrations,
+	}, nil
+}
+
+// Parse читает файлы миграций из корня fsys и сортирует их по версии.
+func Parse(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migrate: read dir: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".sql" {
+			continue
+		}
+
+		version, name, direction, err := parseName(e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("migrate: read %s: %w", e.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("migrate: version %d has different names: %q and %q", version, m.Name, name)
+		}
+
+		switch direction {
+		case "up":
+			m.Up = string(body)
+		case "down":
+			m.Down = string(body)
+		}
+	}
+
+	out := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migrate: version %d has no up migration", m.Version)
+		}
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+
+	return out, nil
+}
+
+// parseName разбирает имя вида 0001_init.up.sql.
+func parseName(file string) (int64, string, string, error) {
+	base := strings.TrimSuffix(file, ".sql")
+
+	dot := strings.LastIndexByte(base, '.')
+	if dot < 0 {
+		return 0, "", "", fmt.Errorf("migrate: invalid file name %q", file)
+	}
+	direction := base[dot+1:]
+	if direction != "up" && direction != "down" {
+		return 0, "", "", fmt.Errorf("migrate: invalid direction in %q", file)
+	}
+	base = base[:dot]
+
+	us := strings.IndexByte(base, '_')
+	if us <= 0 {
+		return 0, "", "", fmt.Errorf("migrate: invalid file name %q", file)
+	}
+
+	version, err := strconv.ParseInt(base[:us], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, "", "", fmt.Errorf("migrate: invalid version in %q", file)
+	}
+
+	return version, base[us+1:], direction, nil
+}
+
+// Up применяет все ожидающие миграции.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		version, err := m.current(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		applied := 0
+		for _, mg := range m.migrations {
+			if mg.Version <= version {
+				continue
+			}
+
+			if err := m.apply(ctx, conn, mg.Up, mg.Version); err != nil {
+				return fmt.Errorf("migrate: up %d_%s: %w", mg.Version, mg.Name, err)
+			}
+			applied++
+
+			log.Info().
+				Int64("version", mg.Version).
+				Str("name", mg.Name).
+				Msg("migration applied")
+		}
+
+		if applied == 0 {
+			return ErrNoChange
+		}
+		return nil
+	})
+}
+
+// Down откатывает steps последних применённых миграций.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("migrate: steps must be positive: %d", steps)
+	}
+
+	return m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		version, err := m.current(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			mg := m.migrations[i]
+			if mg.Version > version {
+				continue
+			}
+			if mg.Down == "" {
+				return fmt.Errorf("%w: %d_%s", ErrNoDownFile, mg.Version, mg.Name)
+			}
+
+			var prev int64
+			if i > 0 {
+				prev = m.migrations[i-1].Version
+			}
+
+			if err := m.apply(ctx, conn, mg.Down, prev); err != nil {
+				return fmt.Errorf("migrate: down %d_%s: %w", mg.Version, mg.Name, err)
+			}
+			steps--
+
+			log.Info().
+				Int64("version", mg.Version).
+				Str("name", mg.Name).
+				Msg("migration rolled back")
+		}
+
+		return nil
+	})
+}
+
+// Status возвращает текущую версию схемы и список применённых и ожидающих миграций.
+func (m *Migrator) Status(ctx context.Context) (*Status, error) {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: acquire conn: %w", err)
+	}
+	defer conn.Release()
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return nil, err
+	}
+
+	st := new(Status)
+	st.Version, st.Dirty, err = readVersion(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mg := range m.migrations {
+		if mg.Version <= st.Version {
+			st.Applied = append(st.Applied, mg)
+		} else {
+			st.Pending = append(st.Pending, mg)
+		}
+	}
+	return st, nil
+}
+
+// withLock выполняет fn на отдельном соединении под advisory lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate: acquire conn: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("migrate: advisory lock: %w", err)
+	}
+	defer func() {
+		// Контекст мог быть отменён, но блокировку нужно снять в любом случае
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey); err != nil {
+			log.Error().Err(err).Msg("failed to release migration lock")
+		}
+	}()
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func (m *Migrator) current(ctx context.Context, conn *pgxpool.Conn) (int64, error) {
+	version, dirty, err := readVersion(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w at version %d, fix the schema and reset the version manually", ErrDirty, version)
+	}
+	return version, nil
+}
+
+// apply выполняет sql и записывает новую версию в одной транзакции.
+func (m *Migrator) apply(ctx context.Context, conn *pgxpool.Conn, sql string, version int64) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `TRUNCATE schema_migrations`); err != nil {
+		return err
+	}
+	if version > 0 {
+		if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, version); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func ensureTable(ctx context.Context, conn *pgxpool.Conn) error {
+	const q = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL PRIMARY KEY,
+		dirty BOOLEAN NOT NULL
+	);
+`
+	if _, err := conn.Exec(ctx, q); err != nil {
+		return fmt.Errorf("migrate: create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func readVersion(ctx context.Context, conn *pgxpool.Conn) (int64, bool, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+	err := conn.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("migrate: read version: %w", err)
+	}
+	return version, dirty, nil
+}
@@ -0,0 +1,63 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/Rasulikus/url-shortener/migrations"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_second.up.sql":   {Data: []byte("up 2")},
+		"0002_second.down.sql": {Data: []byte("down 2")},
+		"0001_init.up.sql":     {Data: []byte("up 1")},
+		"0001_init.down.sql":   {Data: []byte("down 1")},
+		"0010_no_down.up.sql":  {Data: []byte("up 10")},
+		"README.md":            {Data: []byte("ignored")},
+	}
+
+	got, err := Parse(fsys)
+	require.NoError(t, err)
+	require.Equal(t, []Migration{
+		{Version: 1, Name: "init", Up: "up 1", Down: "down 1"},
+		{Version: 2, Name: "second", Up: "up 2", Down: "down 2"},
+		{Version: 10, Name: "no_down", Up: "up 10"},
+	}, got)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	cases := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"no version", fstest.MapFS{"init.up.sql": {Data: []byte("x")}}},
+		{"bad version", fstest.MapFS{"abc_init.up.sql": {Data: []byte("x")}}},
+		{"bad direction", fstest.MapFS{"0001_init.sideways.sql": {Data: []byte("x")}}},
+		{"no up", fstest.MapFS{"0001_init.down.sql": {Data: []byte("x")}}},
+		{"name mismatch", fstest.MapFS{
+			"0001_init.up.sql":    {Data: []byte("x")},
+			"0001_other.down.sql": {Data: []byte("x")},
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Parse(tc.fsys)
+			require.Error(t, err)
+			require.Nil(t, got)
+		})
+	}
+}
+
+func TestParse_Embedded(t *testing.T) {
+	got, err := Parse(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, got)
+
+	for i, m := range got {
+		require.Equal(t, int64(i+1), m.Version, "migrations must be numbered without gaps")
+		require.NotEmpty(t, m.Down, "migration %d has no down file", m.Version)
+	}
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// EventType — тип события ссылки, на который подписываются вебхуки.
+type EventType string
+
+const (
+	EventCreated EventType = "url.created"
+	EventUpdated EventType = "url.updated"
+	EventDeleted EventType = "url.deleted"
+	// EventClicks — число переходов по ссылке достигло одного из порогов.
+	EventClicks EventType = "url.clicks"
+)
+
+// EventTypes — все типы событий.
+var EventTypes = []EventType{EventCreated, EventUpdated, EventDeleted, EventClicks}
+
+// Event — событие ссылки. Хранилище пишет его в outbox той же транзакцией,
+// что и само изменение, а воркер вебхуков рассылает подписчикам.
+type Event struct {
+	ID   int64     `json:"id"`
+	Type EventType `json:"type"`
+	// URL — состояние ссылки после изменения, для url.deleted — перед удалением.
+	URL EventURL `json:"url"`
+	// Clicks — достигнутое число переходов, только для url.clicks.
+	Clicks    int64     `json:"clicks,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// EventURL — поля ссылки в событии.
+type EventURL struct {
+	Domain       string `json:"domain"`
+	Alias        string `json:"alias"`
+	LongURL      string `json:"long_url"`
+	Disabled     bool   `json:"disabled"`
+	Interstitial bool   `json:"interstitial"`
+}
+
+// NewEvent создаёт событие типа t по записи u. ID выдаёт хранилище.
+func NewEvent(t EventType, u *URL) *Event {
+	ev := &Event{
+		Type: t,
+		URL: EventURL{
+			Domain:       u.Domain,
+			Alias:        u.Alias,
+			LongURL:      u.LongURL,
+			Disabled:     u.Disabled,
+			Interstitial: u.Interstitial,
+		},
+		CreatedAt: time.Now().UTC(),
+	}
+	if t == EventClicks {
+		ev.Clicks = u.Clicks
+	}
+	return ev
+}
+
+// DeliveryStatus — состояние доставки события подписчику.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending — доставка ждёт очередной попытки.
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliveryDead — попытки исчерпаны, доставку можно только повторить вручную.
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// Delivery — доставка события одному вебхуку. Успешные доставки удаляются.
+type Delivery struct {
+	ID int64 `json:"id"`
+	// Webhook — имя подписки.
+	Webhook string         `json:"webhook"`
+	Event   *Event         `json:"event"`
+	Status  DeliveryStatus `json:"status"`
+	// Attempts — сколько попыток уже сделано.
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	// LastError — чем закончилась последняя попытка.
+	LastError string    `json:"last_error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Webhook — подписка на события ссылок.
+type Webhook struct {
+	// Name — имя подписки, уникальное среди подписок; хранится в доставках.
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Events — типы событий подписки, пусто — все.
+	Events []EventType `json:"events,omitempty"`
+}
+
+// Subscribed сообщает, что подписка получает события типа t.
+func (w Webhook) Subscribed(t EventType) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, t)
+}
@@ -3,8 +3,48 @@ package model
 import "time"
 
 type URL struct {
-	ID        int64
+	ID int64
+	// Domain — короткий домен ссылки, пусто — домен по умолчанию.
+	// Алиас уникален в пределах домена.
+	Domain    string
 	LongURL   string
 	Alias     string
 	CreatedAt time.Time
+	Disabled  bool
+	// Interstitial — вместо редиректа всегда показывается страница предпросмотра.
+	Interstitial bool
+	// Meta — сведения о странице назначения, nil — ещё не загружались.
+	Meta *Metadata
+	// Health — результат последней проверки доступности страницы назначения, nil — ещё не проверялась.
+	Health *Health
+	// Clicks — число переходов. Считается, только если заданы пороги событий url.clicks.
+	Clicks int64
+}
+
+// Metadata — сведения о странице назначения, которые загружаются в фоне после создания ссылки.
+type Metadata struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	FaviconURL  string `json:"favicon_url,omitempty"`
+	// Error — почему страницу не удалось загрузить, пусто при успехе.
+	Error     string    `json:"error,omitempty"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// Health — результат проверки доступности страницы назначения.
+type Health struct {
+	// Status — HTTP-статус ответа, 0 — ответ не получен.
+	Status int `json:"status,omitempty"`
+	// Error — почему ответ не получен, пусто при ответе с любым статусом.
+	Error string `json:"error,omitempty"`
+	// Latency — время запроса вместе с редиректами.
+	Latency time.Duration `json:"latency"`
+	// Redirects — адреса, через которые прошёл запрос, без исходного.
+	Redirects []string `json:"redirects,omitempty"`
+	// Failures — сколько проверок подряд завершились ошибкой или статусом 4xx/5xx.
+	Failures int `json:"failures"`
+	// Broken — Failures достигло порога, ссылка считается неработающей.
+	Broken    bool      `json:"broken"`
+	CheckedAt time.Time `json:"checked_at"`
 }
@@ -0,0 +1,57 @@
+package bolt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var (
+	bucketURLs     = []byte("urls")
+	bucketAliases  = []byte("aliases")
+	bucketLongURLs = []byte("long_urls")
+
+	bucketOutbox     = []byte("webhook_outbox")
+	bucketDeliveries = []byte("webhook_deliveries")
+)
+
+// Config — настройки хранилища.
+type Config struct {
+	// Path — путь к файлу базы.
+	Path string
+	// Timeout — сколько ждать блокировку файла, если его держит другой процесс.
+	Timeout time.Duration
+}
+
+// Open открывает файл базы bbolt и создаёт недостающие бакеты.
+// Файл блокируется на время работы, поэтому открыть его может только один процесс.
+func Open(cfg Config) (*bbolt.DB, error) {
+	if dir := filepath.Dir(cfg.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create bolt dir: %w", err)
+		}
+	}
+
+	db, err := bbolt.Open(cfg.Path, 0o600, &bbolt.Options{Timeout: cfg.Timeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bolt db: %w", err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{bucketURLs, bucketAliases, bucketLongURLs, bucketOutbox, bucketDeliveries} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create bolt buckets: %w", err)
+	}
+
+	return db, nil
+}
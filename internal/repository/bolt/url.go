@@ -0,0 +1,431 @@
+package bolt
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	"go.etcd.io/bbolt"
+)
+
+// Раскладка данных:
+//   - urls: ID (8 байт big endian) -> запись в JSON; порядок ключей совпадает с порядком ID,
+//     а последовательность бакета хранит последний выданный ID;
+//   - aliases: алиас -> ID;
+//   - long_urls: длинный URL -> ID;
+//   - webhook_outbox: ID события -> событие в JSON, пишется в транзакции изменения ссылки;
+//   - webhook_deliveries: ID доставки -> доставка в JSON.
+//
+// Ключи индексов домена, отличного от домена по умолчанию, начинаются с имени домена, см. indexKey.
+
+// record — запись в бакете urls.
+type record struct {
+	Domain       string    `json:"domain,omitempty"`
+	LongURL      string    `json:"long_url"`
+	Alias        string    `json:"alias"`
+	CreatedAt    time.Time `json:"created_at"`
+	Disabled     bool      `json:"disabled,omitempty"`
+	Interstitial bool      `json:"interstitial,omitempty"`
+
+	Meta   *model.Metadata `json:"meta,omitempty"`
+	Health *model.Health   `json:"health,omitempty"`
+	Clicks int64           `json:"clicks,omitempty"`
+}
+
+func newRecord(u *model.URL) record {
+	return record{
+		Domain:       u.Domain,
+		LongURL:      u.LongURL,
+		Alias:        u.Alias,
+		CreatedAt:    u.CreatedAt,
+		Disabled:     u.Disabled,
+		Interstitial: u.Interstitial,
+		Meta:         u.Meta,
+		Health:       u.Health,
+		Clicks:       u.Clicks,
+	}
+}
+
+type Repo struct {
+	db *bbolt.DB
+}
+
+func NewRepository(db *bbolt.DB) (*Repo, error) {
+	if db == nil {
+		return nil, errors.New("repository: bolt db is nil")
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+func idKey(id int64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, uint64(id))
+	return k
+}
+
+// indexKey возвращает ключ алиаса или длинного URL в индексе. Для домена по умолчанию
+// это сам ключ, как до появления доменов, для остальных — "домен\x00ключ".
+func indexKey(domain, key string) []byte {
+	if domain == "" {
+		return []byte(key)
+	}
+	return []byte(domain + "\x00" + key)
+}
+
+// get читает запись домена по ключу из индекса idx. Возвращает nil, если ключа нет.
+func get(tx *bbolt.Tx, idx []byte, domain, key string) (*model.URL, error) {
+	id := tx.Bucket(idx).Get(indexKey(domain, key))
+	if id == nil {
+		return nil, nil
+	}
+	return getByID(tx, id)
+}
+
+func getByID(tx *bbolt.Tx, id []byte) (*model.URL, error) {
+	v := tx.Bucket(bucketURLs).Get(id)
+	if v == nil {
+		return nil, fmt.Errorf("repository: dangling index entry for id %d", binary.BigEndian.Uint64(id))
+	}
+	return decode(id, v)
+}
+
+func decode(id, v []byte) (*model.URL, error) {
+	var rec record
+	if err := json.Unmarshal(v, &rec); err != nil {
+		return nil, fmt.Errorf("repository: decode url: %w", err)
+	}
+
+	return &model.URL{
+		ID:           int64(binary.BigEndian.Uint64(id)),
+		Domain:       rec.Domain,
+		LongURL:      rec.LongURL,
+		Alias:        rec.Alias,
+		CreatedAt:    rec.CreatedAt,
+		Disabled:     rec.Disabled,
+		Interstitial: rec.Interstitial,
+		Meta:         rec.Meta,
+		Health:       rec.Health,
+		Clicks:       rec.Clicks,
+	}, nil
+}
+
+// put сохраняет запись и индексы. Если u.ID равен 0, выдаёт следующий ID из последовательности,
+// иначе сдвигает последовательность за u.ID.
+func put(tx *bbolt.Tx, u *model.URL) error {
+	urls := tx.Bucket(bucketURLs)
+
+	if u.ID == 0 {
+		id, err := urls.NextSequence()
+		if err != nil {
+			return err
+		}
+		u.ID = int64(id)
+	} else if uint64(u.ID) > urls.Sequence() {
+		if err := urls.SetSequence(uint64(u.ID)); err != nil {
+			return err
+		}
+	}
+
+	key := idKey(u.ID)
+	if urls.Get(key) != nil {
+		return repository.ErrIDConflict
+	}
+
+	v, err := json.Marshal(newRecord(u))
+	if err != nil {
+		return err
+	}
+
+	if err := urls.Put(key, v); err != nil {
+		return err
+	}
+	if err := tx.Bucket(bucketAliases).Put(indexKey(u.Domain, u.Alias), key); err != nil {
+		return err
+	}
+	return tx.Bucket(bucketLongURLs).Put(indexKey(u.Domain, u.LongURL), key)
+}
+
+// GetLastID возвращает последний выданный ID, в том числе ID удалённых записей.
+func (r *Repo) GetLastID(_ context.Context) (uint64, error) {
+	var id uint64
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		id = tx.Bucket(bucketURLs).Sequence()
+		return nil
+	})
+	return id, err
+}
+
+// LeaseIDs резервирует n ID, сдвигая последовательность бакета urls. Import и записи
+// без ID получают значения после зарезервированных, поэтому ID не повторяются.
+func (r *Repo) LeaseIDs(_ context.Context, n uint64) ([]uint64, error) {
+	var last uint64
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		urls := tx.Bucket(bucketURLs)
+		last = urls.Sequence() + n
+		return urls.SetSequence(last)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("repository: LeaseIDs: %w", err)
+	}
+
+	ids := make([]uint64, n)
+	for i := range ids {
+		ids[i] = last - n + 1 + uint64(i)
+	}
+	return ids, nil
+}
+
+func (r *Repo) CreateOrGet(_ context.Context, u *model.URL) (*model.URL, bool, error) {
+	var created bool
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		existing, err := get(tx, bucketLongURLs, u.Domain, u.LongURL)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			*u = *existing
+			return nil
+		}
+
+		if tx.Bucket(bucketAliases).Get(indexKey(u.Domain, u.Alias)) != nil {
+			return repository.ErrAliasConflict
+		}
+
+		u.CreatedAt = time.Now().UTC()
+		u.Disabled = false
+
+		if err := put(tx, u); err != nil {
+			return err
+		}
+		created = true
+		return addEvent(tx, model.NewEvent(model.EventCreated, u))
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, false, err
+		}
+		return nil, false, fmt.Errorf("repository: insert url: %w", err)
+	}
+
+	return u, created, nil
+}
+
+func (r *Repo) GetByAlias(_ context.Context, domain, alias string) (*model.URL, error) {
+	var u *model.URL
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		u, err = get(tx, bucketAliases, domain, alias)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("repository: get url by alias: %w", err)
+	}
+	if u == nil {
+		return nil, repository.ErrNotFound
+	}
+
+	return u, nil
+}
+
+func (r *Repo) GetLongURLByAlias(ctx context.Context, domain, alias string) (string, error) {
+	u, err := r.GetByAlias(ctx, domain, alias)
+	if err != nil {
+		return "", err
+	}
+	if u.Disabled {
+		return "", repository.ErrDisabled
+	}
+
+	return u.LongURL, nil
+}
+
+func (r *Repo) List(_ context.Context, f repository.ListFilter) ([]*model.URL, error) {
+	var urls []*model.URL
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(bucketURLs).Cursor()
+		for k, v := c.Seek(idKey(f.AfterID + 1)); k != nil && len(urls) < f.Limit; k, v = c.Next() {
+			u, err := decode(k, v)
+			if err != nil {
+				return err
+			}
+			if f.Broken && (u.Health == nil || !u.Health.Broken) {
+				continue
+			}
+			urls = append(urls, u)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("repository: list urls: %w", err)
+	}
+
+	return urls, nil
+}
+
+// update изменяет запись по алиасу на домене и, если fn вернула тип события, пишет
+// его в outbox той же транзакцией. Индексы не меняются, поэтому fn не должна трогать
+// домен, алиас и длинный URL.
+func (r *Repo) update(domain, alias string, fn func(u *model.URL) model.EventType) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		id := tx.Bucket(bucketAliases).Get(indexKey(domain, alias))
+		if id == nil {
+			return repository.ErrNotFound
+		}
+		u, err := getByID(tx, id)
+		if err != nil {
+			return err
+		}
+		t := fn(u)
+
+		v, err := json.Marshal(newRecord(u))
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketURLs).Put(id, v); err != nil {
+			return err
+		}
+		if t == "" {
+			return nil
+		}
+		return addEvent(tx, model.NewEvent(t, u))
+	})
+}
+
+func (r *Repo) SetDisabled(_ context.Context, domain, alias string, disabled bool) error {
+	err := r.update(domain, alias, func(u *model.URL) model.EventType {
+		u.Disabled = disabled
+		return model.EventUpdated
+	})
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("repository: set disabled: %w", err)
+	}
+
+	return err
+}
+
+func (r *Repo) SetInterstitial(_ context.Context, domain, alias string, interstitial bool) error {
+	err := r.update(domain, alias, func(u *model.URL) model.EventType {
+		u.Interstitial = interstitial
+		return model.EventUpdated
+	})
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("repository: set interstitial: %w", err)
+	}
+
+	return err
+}
+
+func (r *Repo) SetMetadata(_ context.Context, domain, alias string, meta *model.Metadata) error {
+	err := r.update(domain, alias, func(u *model.URL) model.EventType {
+		u.Meta = meta
+		return ""
+	})
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("repository: set metadata: %w", err)
+	}
+
+	return err
+}
+
+func (r *Repo) SetHealth(_ context.Context, domain, alias string, h *model.Health) error {
+	err := r.update(domain, alias, func(u *model.URL) model.EventType {
+		u.Health = h
+		return ""
+	})
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("repository: set health: %w", err)
+	}
+
+	return err
+}
+
+func (r *Repo) AddClick(_ context.Context, domain, alias string, thresholds []int64) (int64, error) {
+	var clicks int64
+	err := r.update(domain, alias, func(u *model.URL) model.EventType {
+		u.Clicks++
+		clicks = u.Clicks
+		if slices.Contains(thresholds, clicks) {
+			return model.EventClicks
+		}
+		return ""
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return 0, err
+		}
+		return 0, fmt.Errorf("repository: add click: %w", err)
+	}
+
+	return clicks, nil
+}
+
+func (r *Repo) Delete(_ context.Context, domain, alias string) error {
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		u, err := get(tx, bucketAliases, domain, alias)
+		if err != nil {
+			return err
+		}
+		if u == nil {
+			return repository.ErrNotFound
+		}
+
+		if err := tx.Bucket(bucketURLs).Delete(idKey(u.ID)); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketAliases).Delete(indexKey(u.Domain, u.Alias)); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketLongURLs).Delete(indexKey(u.Domain, u.LongURL)); err != nil {
+			return err
+		}
+		return addEvent(tx, model.NewEvent(model.EventDeleted, u))
+	})
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("repository: delete url: %w", err)
+	}
+
+	return err
+}
+
+// Import сохраняет записи одной транзакцией, пропуская занятые алиасы и длинные URL.
+func (r *Repo) Import(_ context.Context, urls []*model.URL) (int, error) {
+	imported := 0
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		aliases := tx.Bucket(bucketAliases)
+		longURLs := tx.Bucket(bucketLongURLs)
+
+		for _, u := range urls {
+			if aliases.Get(indexKey(u.Domain, u.Alias)) != nil || longURLs.Get(indexKey(u.Domain, u.LongURL)) != nil {
+				continue
+			}
+
+			c := *u
+			c.ID = 0
+			if c.CreatedAt.IsZero() {
+				c.CreatedAt = time.Now().UTC()
+			}
+			if err := put(tx, &c); err != nil {
+				return err
+			}
+			if err := addEvent(tx, model.NewEvent(model.EventCreated, &c)); err != nil {
+				return err
+			}
+			imported++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("repository: import urls: %w", err)
+	}
+
+	return imported, nil
+}
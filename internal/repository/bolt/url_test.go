@@ -0,0 +1,108 @@
+package bolt
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	"github.com/Rasulikus/url-shortener/internal/repository/repositorytest"
+	urlService "github.com/Rasulikus/url-shortener/internal/service/url"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+func testConfig(t *testing.T) Config {
+	t.Helper()
+
+	return Config{
+		Path:    filepath.Join(t.TempDir(), "data", "test.db"),
+		Timeout: time.Second,
+	}
+}
+
+func openDB(t *testing.T, cfg Config) *bbolt.DB {
+	t.Helper()
+
+	db, err := Open(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db
+}
+
+func newRepo(t *testing.T) *Repo {
+	t.Helper()
+
+	r, err := NewRepository(openDB(t, testConfig(t)))
+	require.NoError(t, err)
+
+	return r
+}
+
+func TestRepo(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) urlService.URLRepository {
+		return newRepo(t)
+	})
+}
+
+func TestRepo_CreateOrGetWithID(t *testing.T) {
+	ctx := context.Background()
+	repo := newRepo(t)
+
+	u, _, err := repo.CreateOrGet(ctx, &model.URL{
+		ID:      10,
+		LongURL: "https://rkrkrkrk.com",
+		Alias:   "aa",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(10), u.ID)
+
+	// Запись без ID получает ID после уже выданных
+	next, _, err := repo.CreateOrGet(ctx, &model.URL{
+		LongURL: "https://other.com",
+		Alias:   "bb",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(11), next.ID)
+
+	lastID, err := repo.GetLastID(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 11, lastID)
+
+	// Повторно выданный ID — конфликт, а не перезапись
+	_, _, err = repo.CreateOrGet(ctx, &model.URL{
+		ID:      10,
+		LongURL: "https://third.com",
+		Alias:   "cc",
+	})
+	require.ErrorIs(t, err, repository.ErrConflict)
+}
+
+func TestRepo_Reopen(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig(t)
+
+	db, err := Open(cfg)
+	require.NoError(t, err)
+	repo, err := NewRepository(db)
+	require.NoError(t, err)
+
+	u, _, err := repo.CreateOrGet(ctx, &model.URL{LongURL: "https://rkrkrkrk.com", Alias: "aa"})
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	repo, err = NewRepository(openDB(t, cfg))
+	require.NoError(t, err)
+
+	got, err := repo.GetByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, got.ID)
+
+	lastID, err := repo.GetLastID(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, u.ID, lastID)
+}
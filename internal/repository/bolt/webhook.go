@@ -0,0 +1,200 @@
+package bolt
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	"go.etcd.io/bbolt"
+)
+
+// addEvent пишет событие в outbox в транзакции изменения ссылки.
+func addEvent(tx *bbolt.Tx, ev *model.Event) error {
+	outbox := tx.Bucket(bucketOutbox)
+
+	id, err := outbox.NextSequence()
+	if err != nil {
+		return err
+	}
+	ev.ID = int64(id)
+
+	v, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return outbox.Put(idKey(ev.ID), v)
+}
+
+func putDelivery(b *bbolt.Bucket, d *model.Delivery) error {
+	v, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return b.Put(idKey(d.ID), v)
+}
+
+func decodeDelivery(v []byte) (*model.Delivery, error) {
+	d := new(model.Delivery)
+	if err := json.Unmarshal(v, d); err != nil {
+		return nil, fmt.Errorf("repository: decode delivery: %w", err)
+	}
+	return d, nil
+}
+
+func (r *Repo) Events(_ context.Context, limit int) ([]*model.Event, error) {
+	var events []*model.Event
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(bucketOutbox).Cursor()
+		for k, v := c.First(); k != nil && len(events) < limit; k, v = c.Next() {
+			ev := new(model.Event)
+			if err := json.Unmarshal(v, ev); err != nil {
+				return err
+			}
+			ev.ID = int64(binary.BigEndian.Uint64(k))
+			events = append(events, ev)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("repository: list events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *Repo) Dispatch(_ context.Context, eventID int64, deliveries []*model.Delivery) error {
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		outbox := tx.Bucket(bucketOutbox)
+		if outbox.Get(idKey(eventID)) == nil {
+			return repository.ErrNotFound
+		}
+		if err := outbox.Delete(idKey(eventID)); err != nil {
+			return err
+		}
+
+		b := tx.Bucket(bucketDeliveries)
+		for _, d := range deliveries {
+			id, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			d.ID = int64(id)
+			if err := putDelivery(b, d); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("repository: dispatch event: %w", err)
+	}
+
+	return err
+}
+
+// ClaimDeliveries просматривает все доставки: успешные удаляются, поэтому их немного.
+func (r *Repo) ClaimDeliveries(_ context.Context, now time.Time, lease time.Duration, limit int) ([]*model.Delivery, error) {
+	var due []*model.Delivery
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucketDeliveries)
+		err := b.ForEach(func(_, v []byte) error {
+			d, err := decodeDelivery(v)
+			if err != nil {
+				return err
+			}
+			if d.Status == model.DeliveryPending && !d.NextAttemptAt.After(now) {
+				due = append(due, d)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		sort.SliceStable(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(due[j].NextAttemptAt) })
+		if len(due) > limit {
+			due = due[:limit]
+		}
+		for _, d := range due {
+			d.NextAttemptAt = now.Add(lease)
+			if err := putDelivery(b, d); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("repository: claim deliveries: %w", err)
+	}
+
+	return due, nil
+}
+
+func (r *Repo) UpdateDelivery(_ context.Context, d *model.Delivery) error {
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucketDeliveries)
+		v := b.Get(idKey(d.ID))
+		if v == nil {
+			return repository.ErrNotFound
+		}
+		stored, err := decodeDelivery(v)
+		if err != nil {
+			return err
+		}
+
+		stored.Status = d.Status
+		stored.Attempts = d.Attempts
+		stored.NextAttemptAt = d.NextAttemptAt
+		stored.LastError = d.LastError
+		return putDelivery(b, stored)
+	})
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("repository: update delivery: %w", err)
+	}
+
+	return err
+}
+
+func (r *Repo) DeleteDelivery(_ context.Context, id int64) error {
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucketDeliveries)
+		if b.Get(idKey(id)) == nil {
+			return repository.ErrNotFound
+		}
+		return b.Delete(idKey(id))
+	})
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("repository: delete delivery: %w", err)
+	}
+
+	return err
+}
+
+func (r *Repo) ListDeliveries(_ context.Context, f repository.DeliveryFilter) ([]*model.Delivery, error) {
+	var list []*model.Delivery
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(bucketDeliveries).Cursor()
+		for k, v := c.Seek(idKey(f.AfterID + 1)); k != nil && len(list) < f.Limit; k, v = c.Next() {
+			d, err := decodeDelivery(v)
+			if err != nil {
+				return err
+			}
+			if f.Status != "" && d.Status != f.Status {
+				continue
+			}
+			list = append(list, d)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("repository: list deliveries: %w", err)
+	}
+
+	return list, nil
+}
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package memory
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockDir создаёт файл LOCK, но не блокирует его: flock есть только в unix-системах.
+// Запускать несколько процессов с одним каталогом здесь нельзя.
+func lockDir(dir string, _ time.Duration) (*os.File, error) {
+	f, err := os.OpenFile(filepath.Join(dir, lockFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("memory: open lock file: %w", err)
+	}
+	return f, nil
+}
@@ -0,0 +1,42 @@
+//go:build unix
+
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// lockPollInterval — как часто повторять попытку взять занятую блокировку.
+const lockPollInterval = 50 * time.Millisecond
+
+// lockDir берёт эксклюзивную блокировку файла LOCK в каталоге dir. Если каталог
+// занят другим процессом, ждёт до timeout и возвращает ErrLocked.
+// Блокировка снимается при закрытии возвращённого файла.
+func lockDir(dir string, timeout time.Duration) (*os.File, error) {
+	f, err := os.OpenFile(filepath.Join(dir, lockFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("memory: open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			f.Close()
+			return nil, fmt.Errorf("memory: lock data dir: %w", err)
+		}
+		if !time.Now().Before(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("%w: %s", ErrLocked, dir)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
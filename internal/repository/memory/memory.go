@@ -1,22 +1,647 @@
 package memory
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/rs/zerolog/log"
 )
 
+const (
+	snapshotFile    = "snapshot.dat"
+	snapshotTmpFile = "snapshot.tmp"
+
+	segmentPrefix = "wal-"
+	segmentSuffix = ".log"
+
+	// lockFile держит блокировку каталога, пока хранилище открыто.
+	lockFile = "LOCK"
+)
+
+// ErrLocked — каталог данных уже открыт другим процессом.
+var ErrLocked = errors.New("memory: data dir is locked by another process")
+
+// FsyncPolicy — когда журнал сбрасывается на диск.
+type FsyncPolicy string
+
+const (
+	// FsyncAlways — fsync после каждой записи, изменения не теряются.
+	FsyncAlways FsyncPolicy = "always"
+	// FsyncInterval — fsync раз в Config.FsyncInterval, при сбое теряется не больше интервала.
+	FsyncInterval FsyncPolicy = "interval"
+	// FsyncNever — сброс на диск остаётся на усмотрение ОС.
+	FsyncNever FsyncPolicy = "never"
+)
+
+// Config — настройки сохранения хранилища на диск.
+type Config struct {
+	// DataDir — каталог журнала и снапшотов. Если пуст, данные живут только в памяти.
+	DataDir string
+
+	Fsync         FsyncPolicy
+	FsyncInterval time.Duration
+
+	// SnapshotInterval — как часто сохранять снапшот и обрезать журнал. 0 — только при остановке.
+	SnapshotInterval time.Duration
+
+	// LockTimeout — сколько ждать, если каталог открыт другим процессом. 0 — не ждать.
+	LockTimeout time.Duration
+}
+
 type Memory struct {
-	mu      sync.RWMutex
+	mu sync.RWMutex
+	// Ключи индексов включают домен, см. key.
 	byAlias map[string]*model.URL
 	byLong  map[string]*model.URL
+	byID    map[int64]*model.URL
 	nextID  int64
+
+	// events — outbox событий вебхуков, deliveries — их доставки.
+	events         map[int64]*model.Event
+	deliveries     map[int64]*model.Delivery
+	nextEventID    int64
+	nextDeliveryID int64
+
+	// Поля ниже используются, только если включено сохранение на диск.
+	cfg     *Config
+	lock    *os.File
+	wal     *os.File
+	walName string
+	walSize int64
+	seq     uint64
+	dirty   atomic.Bool
+
+	// snapMu не даёт двум снапшотам выполняться одновременно.
+	snapMu sync.Mutex
+	done   chan struct{}
+	wg     sync.WaitGroup
 }
 
 func New() *Memory {
 	return &Memory{
 		byAlias: make(map[string]*model.URL),
 		byLong:  make(map[string]*model.URL),
+		byID:    make(map[int64]*model.URL),
 		nextID:  1,
+
+		events:         make(map[int64]*model.Event),
+		deliveries:     make(map[int64]*model.Delivery),
+		nextEventID:    1,
+		nextDeliveryID: 1,
+	}
+}
+
+// Open создаёт хранилище, которое пишет изменения в журнал в каталоге cfg.DataDir
+// и периодически сохраняет снапшот. При открытии состояние восстанавливается из
+// последнего снапшота и журнала. Каталог блокируется, пока хранилище открыто: если
+// его держит другой процесс, Open ждёт cfg.LockTimeout и возвращает ErrLocked.
+// Если cfg.DataDir пуст, работает как New.
+func Open(cfg Config) (*Memory, error) {
+	m := New()
+	if cfg.DataDir == "" {
+		return m, nil
+	}
+	switch cfg.Fsync {
+	case FsyncAlways, FsyncNever:
+	case FsyncInterval:
+		if cfg.FsyncInterval <= 0 {
+			return nil, fmt.Errorf("memory: fsync interval must be positive: %s", cfg.FsyncInterval)
+		}
+	default:
+		return nil, fmt.Errorf("memory: unknown fsync policy: %q", cfg.Fsync)
+	}
+
+	if err := os.MkdirAll(cfg.DataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("memory: create data dir: %w", err)
+	}
+	lock, err := lockDir(cfg.DataDir, cfg.LockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	m.cfg = &cfg
+	m.lock = lock
+
+	if err := m.load(); err != nil {
+		if m.wal != nil {
+			m.wal.Close()
+		}
+		lock.Close()
+		return nil, err
+	}
+
+	m.done = make(chan struct{})
+	if cfg.Fsync == FsyncInterval {
+		m.wg.Add(1)
+		go m.syncLoop(cfg.FsyncInterval)
+	}
+	if cfg.SnapshotInterval > 0 {
+		m.wg.Add(1)
+		go m.snapshotLoop(cfg.SnapshotInterval)
+	}
+
+	log.Info().
+		Str("dir", cfg.DataDir).
+		Str("fsync", string(cfg.Fsync)).
+		Int("urls", len(m.byAlias)).
+		Uint64("seq", m.seq).
+		Msg("memory storage restored")
+
+	return m, nil
+}
+
+// Close сохраняет снапшот, закрывает журнал и снимает блокировку каталога.
+// Для хранилища без диска ничего не делает.
+func (m *Memory) Close() error {
+	if m.cfg == nil {
+		return nil
+	}
+
+	close(m.done)
+	m.wg.Wait()
+
+	snapErr := m.Snapshot()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Блокировку снимаем последней, когда журнал уже закрыт
+	defer m.lock.Close()
+
+	if m.wal == nil {
+		return snapErr
+	}
+	err := errors.Join(snapErr, m.wal.Sync(), m.wal.Close())
+	m.wal = nil
+
+	return err
+}
+
+// load восстанавливает состояние из снапшота и сегментов журнала и открывает
+// последний сегмент на запись.
+func (m *Memory) load() error {
+	snapSeq, err := m.loadSnapshot()
+	if err != nil {
+		return err
+	}
+	m.seq = snapSeq
+
+	segments, err := m.segments()
+	if err != nil {
+		return err
+	}
+
+	for i, name := range segments {
+		path := filepath.Join(m.cfg.DataDir, name)
+
+		end, err := readRecords(path, func(rec *record) error {
+			if rec.Seq <= snapSeq {
+				return nil
+			}
+			m.apply(rec)
+			m.seq = rec.Seq
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("memory: replay %s: %w", name, err)
+		}
+
+		st, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if end < st.Size() {
+			log.Warn().
+				Str("segment", name).
+				Int64("offset", end).
+				Int64("size", st.Size()).
+				Msg("truncating torn wal record")
+
+			if err := os.Truncate(path, end); err != nil {
+				return fmt.Errorf("memory: truncate %s: %w", name, err)
+			}
+		}
+
+		if i == len(segments)-1 {
+			return m.openSegment(name, end)
+		}
+	}
+
+	return m.createSegment()
+}
+
+// loadSnapshot загружает снапшот, если он есть, и возвращает номер последнего вошедшего в него изменения.
+func (m *Memory) loadSnapshot() (uint64, error) {
+	path := filepath.Join(m.cfg.DataDir, snapshotFile)
+
+	st, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		seq    uint64
+		header bool
+	)
+	end, err := readRecords(path, func(rec *record) error {
+		if !header {
+			if rec.Op != opSnapshot {
+				return fmt.Errorf("%w: %s: missing header", ErrCorrupted, snapshotFile)
+			}
+			header = true
+			seq = rec.Seq
+			m.nextID = rec.NextID
+			// В снапшотах до появления вебхуков счётчиков событий нет
+			m.nextEventID = max(rec.NextEventID, 1)
+			m.nextDeliveryID = max(rec.NextDeliveryID, 1)
+			return nil
+		}
+		m.apply(rec)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("memory: load snapshot: %w", err)
+	}
+	// Снапшот пишется атомарно, поэтому оборванный хвост означает повреждение
+	if end != st.Size() || !header {
+		return 0, fmt.Errorf("memory: load snapshot: %w: %s: truncated", ErrCorrupted, snapshotFile)
+	}
+
+	return seq, nil
+}
+
+// segments возвращает имена сегментов журнала по возрастанию.
+func (m *Memory) segments() ([]string, error) {
+	entries, err := os.ReadDir(m.cfg.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("memory: read data dir: %w", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), segmentPrefix) && strings.HasSuffix(e.Name(), segmentSuffix) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (m *Memory) openSegment(name string, size int64) error {
+	f, err := os.OpenFile(filepath.Join(m.cfg.DataDir, name), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("memory: open wal: %w", err)
+	}
+
+	m.wal = f
+	m.walName = name
+	m.walSize = size
+
+	return nil
+}
+
+// createSegment начинает новый сегмент журнала; имя содержит номер первого изменения в нём.
+func (m *Memory) createSegment() error {
+	name := fmt.Sprintf("%s%020d%s", segmentPrefix, m.seq+1, segmentSuffix)
+	if err := m.openSegment(name, 0); err != nil {
+		return err
+	}
+	return syncDir(m.cfg.DataDir)
+}
+
+// commit записывает изменение в журнал и применяет его. Вызывается под m.mu.
+func (m *Memory) commit(rec *record) error {
+	if m.wal != nil {
+		rec.Seq = m.seq + 1
+
+		n, err := appendRecord(m.wal, rec)
+		if err == nil && m.cfg.Fsync == FsyncAlways {
+			err = m.wal.Sync()
+		}
+		if err != nil {
+			// Обрезаем недописанную запись, чтобы следующие не оказались за мусором
+			_ = m.wal.Truncate(m.walSize)
+			return fmt.Errorf("memory: append wal: %w", err)
+		}
+		if m.cfg.Fsync != FsyncAlways {
+			m.dirty.Store(true)
+		}
+
+		m.walSize += int64(n)
+		m.seq = rec.Seq
+	}
+
+	m.apply(rec)
+
+	return nil
+}
+
+// apply применяет изменение к индексам. Применение put повторно к тем же данным ничего не меняет.
+func (m *Memory) apply(rec *record) {
+	switch rec.Op {
+	case opPut:
+		if rec.URL == nil {
+			return
+		}
+		u := *rec.URL
+		if old, ok := m.byAlias[key(u.Domain, u.Alias)]; ok {
+			m.remove(old)
+		}
+		if old, ok := m.byLong[key(u.Domain, u.LongURL)]; ok {
+			m.remove(old)
+		}
+		if old, ok := m.byID[u.ID]; ok {
+			m.remove(old)
+		}
+
+		m.byAlias[key(u.Domain, u.Alias)] = &u
+		m.byLong[key(u.Domain, u.LongURL)] = &u
+		m.byID[u.ID] = &u
+		if u.ID >= m.nextID {
+			m.nextID = u.ID + 1
+		}
+	case opDisable:
+		if u, ok := m.byAlias[key(rec.Domain, rec.Alias)]; ok {
+			u.Disabled = rec.Disabled
+		}
+	case opInterstitial:
+		if u, ok := m.byAlias[key(rec.Domain, rec.Alias)]; ok {
+			u.Interstitial = rec.Interstitial
+		}
+	case opMetadata:
+		if u, ok := m.byAlias[key(rec.Domain, rec.Alias)]; ok {
+			u.Meta = rec.Meta
+		}
+	case opHealth:
+		if u, ok := m.byAlias[key(rec.Domain, rec.Alias)]; ok {
+			u.Health = rec.Health
+		}
+	case opClick:
+		if u, ok := m.byAlias[key(rec.Domain, rec.Alias)]; ok {
+			u.Clicks = rec.Clicks
+		}
+	case opDelete:
+		if u, ok := m.byAlias[key(rec.Domain, rec.Alias)]; ok {
+			m.remove(u)
+		}
+	case opDispatch:
+		delete(m.events, rec.ID)
+		for _, d := range rec.Deliveries {
+			m.putDelivery(d)
+		}
+	case opDelivery:
+		if rec.Delivery != nil {
+			m.putDelivery(rec.Delivery)
+		}
+	case opDeleteDelivery:
+		delete(m.deliveries, rec.ID)
+	}
+
+	// Событие пишется вместе с изменением ссылки, к которому относится
+	if ev := rec.Event; ev != nil {
+		m.events[ev.ID] = ev
+		if ev.ID >= m.nextEventID {
+			m.nextEventID = ev.ID + 1
+		}
+	}
+}
+
+func (m *Memory) putDelivery(d *model.Delivery) {
+	c := *d
+	m.deliveries[d.ID] = &c
+	if d.ID >= m.nextDeliveryID {
+		m.nextDeliveryID = d.ID + 1
+	}
+}
+
+func (m *Memory) remove(u *model.URL) {
+	if k := key(u.Domain, u.Alias); m.byAlias[k] == u {
+		delete(m.byAlias, k)
+	}
+	if k := key(u.Domain, u.LongURL); m.byLong[k] == u {
+		delete(m.byLong, k)
+	}
+	if m.byID[u.ID] == u {
+		delete(m.byID, u.ID)
+	}
+}
+
+// key возвращает ключ алиаса или длинного URL в индексе: одинаковые ключи разных доменов различаются.
+func key(domain, s string) string {
+	return domain + "\x00" + s
+}
+
+// Snapshot сохраняет текущее состояние и удаляет сегменты журнала, вошедшие в снапшот.
+func (m *Memory) Snapshot() error {
+	if m.cfg == nil {
+		return nil
 	}
+
+	m.snapMu.Lock()
+	defer m.snapMu.Unlock()
+
+	m.mu.Lock()
+	if m.wal == nil {
+		m.mu.Unlock()
+		return nil
+	}
+	st := snapshotState{
+		seq:            m.seq,
+		nextID:         m.nextID,
+		nextEventID:    m.nextEventID,
+		nextDeliveryID: m.nextDeliveryID,
+		urls:           make([]model.URL, 0, len(m.byAlias)),
+		events:         make([]model.Event, 0, len(m.events)),
+		deliveries:     make([]model.Delivery, 0, len(m.deliveries)),
+	}
+	for _, u := range m.byAlias {
+		st.urls = append(st.urls, *u)
+	}
+	for _, ev := range m.events {
+		st.events = append(st.events, *ev)
+	}
+	for _, d := range m.deliveries {
+		st.deliveries = append(st.deliveries, *d)
+	}
+	// Новые изменения пойдут в новый сегмент, старые войдут в снапшот
+	err := m.rotate()
+	current := m.walName
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(st.urls, func(i, j int) bool { return st.urls[i].ID < st.urls[j].ID })
+	sort.Slice(st.events, func(i, j int) bool { return st.events[i].ID < st.events[j].ID })
+	sort.Slice(st.deliveries, func(i, j int) bool { return st.deliveries[i].ID < st.deliveries[j].ID })
+	if err := m.writeSnapshot(&st); err != nil {
+		return err
+	}
+
+	segments, err := m.segments()
+	if err != nil {
+		return err
+	}
+	for _, name := range segments {
+		if name >= current {
+			break
+		}
+		if err := os.Remove(filepath.Join(m.cfg.DataDir, name)); err != nil {
+			return fmt.Errorf("memory: remove wal segment: %w", err)
+		}
+	}
+
+	log.Debug().
+		Uint64("seq", st.seq).
+		Int("urls", len(st.urls)).
+		Msg("memory snapshot saved")
+
+	return nil
+}
+
+// rotate закрывает текущий сегмент и открывает новый. Вызывается под m.mu.
+func (m *Memory) rotate() error {
+	if m.walSize == 0 {
+		return nil
+	}
+	if err := m.wal.Sync(); err != nil {
+		return fmt.Errorf("memory: sync wal: %w", err)
+	}
+	if err := m.wal.Close(); err != nil {
+		return fmt.Errorf("memory: close wal: %w", err)
+	}
+	m.dirty.Store(false)
+
+	return m.createSegment()
+}
+
+// snapshotState — копия состояния для записи снапшота без блокировки.
+type snapshotState struct {
+	seq            uint64
+	nextID         int64
+	nextEventID    int64
+	nextDeliveryID int64
+
+	urls       []model.URL
+	events     []model.Event
+	deliveries []model.Delivery
+}
+
+func (m *Memory) writeSnapshot(st *snapshotState) error {
+	tmp := filepath.Join(m.cfg.DataDir, snapshotTmpFile)
+
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("memory: create snapshot: %w", err)
+	}
+	defer os.Remove(tmp)
+
+	err = func() error {
+		defer f.Close()
+
+		w := bufio.NewWriter(f)
+		header := &record{
+			Seq:            st.seq,
+			Op:             opSnapshot,
+			NextID:         st.nextID,
+			NextEventID:    st.nextEventID,
+			NextDeliveryID: st.nextDeliveryID,
+		}
+		if _, err := appendRecord(w, header); err != nil {
+			return err
+		}
+		for i := range st.urls {
+			if _, err := appendRecord(w, &record{Op: opPut, URL: &st.urls[i]}); err != nil {
+				return err
+			}
+		}
+		for i := range st.events {
+			if _, err := appendRecord(w, &record{Op: opEvent, Event: &st.events[i]}); err != nil {
+				return err
+			}
+		}
+		for i := range st.deliveries {
+			if _, err := appendRecord(w, &record{Op: opDelivery, Delivery: &st.deliveries[i]}); err != nil {
+				return err
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		return f.Sync()
+	}()
+	if err != nil {
+		return fmt.Errorf("memory: write snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmp, filepath.Join(m.cfg.DataDir, snapshotFile)); err != nil {
+		return fmt.Errorf("memory: rename snapshot: %w", err)
+	}
+
+	return syncDir(m.cfg.DataDir)
+}
+
+func (m *Memory) syncLoop(interval time.Duration) {
+	defer m.wg.Done()
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-t.C:
+			if !m.dirty.Swap(false) {
+				continue
+			}
+			m.mu.RLock()
+			err := m.wal.Sync()
+			m.mu.RUnlock()
+			if err != nil {
+				log.Error().Err(err).Msg("failed to sync memory wal")
+			}
+		}
+	}
+}
+
+func (m *Memory) snapshotLoop(interval time.Duration) {
+	defer m.wg.Done()
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-t.C:
+			if err := m.Snapshot(); err != nil {
+				log.Error().Err(err).Msg("failed to save memory snapshot")
+			}
+		}
+	}
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
 }
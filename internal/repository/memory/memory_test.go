@@ -0,0 +1,359 @@
+package memory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMemoryConfig(t *testing.T) Config {
+	t.Helper()
+
+	return Config{
+		DataDir: t.TempDir(),
+		Fsync:   FsyncAlways,
+	}
+}
+
+func openRepo(t *testing.T, cfg Config) (*Memory, *Repo) {
+	t.Helper()
+
+	m, err := Open(cfg)
+	require.NoError(t, err)
+
+	r, err := NewRepository(m)
+	require.NoError(t, err)
+
+	return m, r
+}
+
+// crash закрывает журнал и блокировку без снапшота, как при падении процесса.
+func crash(t *testing.T, m *Memory) {
+	t.Helper()
+
+	require.NoError(t, m.wal.Close())
+	require.NoError(t, m.lock.Close())
+}
+
+// fillRepo создаёт aa, bb и aa на домене brand.link, включает предпросмотр aa,
+// отключает bb и удаляет aa на brand.link.
+func fillRepo(t *testing.T, r *Repo) {
+	t.Helper()
+	ctx := context.Background()
+
+	for _, a := range []string{"aa", "bb"} {
+		_, _, err := r.CreateOrGet(ctx, &model.URL{LongURL: "https://" + a + ".com", Alias: a})
+		require.NoError(t, err)
+	}
+	_, _, err := r.CreateOrGet(ctx, &model.URL{Domain: "brand.link", LongURL: "https://aa.com", Alias: "aa"})
+	require.NoError(t, err)
+	require.NoError(t, r.SetInterstitial(ctx, "", "aa", true))
+	require.NoError(t, r.SetMetadata(ctx, "", "aa", &model.Metadata{Title: "AA"}))
+	require.NoError(t, r.SetHealth(ctx, "", "aa", &model.Health{Status: 404, Failures: 3, Broken: true}))
+	require.NoError(t, r.SetDisabled(ctx, "", "bb", true))
+	require.NoError(t, r.Delete(ctx, "brand.link", "aa"))
+}
+
+func assertFilled(t *testing.T, r *Repo) {
+	t.Helper()
+	ctx := context.Background()
+
+	aa, err := r.GetByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+	assert.Equal(t, "https://aa.com", aa.LongURL)
+	assert.True(t, aa.Interstitial)
+	require.NotNil(t, aa.Meta)
+	assert.Equal(t, "AA", aa.Meta.Title)
+	require.NotNil(t, aa.Health)
+	assert.True(t, aa.Health.Broken)
+
+	_, err = r.GetLongURLByAlias(ctx, "", "bb")
+	require.ErrorIs(t, err, repository.ErrDisabled)
+
+	_, err = r.GetByAlias(ctx, "brand.link", "aa")
+	require.ErrorIs(t, err, repository.ErrNotFound)
+
+	lastID, err := r.GetLastID(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, lastID)
+}
+
+func TestOpen_ReplayWAL(t *testing.T) {
+	cfg := testMemoryConfig(t)
+
+	m, r := openRepo(t, cfg)
+	fillRepo(t, r)
+	// Без Close снапшот не сохраняется, состояние восстанавливается только из журнала
+	crash(t, m)
+
+	_, r = openRepo(t, cfg)
+	assertFilled(t, r)
+}
+
+func TestOpen_Snapshot(t *testing.T) {
+	cfg := testMemoryConfig(t)
+	ctx := context.Background()
+
+	m, r := openRepo(t, cfg)
+	fillRepo(t, r)
+	require.NoError(t, m.Snapshot())
+
+	// Изменение после снапшота попадает в новый сегмент журнала
+	_, _, err := r.CreateOrGet(ctx, &model.URL{LongURL: "https://dd.com", Alias: "dd"})
+	require.NoError(t, err)
+	crash(t, m)
+
+	segments, err := m.segments()
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+
+	m, r = openRepo(t, cfg)
+	assertFilledWithDD(t, r)
+
+	require.NoError(t, m.Close())
+
+	_, r = openRepo(t, cfg)
+	assertFilledWithDD(t, r)
+}
+
+func assertFilledWithDD(t *testing.T, r *Repo) {
+	t.Helper()
+
+	dd, err := r.GetByAlias(context.Background(), "", "dd")
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, dd.ID)
+
+	aa, err := r.GetByAlias(context.Background(), "", "aa")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, aa.ID)
+
+	_, err = r.GetLongURLByAlias(context.Background(), "", "bb")
+	require.ErrorIs(t, err, repository.ErrDisabled)
+}
+
+// fillOutbox создаёт aa и bb, переводит событие aa в доставку и засчитывает
+// два перехода по bb.
+func fillOutbox(t *testing.T, r *Repo) {
+	t.Helper()
+	ctx := context.Background()
+
+	for _, a := range []string{"aa", "bb"} {
+		_, _, err := r.CreateOrGet(ctx, &model.URL{LongURL: "https://" + a + ".com", Alias: a})
+		require.NoError(t, err)
+	}
+	events, err := r.Events(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	now := time.Now().UTC()
+	d := &model.Delivery{Webhook: "hook", Event: events[0], Status: model.DeliveryPending, NextAttemptAt: now, CreatedAt: now}
+	require.NoError(t, r.Dispatch(ctx, events[0].ID, []*model.Delivery{d}))
+	d.Attempts = 1
+	d.LastError = "timeout"
+	require.NoError(t, r.UpdateDelivery(ctx, d))
+
+	for range 2 {
+		_, err = r.AddClick(ctx, "", "bb", nil)
+		require.NoError(t, err)
+	}
+}
+
+func assertOutbox(t *testing.T, r *Repo) {
+	t.Helper()
+	ctx := context.Background()
+
+	events, err := r.Events(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.EqualValues(t, 2, events[0].ID)
+	assert.Equal(t, "bb", events[0].URL.Alias)
+
+	list, err := r.ListDeliveries(ctx, repository.DeliveryFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.EqualValues(t, 1, list[0].ID)
+	assert.Equal(t, 1, list[0].Attempts)
+	assert.Equal(t, "timeout", list[0].LastError)
+	assert.Equal(t, "aa", list[0].Event.URL.Alias)
+
+	bb, err := r.GetByAlias(ctx, "", "bb")
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, bb.Clicks)
+
+	// Счётчики ID продолжаются после восстановления
+	_, _, err = r.CreateOrGet(ctx, &model.URL{LongURL: "https://cc.com", Alias: "cc"})
+	require.NoError(t, err)
+	events, err = r.Events(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.EqualValues(t, 3, events[1].ID)
+}
+
+func TestOpen_Outbox(t *testing.T) {
+	t.Run("wal", func(t *testing.T) {
+		cfg := testMemoryConfig(t)
+
+		m, r := openRepo(t, cfg)
+		fillOutbox(t, r)
+		crash(t, m)
+
+		_, r = openRepo(t, cfg)
+		assertOutbox(t, r)
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		cfg := testMemoryConfig(t)
+
+		m, r := openRepo(t, cfg)
+		fillOutbox(t, r)
+		require.NoError(t, m.Close())
+
+		_, r = openRepo(t, cfg)
+		assertOutbox(t, r)
+	})
+}
+
+func TestOpen_TornTail(t *testing.T) {
+	cfg := testMemoryConfig(t)
+
+	m, r := openRepo(t, cfg)
+	fillRepo(t, r)
+	name := m.walName
+	crash(t, m)
+
+	// Имитируем сбой посреди записи: дописываем половину заголовка
+	path := filepath.Join(cfg.DataDir, name)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0, 0, 0})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	m, r = openRepo(t, cfg)
+	assertFilled(t, r)
+
+	// Хвост обрезан, новые записи читаются после перезапуска
+	_, _, err = r.CreateOrGet(context.Background(), &model.URL{LongURL: "https://dd.com", Alias: "dd"})
+	require.NoError(t, err)
+	crash(t, m)
+
+	_, r = openRepo(t, cfg)
+	_, err = r.GetByAlias(context.Background(), "", "dd")
+	require.NoError(t, err)
+}
+
+func TestOpen_RecordTooLarge(t *testing.T) {
+	cfg := testMemoryConfig(t)
+	ctx := context.Background()
+
+	m, r := openRepo(t, cfg)
+	fillRepo(t, r)
+
+	_, _, err := r.CreateOrGet(ctx, &model.URL{LongURL: "https://dd.com/" + strings.Repeat("d", maxRecordSize), Alias: "dd"})
+	require.ErrorIs(t, err, ErrRecordTooLarge)
+	_, err = r.GetByAlias(ctx, "", "dd")
+	require.ErrorIs(t, err, repository.ErrNotFound)
+
+	// Журнал не испорчен: следующая запись переживает перезапуск
+	_, _, err = r.CreateOrGet(ctx, &model.URL{LongURL: "https://dd.com", Alias: "dd"})
+	require.NoError(t, err)
+	crash(t, m)
+
+	_, r = openRepo(t, cfg)
+	assertFilledWithDD(t, r)
+}
+
+func TestOpen_Corrupted(t *testing.T) {
+	cfg := testMemoryConfig(t)
+
+	m, r := openRepo(t, cfg)
+	fillRepo(t, r)
+	name := m.walName
+	crash(t, m)
+
+	// Портим байт в первой записи, за которой идут целые записи
+	path := filepath.Join(cfg.DataDir, name)
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	b[10] ^= 0xff
+	require.NoError(t, os.WriteFile(path, b, 0o644))
+
+	_, err = Open(cfg)
+	require.ErrorIs(t, err, ErrCorrupted)
+}
+
+func TestOpen_CorruptedSnapshot(t *testing.T) {
+	cfg := testMemoryConfig(t)
+
+	m, r := openRepo(t, cfg)
+	fillRepo(t, r)
+	require.NoError(t, m.Close())
+
+	path := filepath.Join(cfg.DataDir, snapshotFile)
+	st, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, st.Size()-1))
+
+	_, err = Open(cfg)
+	require.ErrorIs(t, err, ErrCorrupted)
+}
+
+func TestOpen_FsyncInterval(t *testing.T) {
+	cfg := testMemoryConfig(t)
+	cfg.Fsync = FsyncInterval
+	cfg.FsyncInterval = time.Millisecond
+	cfg.SnapshotInterval = 5 * time.Millisecond
+
+	m, r := openRepo(t, cfg)
+	fillRepo(t, r)
+
+	// Фоновый снапшот удаляет сегменты, вошедшие в него
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(cfg.DataDir, snapshotFile))
+		return err == nil
+	}, time.Second, time.Millisecond)
+	require.NoError(t, m.Close())
+
+	_, r = openRepo(t, cfg)
+	assertFilled(t, r)
+}
+
+func TestOpen_InvalidConfig(t *testing.T) {
+	cfg := testMemoryConfig(t)
+	cfg.Fsync = "sometimes"
+
+	_, err := Open(cfg)
+	require.Error(t, err)
+}
+
+func TestOpen_Locked(t *testing.T) {
+	cfg := testMemoryConfig(t)
+	cfg.LockTimeout = 10 * time.Millisecond
+
+	m, err := Open(cfg)
+	require.NoError(t, err)
+
+	_, err = Open(cfg)
+	require.ErrorIs(t, err, ErrLocked)
+
+	require.NoError(t, m.Close())
+
+	m, err = Open(cfg)
+	require.NoError(t, err)
+	require.NoError(t, m.Close())
+}
+
+func TestOpen_NoDataDir(t *testing.T) {
+	m, err := Open(Config{})
+	require.NoError(t, err)
+	require.Nil(t, m.wal)
+	require.NoError(t, m.Close())
+}
@@ -3,6 +3,8 @@ package memory
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
 	"time"
 
 	"github.com/Rasulikus/url-shortener/internal/model"
@@ -23,42 +25,71 @@ func NewRepository(m *Memory) (*Repo, error) {
 }
 
 func (r *Repo) GetLastID(_ context.Context) (uint64, error) {
-	return 0, nil
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	return uint64(r.m.nextID - 1), nil
 }
 
-func (r *Repo) CreateOrGet(_ context.Context, url *model.URL) (*model.URL, error) {
+// LeaseIDs резервирует n ID, сдвигая счётчик nextID. Import и записи без ID получают
+// значения после зарезервированных, поэтому ID не повторяются. Резерв не пишется в
+// журнал: после перезапуска счётчик восстанавливается по сохранённым записям, и
+// неиспользованные ID выдаются снова.
+func (r *Repo) LeaseIDs(_ context.Context, n uint64) ([]uint64, error) {
 	r.m.mu.Lock()
 	defer r.m.mu.Unlock()
 
-	if existing, ok := r.m.byLong[url.LongURL]; ok {
-		url.ID = existing.ID
-		url.Alias = existing.Alias
-		url.CreatedAt = existing.CreatedAt
+	ids := make([]uint64, n)
+	for i := range ids {
+		ids[i] = uint64(r.m.nextID)
+		r.m.nextID++
+	}
+	return ids, nil
+}
+
+func (r *Repo) CreateOrGet(_ context.Context, url *model.URL) (*model.URL, bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if existing, ok := r.m.byLong[key(url.Domain, url.LongURL)]; ok {
+		*url = *existing
 		c := *url
-		return &c, nil
+		return &c, false, nil
 	}
 
-	if _, ok := r.m.byAlias[url.Alias]; ok {
-		return nil, repository.ErrConflict
+	if _, ok := r.m.byAlias[key(url.Domain, url.Alias)]; ok {
+		return nil, false, repository.ErrAliasConflict
 	}
 
-	url.ID = r.m.nextID
+	// ID, выданный генератором, сохраняем как есть, apply сдвинет собственный счётчик за него
+	if url.ID == 0 {
+		url.ID = r.m.nextID
+	} else if _, ok := r.m.byID[url.ID]; ok {
+		return nil, false, repository.ErrIDConflict
+	}
 	url.CreatedAt = time.Now().UTC()
 
-	r.m.nextID++
-
-	r.m.byAlias[url.Alias] = url
-	r.m.byLong[url.LongURL] = url
+	if err := r.m.commit(&record{Op: opPut, URL: url, Event: r.event(model.EventCreated, url)}); err != nil {
+		return nil, false, err
+	}
 
 	c := *url
-	return &c, nil
+	return &c, true, nil
 }
 
-func (r *Repo) GetByAlias(_ context.Context, alias string) (*model.URL, error) {
+// event создаёт событие для outbox с очередным ID. Событие пишется в ту же запись
+// журнала, что и изменение, поэтому сохраняется вместе с ним. Вызывается под m.mu.
+func (r *Repo) event(t model.EventType, u *model.URL) *model.Event {
+	ev := model.NewEvent(t, u)
+	ev.ID = r.m.nextEventID
+	return ev
+}
+
+func (r *Repo) GetByAlias(_ context.Context, domain, alias string) (*model.URL, error) {
 	r.m.mu.RLock()
 	defer r.m.mu.RUnlock()
 
-	u, ok := r.m.byAlias[alias]
+	u, ok := r.m.byAlias[key(domain, alias)]
 	if !ok {
 		return nil, repository.ErrNotFound
 	}
@@ -67,14 +98,149 @@ func (r *Repo) GetByAlias(_ context.Context, alias string) (*model.URL, error) {
 	return &c, nil
 }
 
-func (r *Repo) GetLongURLByAlias(_ context.Context, alias string) (string, error) {
+func (r *Repo) GetLongURLByAlias(_ context.Context, domain, alias string) (string, error) {
 	r.m.mu.RLock()
 	defer r.m.mu.RUnlock()
 
-	u, ok := r.m.byAlias[alias]
+	u, ok := r.m.byAlias[key(domain, alias)]
 	if !ok {
 		return "", repository.ErrNotFound
 	}
+	if u.Disabled {
+		return "", repository.ErrDisabled
+	}
 
 	return u.LongURL, nil
 }
+
+func (r *Repo) List(_ context.Context, f repository.ListFilter) ([]*model.URL, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	urls := make([]*model.URL, 0, len(r.m.byAlias))
+	for _, u := range r.m.byAlias {
+		if u.ID > f.AfterID && (!f.Broken || u.Health != nil && u.Health.Broken) {
+			c := *u
+			urls = append(urls, &c)
+		}
+	}
+
+	sort.Slice(urls, func(i, j int) bool { return urls[i].ID < urls[j].ID })
+	if len(urls) > f.Limit {
+		urls = urls[:f.Limit]
+	}
+
+	return urls, nil
+}
+
+func (r *Repo) SetDisabled(_ context.Context, domain, alias string, disabled bool) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	u, ok := r.m.byAlias[key(domain, alias)]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	c := *u
+	c.Disabled = disabled
+
+	return r.m.commit(&record{Op: opDisable, Domain: domain, Alias: alias, Disabled: disabled, Event: r.event(model.EventUpdated, &c)})
+}
+
+func (r *Repo) SetInterstitial(_ context.Context, domain, alias string, interstitial bool) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	u, ok := r.m.byAlias[key(domain, alias)]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	c := *u
+	c.Interstitial = interstitial
+
+	return r.m.commit(&record{Op: opInterstitial, Domain: domain, Alias: alias, Interstitial: interstitial, Event: r.event(model.EventUpdated, &c)})
+}
+
+func (r *Repo) SetMetadata(_ context.Context, domain, alias string, meta *model.Metadata) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if _, ok := r.m.byAlias[key(domain, alias)]; !ok {
+		return repository.ErrNotFound
+	}
+
+	return r.m.commit(&record{Op: opMetadata, Domain: domain, Alias: alias, Meta: meta})
+}
+
+func (r *Repo) SetHealth(_ context.Context, domain, alias string, h *model.Health) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if _, ok := r.m.byAlias[key(domain, alias)]; !ok {
+		return repository.ErrNotFound
+	}
+
+	return r.m.commit(&record{Op: opHealth, Domain: domain, Alias: alias, Health: h})
+}
+
+func (r *Repo) AddClick(_ context.Context, domain, alias string, thresholds []int64) (int64, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	u, ok := r.m.byAlias[key(domain, alias)]
+	if !ok {
+		return 0, repository.ErrNotFound
+	}
+	c := *u
+	c.Clicks++
+
+	rec := &record{Op: opClick, Domain: domain, Alias: alias, Clicks: c.Clicks}
+	if slices.Contains(thresholds, c.Clicks) {
+		rec.Event = r.event(model.EventClicks, &c)
+	}
+	if err := r.m.commit(rec); err != nil {
+		return 0, err
+	}
+
+	return c.Clicks, nil
+}
+
+func (r *Repo) Delete(_ context.Context, domain, alias string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	u, ok := r.m.byAlias[key(domain, alias)]
+	if !ok {
+		return repository.ErrNotFound
+	}
+
+	return r.m.commit(&record{Op: opDelete, Domain: domain, Alias: alias, Event: r.event(model.EventDeleted, u)})
+}
+
+func (r *Repo) Import(_ context.Context, urls []*model.URL) (int, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	imported := 0
+	for _, u := range urls {
+		if _, ok := r.m.byAlias[key(u.Domain, u.Alias)]; ok {
+			continue
+		}
+		if _, ok := r.m.byLong[key(u.Domain, u.LongURL)]; ok {
+			continue
+		}
+
+		c := *u
+		c.ID = r.m.nextID
+		if c.CreatedAt.IsZero() {
+			c.CreatedAt = time.Now().UTC()
+		}
+
+		if err := r.m.commit(&record{Op: opPut, URL: &c, Event: r.event(model.EventCreated, &c)}); err != nil {
+			return imported, err
+		}
+		imported++
+	}
+
+	return imported, nil
+}
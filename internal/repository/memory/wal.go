@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+)
+
+// ErrCorrupted — журнал или снапшот повреждён не только в хвосте.
+var ErrCorrupted = errors.New("memory: data file corrupted")
+
+// ErrRecordTooLarge — запись длиннее maxRecordSize; такую запись не прочитать при загрузке.
+var ErrRecordTooLarge = errors.New("memory: record too large")
+
+// maxRecordSize ограничивает длину записи, чтобы мусорный заголовок не приводил к огромной аллокации.
+const maxRecordSize = 1 << 20
+
+const (
+	opPut          = "put"
+	opDisable      = "disable"
+	opDelete       = "delete"
+	opInterstitial = "interstitial"
+	opMetadata     = "metadata"
+	opHealth       = "health"
+	opClick        = "click"
+	opSnapshot     = "snapshot"
+
+	// Операции outbox и доставок вебхуков. Событие изменения ссылки пишется
+	// в ту же запись, что и изменение, поле Event; opEvent — только в снапшоте.
+	opEvent          = "event"
+	opDispatch       = "dispatch"
+	opDelivery       = "delivery"
+	opDeleteDelivery = "delete_delivery"
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// record — изменение хранилища. Журнал и снапшот — последовательности записей.
+//
+// На диске запись хранится как [длина uint32][crc32c uint32][JSON], числа в big endian.
+type record struct {
+	// Seq — номер изменения; записи журнала с Seq не больше, чем у снапшота, при загрузке пропускаются.
+	Seq uint64 `json:"seq,omitempty"`
+	Op  string `json:"op"`
+
+	URL          *model.URL      `json:"url,omitempty"`
+	Domain       string          `json:"domain,omitempty"`
+	Alias        string          `json:"alias,omitempty"`
+	Disabled     bool            `json:"disabled,omitempty"`
+	Interstitial bool            `json:"interstitial,omitempty"`
+	Meta         *model.Metadata `json:"meta,omitempty"`
+	Health       *model.Health   `json:"health,omitempty"`
+	Clicks       int64           `json:"clicks,omitempty"`
+
+	Event      *model.Event      `json:"event,omitempty"`
+	ID         int64             `json:"id,omitempty"`
+	Delivery   *model.Delivery   `json:"delivery,omitempty"`
+	Deliveries []*model.Delivery `json:"deliveries,omitempty"`
+
+	// NextID, NextEventID и NextDeliveryID — только в заголовке снапшота.
+	NextID         int64 `json:"next_id,omitempty"`
+	NextEventID    int64 `json:"next_event_id,omitempty"`
+	NextDeliveryID int64 `json:"next_delivery_id,omitempty"`
+}
+
+// appendRecord пишет запись одним вызовом Write и возвращает число записанных байт.
+// Запись длиннее maxRecordSize не пишется: readRecords приняла бы её за повреждение.
+func appendRecord(w io.Writer, rec *record) (int, error) {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return 0, err
+	}
+	if len(payload) > maxRecordSize {
+		return 0, fmt.Errorf("%w: %d bytes, max %d", ErrRecordTooLarge, len(payload), maxRecordSize)
+	}
+
+	buf := make([]byte, 8+len(payload))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.Checksum(payload, crcTable))
+	copy(buf[8:], payload)
+
+	return w.Write(buf)
+}
+
+// readRecords читает записи из файла path и передаёт их fn.
+// Возвращает смещение конца последней целой записи. Недописанная или испорченная
+// последняя запись считается оборванной при сбое записью и не является ошибкой:
+// вызывающий может обрезать файл по возвращённому смещению. Повреждение в
+// середине файла приводит к ErrCorrupted.
+func readRecords(path string, fn func(rec *record) error) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := st.Size()
+
+	var (
+		r      = bufio.NewReader(f)
+		offset int64
+		header [8]byte
+	)
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return offset, nil
+			}
+			return offset, err
+		}
+
+		n := binary.BigEndian.Uint32(header[0:4])
+		end := offset + 8 + int64(n)
+		if n > maxRecordSize || end > size {
+			if end >= size {
+				return offset, nil
+			}
+			return offset, fmt.Errorf("%w: %s: bad record length at offset %d", ErrCorrupted, path, offset)
+		}
+
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return offset, err
+		}
+
+		var rec record
+		if crc32.Checksum(payload, crcTable) != binary.BigEndian.Uint32(header[4:8]) || json.Unmarshal(payload, &rec) != nil {
+			if end == size {
+				return offset, nil
+			}
+			return offset, fmt.Errorf("%w: %s: bad record at offset %d", ErrCorrupted, path, offset)
+		}
+
+		if err := fn(&rec); err != nil {
+			return offset, err
+		}
+		offset = end
+	}
+}
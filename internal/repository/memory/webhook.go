@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+)
+
+func (r *Repo) Events(_ context.Context, limit int) ([]*model.Event, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	events := make([]*model.Event, 0, len(r.m.events))
+	for _, ev := range r.m.events {
+		c := *ev
+		events = append(events, &c)
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+
+	return events, nil
+}
+
+func (r *Repo) Dispatch(_ context.Context, eventID int64, deliveries []*model.Delivery) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if _, ok := r.m.events[eventID]; !ok {
+		return repository.ErrNotFound
+	}
+	for i, d := range deliveries {
+		d.ID = r.m.nextDeliveryID + int64(i)
+	}
+
+	return r.m.commit(&record{Op: opDispatch, ID: eventID, Deliveries: deliveries})
+}
+
+func (r *Repo) ClaimDeliveries(_ context.Context, now time.Time, lease time.Duration, limit int) ([]*model.Delivery, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	var due []*model.Delivery
+	for _, d := range r.m.deliveries {
+		if d.Status == model.DeliveryPending && !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextAttemptAt.Equal(due[j].NextAttemptAt) {
+			return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]*model.Delivery, 0, len(due))
+	for _, d := range due {
+		c := *d
+		c.NextAttemptAt = now.Add(lease)
+		if err := r.m.commit(&record{Op: opDelivery, Delivery: &c}); err != nil {
+			return nil, err
+		}
+		claimed = append(claimed, &c)
+	}
+
+	return claimed, nil
+}
+
+func (r *Repo) UpdateDelivery(_ context.Context, d *model.Delivery) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	stored, ok := r.m.deliveries[d.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+
+	c := *stored
+	c.Status = d.Status
+	c.Attempts = d.Attempts
+	c.NextAttemptAt = d.NextAttemptAt
+	c.LastError = d.LastError
+
+	return r.m.commit(&record{Op: opDelivery, Delivery: &c})
+}
+
+func (r *Repo) DeleteDelivery(_ context.Context, id int64) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+
+	if _, ok := r.m.deliveries[id]; !ok {
+		return repository.ErrNotFound
+	}
+
+	return r.m.commit(&record{Op: opDeleteDelivery, ID: id})
+}
+
+func (r *Repo) ListDeliveries(_ context.Context, f repository.DeliveryFilter) ([]*model.Delivery, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+
+	list := make([]*model.Delivery, 0, len(r.m.deliveries))
+	for _, d := range r.m.deliveries {
+		if d.ID > f.AfterID && (f.Status == "" || d.Status == f.Status) {
+			c := *d
+			list = append(list, &c)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	if len(list) > f.Limit {
+		list = list[:f.Limit]
+	}
+
+	return list, nil
+}
@@ -14,7 +14,25 @@ func NewPool(cfg *config.DBConfig) (*pgxpool.Pool, error) {
 		return nil, fmt.Errorf("db config is nil")
 	}
 
-	poolcfg, err := pgxpool.ParseConfig(cfg.DSN())
+	pool, err := newPool(cfg, cfg.DSN())
+	if err != nil {
+		return nil, err
+	}
+
+	pingCtx, pingCancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer pingCancel()
+
+	if err = pool.Ping(pingCtx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to ping postgres: %w", err)
+	}
+
+	return pool, nil
+}
+
+// newPool создаёт пул по dsn с параметрами пула из cfg, не проверяя соединение.
+func newPool(cfg *config.DBConfig, dsn string) (*pgxpool.Pool, error) {
+	poolcfg, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse dsn: %w", err)
 	}
@@ -32,13 +50,5 @@ func NewPool(cfg *config.DBConfig) (*pgxpool.Pool, error) {
 		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
 	}
 
-	pingCtx, pingCancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer pingCancel()
-
-	if err = pool.Ping(pingCtx); err != nil {
-		pool.Close()
-		return nil, fmt.Errorf("failed to ping postgres: %w", err)
-	}
-
 	return pool, nil
 }
@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/config"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+const replicaPingTimeout = 2 * time.Second
+
+type replica struct {
+	host    string
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+}
+
+// Replicas распределяет чтения между репликами по кругу и периодически проверяет их доступность.
+// Если доступных реплик нет, чтения идут в основную БД.
+type Replicas struct {
+	primary  *pgxpool.Pool
+	replicas []*replica
+	next     atomic.Uint64
+
+	// Алиасы, изменённые меньше sticky назад, читаются из основной БД:
+	// реплика могла ещё не получить запись. Ключ — writtenKey.
+	sticky    time.Duration
+	mu        sync.Mutex
+	written   map[string]time.Time
+	lastSweep time.Time
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewReplicas создаёт пулы реплик из cfg.ReplicaDSNs с DB_PARAMS и сертификатами основной БД и запускает проверку их доступности.
+// Недоступная при старте реплика не считается ошибкой: она начнёт получать запросы, когда поднимется.
+func NewReplicas(primary *pgxpool.Pool, cfg *config.DBConfig) (*Replicas, error) {
+	if primary == nil {
+		return nil, fmt.Errorf("primary pool is nil")
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("db config is nil")
+	}
+
+	rs := &Replicas{
+		primary: primary,
+		sticky:  cfg.ReplicaStickyWindow,
+		written: make(map[string]time.Time),
+		stop:    make(chan struct{}),
+	}
+	for _, dsn := range cfg.ReplicaDSNs {
+		pool, err := newPool(cfg, cfg.ReplicaDSN(dsn))
+		if err != nil {
+			rs.closePools()
+			return nil, fmt.Errorf("replica: %w", err)
+		}
+		r := &replica{
+			host: pool.Config().ConnConfig.Host,
+			pool: pool,
+		}
+		// Считаем реплику доступной до первой проверки, чтобы её недоступность при старте попала в лог
+		r.healthy.Store(true)
+		rs.replicas = append(rs.replicas, r)
+	}
+
+	rs.checkHealth()
+
+	rs.wg.Add(1)
+	go rs.healthLoop(cfg.ReplicaHealthInterval)
+
+	return rs, nil
+}
+
+// Close останавливает проверку доступности и закрывает пулы реплик. Основной пул не закрывается.
+func (rs *Replicas) Close() {
+	close(rs.stop)
+	rs.wg.Wait()
+	rs.closePools()
+}
+
+func (rs *Replicas) closePools() {
+	for _, r := range rs.replicas {
+		r.pool.Close()
+	}
+}
+
+// pool возвращает пул для чтения алиаса на домене. Пустой алиас означает чтение без привязки к записи.
+func (rs *Replicas) pool(domain, alias string) *pgxpool.Pool {
+	if alias != "" && rs.recentlyWritten(writtenKey(domain, alias)) {
+		return rs.primary
+	}
+
+	n := uint64(len(rs.replicas))
+	start := rs.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if r := rs.replicas[(start+i)%n]; r.healthy.Load() {
+			return r.pool
+		}
+	}
+	return rs.primary
+}
+
+// writtenKey — ключ алиаса в written: одинаковые алиасы разных доменов различаются.
+func writtenKey(domain, alias string) string {
+	return domain + "\x00" + alias
+}
+
+// markWritten запоминает, что алиасы домена только что изменены в основной БД.
+func (rs *Replicas) markWritten(domain string, aliases ...string) {
+	if rs.sticky <= 0 {
+		return
+	}
+
+	now := time.Now()
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	for _, alias := range aliases {
+		rs.written[writtenKey(domain, alias)] = now.Add(rs.sticky)
+	}
+
+	// Просроченные записи удаляем не чаще раза за окно, чтобы не обходить карту на каждую запись
+	if now.Sub(rs.lastSweep) >= rs.sticky {
+		for key, until := range rs.written {
+			if now.After(until) {
+				delete(rs.written, key)
+			}
+		}
+		rs.lastSweep = now
+	}
+}
+
+func (rs *Replicas) recentlyWritten(key string) bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	until, ok := rs.written[key]
+	return ok && time.Now().Before(until)
+}
+
+func (rs *Replicas) healthLoop(interval time.Duration) {
+	defer rs.wg.Done()
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-rs.stop:
+			return
+		case <-t.C:
+			rs.checkHealth()
+		}
+	}
+}
+
+// checkHealth пингует все реплики и логирует смену их состояния.
+func (rs *Replicas) checkHealth() {
+	for _, r := range rs.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), replicaPingTimeout)
+		err := r.pool.Ping(ctx)
+		cancel()
+
+		healthy := err == nil
+		if r.healthy.Swap(healthy) == healthy {
+			continue
+		}
+		if healthy {
+			log.Info().Str("host", r.host).Msg("postgres replica is up")
+		} else {
+			log.Warn().Err(err).Str("host", r.host).Msg("postgres replica is down, reading from primary")
+		}
+	}
+}
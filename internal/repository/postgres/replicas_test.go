@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository/repositorytest"
+	urlService "github.com/Rasulikus/url-shortener/internal/service/url"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestReplicas создаёт реплики с заданными DSN; основной пул — тестовый.
+func newTestReplicas(t *testing.T, dsns ...string) *Replicas {
+	t.Helper()
+
+	cfg := *testDBCfg
+	cfg.ReplicaDSNs = dsns
+	cfg.ReplicaHealthInterval = time.Minute
+	cfg.ReplicaStickyWindow = time.Minute
+
+	rs, err := NewReplicas(testPool, &cfg)
+	require.NoError(t, err)
+	t.Cleanup(rs.Close)
+
+	return rs
+}
+
+func TestReplicatedRepo(t *testing.T) {
+	// Реплика указывает на ту же БД, поэтому общий набор проверок применим без изменений
+	repositorytest.Run(t, func(t *testing.T) urlService.URLRepository {
+		s := setupTestSuite(t)
+		repo, err := NewReplicatedRepository(s.pool, newTestReplicas(t, testDBCfg.DSN()))
+		require.NoError(t, err)
+		return repo
+	})
+}
+
+func TestReplicas_ReadYourWrites(t *testing.T) {
+	s := setupTestSuite(t)
+	rs := newTestReplicas(t, testDBCfg.DSN())
+	repo, err := NewReplicatedRepository(s.pool, rs)
+	require.NoError(t, err)
+
+	ctx, cancel := s.ctx2s()
+	defer cancel()
+
+	assert.NotSame(t, testPool, rs.pool("", "aa"))
+
+	_, _, err = repo.CreateOrGet(ctx, &model.URL{LongURL: "https://rkrkrkrk.com", Alias: "aa"})
+	require.NoError(t, err)
+
+	assert.Same(t, testPool, rs.pool("", "aa"), "just written alias must be read from primary")
+	assert.NotSame(t, testPool, rs.pool("", "bb"))
+	assert.NotSame(t, testPool, rs.pool("brand.link", "aa"), "alias on another domain was not written")
+	assert.NotSame(t, testPool, rs.pool("", ""))
+}
+
+func TestReplicas_FallbackToPrimary(t *testing.T) {
+	s := setupTestSuite(t)
+
+	down := *testDBCfg
+	down.Port = "1"
+	rs := newTestReplicas(t, down.DSN())
+	require.Same(t, testPool, rs.pool("", "aa"), "unreachable replica must not be used")
+
+	repo, err := NewReplicatedRepository(s.pool, rs)
+	require.NoError(t, err)
+
+	ctx, cancel := s.ctx2s()
+	defer cancel()
+
+	u := insertURL(t, ctx, s.pool, &model.URL{LongURL: "https://rkrkrkrk.com", Alias: "aa"})
+
+	longURL, err := repo.GetLongURLByAlias(ctx, u.Domain, u.Alias)
+	require.NoError(t, err)
+	assert.Equal(t, u.LongURL, longURL)
+}
+
+func TestReplicas_StaleReplicaRetriesPrimary(t *testing.T) {
+	s := setupTestSuite(t)
+	rs := newTestReplicas(t, testDBCfg.DSN())
+	repo, err := NewReplicatedRepository(s.pool, rs)
+	require.NoError(t, err)
+
+	ctx, cancel := s.ctx2s()
+	defer cancel()
+
+	// Запись в обход репозитория, как если бы её сделал другой экземпляр
+	u := insertURL(t, ctx, s.pool, &model.URL{LongURL: "https://rkrkrkrk.com", Alias: "aa"})
+	require.NotSame(t, testPool, rs.pool(u.Domain, u.Alias))
+
+	got, err := repo.GetByAlias(context.Background(), u.Domain, u.Alias)
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, got.ID)
+}
@@ -32,6 +32,6 @@ func NewTestPool() (*pgxpool.Pool, error) {
 }
 
 func TruncateUrls(ctx context.Context, pool *pgxpool.Pool) error {
-	_, err := pool.Exec(ctx, `TRUNCATE TABLE urls RESTART IDENTITY;`)
+	_, err := pool.Exec(ctx, `TRUNCATE TABLE urls, webhook_outbox, webhook_deliveries RESTART IDENTITY;`)
 	return err
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/Rasulikus/url-shortener/internal/model"
 	"github.com/Rasulikus/url-shortener/internal/repository"
@@ -12,8 +14,31 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const (
+	uniqueViolation = "23505"
+
+	constraintID      = "urls_pkey"
+	constraintAlias   = "urls_domain_alias_key"
+	constraintLongURL = "urls_domain_long_url_key"
+)
+
+// conflictError определяет вид конфликта по имени нарушенного ограничения.
+func conflictError(pgErr *pgconn.PgError) error {
+	switch pgErr.ConstraintName {
+	case constraintID:
+		return repository.ErrIDConflict
+	case constraintAlias:
+		return repository.ErrAliasConflict
+	case constraintLongURL:
+		return repository.ErrLongURLConflict
+	default:
+		return repository.ErrConflict
+	}
+}
+
 type Repo struct {
-	pool *pgxpool.Pool
+	pool     *pgxpool.Pool
+	replicas *Replicas
 }
 
 func NewRepository(pool *pgxpool.Pool) (*Repo, error) {
@@ -26,10 +51,49 @@ func NewRepository(pool *pgxpool.Pool) (*Repo, error) {
 	}, nil
 }
 
+// NewReplicatedRepository создаёт репозиторий, который пишет в pool, а чтения по алиасу
+// и списки направляет в реплики.
+func NewReplicatedRepository(pool *pgxpool.Pool, replicas *Replicas) (*Repo, error) {
+	r, err := NewRepository(pool)
+	if err != nil {
+		return nil, err
+	}
+	if replicas == nil {
+		return nil, errors.New("repository: replicas is nil")
+	}
+
+	r.replicas = replicas
+	return r, nil
+}
+
+// read выполняет чтение на реплике, а если она недоступна или ещё не получила запись,
+// повторяет его на основной БД. Без реплик чтение сразу идёт в основную БД.
+func (r *Repo) read(ctx context.Context, domain, alias string, fn func(pool *pgxpool.Pool) error) error {
+	if r.replicas == nil {
+		return fn(r.pool)
+	}
+
+	pool := r.replicas.pool(domain, alias)
+	err := fn(pool)
+	if err == nil || pool == r.pool || errors.Is(err, repository.ErrDisabled) || ctx.Err() != nil {
+		return err
+	}
+	return fn(r.pool)
+}
+
+// written отмечает алиасы домена, изменённые в основной БД, чтобы их сразу читать оттуда.
+func (r *Repo) written(domain string, aliases ...string) {
+	if r.replicas != nil {
+		r.replicas.markWritten(domain, aliases...)
+	}
+}
+
+// GetLastID возвращает последнее значение последовательности ID ссылок.
+// В отличие от MAX(id) учитывает значения, выданные под ещё не сохранённые записи.
 func (r *Repo) GetLastID(ctx context.Context) (uint64, error) {
 	const q = `
-	SELECT COALESCE(MAX(id), 0)
-	FROM urls;
+	SELECT CASE WHEN is_called THEN last_value ELSE 0 END
+	FROM urls_id_seq;
 `
 	var id uint64
 	err := r.pool.QueryRow(ctx, q).Scan(&id)
@@ -39,38 +103,73 @@ func (r *Repo) GetLastID(ctx context.Context) (uint64, error) {
 	return id, nil
 }
 
-func (r *Repo) CreateOrGet(ctx context.Context, u *model.URL) (*model.URL, error) {
+// LeaseIDs резервирует n значений последовательности urls_id_seq. Значения
+// уникальны между всеми экземплярами сервиса, но могут идти не подряд.
+func (r *Repo) LeaseIDs(ctx context.Context, n uint64) ([]uint64, error) {
+	const q = `
+	SELECT nextval('urls_id_seq') FROM generate_series(1, $1);
+`
+	rows, err := r.pool.Query(ctx, q, int64(n))
+	if err != nil {
+		return nil, fmt.Errorf("repository: LeaseIDs: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uint64])
+	if err != nil {
+		return nil, fmt.Errorf("repository: LeaseIDs: %w", err)
+	}
+	return ids, nil
+}
+
+func (r *Repo) CreateOrGet(ctx context.Context, u *model.URL) (*model.URL, bool, error) {
+	// Если ID не передан, берём его из той же последовательности, что и LeaseIDs.
+	// xmax равен 0 только у вставленной строки, у найденной по конфликту — нет
 	const q = `
-	INSERT INTO urls (long_url, alias)
-	VALUES ($1, $2)
-	ON CONFLICT (long_url) DO UPDATE
+	INSERT INTO urls (id, domain, long_url, alias)
+	VALUES (COALESCE(NULLIF($1::BIGINT, 0), nextval('urls_id_seq')), $2, $3, $4)
+	ON CONFLICT (domain, long_url) DO UPDATE
 	SET long_url = excluded.long_url
-	RETURNING id, long_url, alias, created_at;
+	RETURNING id, domain, long_url, alias, created_at, disabled, interstitial, metadata, health, clicks, xmax = 0;
 `
 
-	err := r.pool.QueryRow(ctx, q, u.LongURL, u.Alias).Scan(&u.ID, &u.LongURL, &u.Alias, &u.CreatedAt)
+	var inserted bool
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, q, u.ID, u.Domain, u.LongURL, u.Alias).
+			Scan(&u.ID, &u.Domain, &u.LongURL, &u.Alias, &u.CreatedAt, &u.Disabled, &u.Interstitial, &u.Meta, &u.Health, &u.Clicks, &inserted)
+		if err != nil || !inserted {
+			return err
+		}
+		return addEvent(ctx, tx, model.NewEvent(model.EventCreated, u))
+	})
 	if err != nil {
 		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-			return nil, repository.ErrConflict
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return nil, false, conflictError(pgErr)
 		}
-		return nil, fmt.Errorf("repository: insert u: %w", err)
+		return nil, false, fmt.Errorf("repository: insert u: %w", err)
 	}
+	r.written(u.Domain, u.Alias)
 
-	return u, nil
+	return u, inserted, nil
 }
 
-func (r *Repo) GetByAlias(ctx context.Context, alias string) (*model.URL, error) {
+func (r *Repo) GetByAlias(ctx context.Context, domain, alias string) (*model.URL, error) {
 	const q = `
-	SELECT id, long_url, alias, created_at FROM urls WHERE alias = $1;
+	SELECT id, domain, long_url, alias, created_at, disabled, interstitial, metadata, health, clicks FROM urls WHERE domain = $1 AND alias = $2;
 `
 
 	url := new(model.URL)
 
-	err := r.pool.QueryRow(ctx, q, alias).Scan(&url.ID, &url.LongURL, &url.Alias, &url.CreatedAt)
-	if err != nil {
+	err := r.read(ctx, domain, alias, func(pool *pgxpool.Pool) error {
+		err := pool.QueryRow(ctx, q, domain, alias).Scan(&url.ID, &url.Domain, &url.LongURL, &url.Alias, &url.CreatedAt, &url.Disabled, &url.Interstitial, &url.Meta, &url.Health, &url.Clicks)
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, repository.ErrNotFound
+			return repository.ErrNotFound
+		}
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, err
 		}
 		return nil, fmt.Errorf("repository: select url by alias: %w", err)
 	}
@@ -78,20 +177,269 @@ func (r *Repo) GetByAlias(ctx context.Context, alias string) (*model.URL, error)
 	return url, nil
 }
 
-func (r *Repo) GetLongURLByAlias(ctx context.Context, alias string) (string, error) {
+// GetLongURLByAlias возвращает длинный URL по алиасу или ErrDisabled, если ссылка отключена.
+func (r *Repo) GetLongURLByAlias(ctx context.Context, domain, alias string) (string, error) {
 	const q = `
-	SELECT long_url FROM urls WHERE alias = $1;
+	SELECT long_url, disabled FROM urls WHERE domain = $1 AND alias = $2;
 `
 
-	var longURL string
+	var (
+		longURL  string
+		disabled bool
+	)
 
-	err := r.pool.QueryRow(ctx, q, alias).Scan(&longURL)
-	if err != nil {
+	err := r.read(ctx, domain, alias, func(pool *pgxpool.Pool) error {
+		err := pool.QueryRow(ctx, q, domain, alias).Scan(&longURL, &disabled)
 		if errors.Is(err, pgx.ErrNoRows) {
-			return "", repository.ErrNotFound
+			return repository.ErrNotFound
+		}
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return "", err
 		}
 		return "", fmt.Errorf("repository: select longURL by alias: %w", err)
 	}
+	if disabled {
+		return "", repository.ErrDisabled
+	}
 
 	return longURL, nil
 }
+
+func (r *Repo) List(ctx context.Context, f repository.ListFilter) ([]*model.URL, error) {
+	const q = `
+	SELECT id, domain, long_url, alias, created_at, disabled, interstitial, metadata, health, clicks
+	FROM urls
+	WHERE id > $1 AND (NOT $3 OR broken)
+	ORDER BY id
+	LIMIT $2;
+`
+
+	var urls []*model.URL
+	err := r.read(ctx, "", "", func(pool *pgxpool.Pool) error {
+		rows, err := pool.Query(ctx, q, f.AfterID, f.Limit, f.Broken)
+		if err != nil {
+			return err
+		}
+
+		urls, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*model.URL, error) {
+			u := new(model.URL)
+			err := row.Scan(&u.ID, &u.Domain, &u.LongURL, &u.Alias, &u.CreatedAt, &u.Disabled, &u.Interstitial, &u.Meta, &u.Health, &u.Clicks)
+			return u, err
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("repository: list urls: %w", err)
+	}
+
+	return urls, nil
+}
+
+func (r *Repo) SetDisabled(ctx context.Context, domain, alias string, disabled bool) error {
+	const q = `
+	UPDATE urls SET disabled = $3 WHERE domain = $1 AND alias = $2
+	RETURNING domain, long_url, alias, disabled, interstitial, clicks;
+`
+
+	err := r.withEvent(ctx, model.EventUpdated, q, domain, alias, disabled)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("repository: set disabled: %w", err)
+	}
+	r.written(domain, alias)
+
+	return nil
+}
+
+func (r *Repo) SetInterstitial(ctx context.Context, domain, alias string, interstitial bool) error {
+	const q = `
+	UPDATE urls SET interstitial = $3 WHERE domain = $1 AND alias = $2
+	RETURNING domain, long_url, alias, disabled, interstitial, clicks;
+`
+
+	err := r.withEvent(ctx, model.EventUpdated, q, domain, alias, interstitial)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("repository: set interstitial: %w", err)
+	}
+	r.written(domain, alias)
+
+	return nil
+}
+
+func (r *Repo) SetMetadata(ctx context.Context, domain, alias string, meta *model.Metadata) error {
+	const q = `
+	UPDATE urls SET metadata = $3 WHERE domain = $1 AND alias = $2;
+`
+
+	tag, err := r.pool.Exec(ctx, q, domain, alias, meta)
+	if err != nil {
+		return fmt.Errorf("repository: set metadata: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	r.written(domain, alias)
+
+	return nil
+}
+
+// SetHealth не отмечает ссылку для чтения из основной БД: проверка обновляет все ссылки
+// периодически, и отставание реплики здесь не мешает.
+func (r *Repo) SetHealth(ctx context.Context, domain, alias string, h *model.Health) error {
+	const q = `
+	UPDATE urls SET health = $3, broken = $4 WHERE domain = $1 AND alias = $2;
+`
+
+	tag, err := r.pool.Exec(ctx, q, domain, alias, h, h != nil && h.Broken)
+	if err != nil {
+		return fmt.Errorf("repository: set health: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// AddClick не отмечает ссылку для чтения из основной БД: счётчик читается
+// только в событиях, и отставание реплики здесь не мешает.
+func (r *Repo) AddClick(ctx context.Context, domain, alias string, thresholds []int64) (int64, error) {
+	const q = `
+	UPDATE urls SET clicks = clicks + 1 WHERE domain = $1 AND alias = $2
+	RETURNING domain, long_url, alias, disabled, interstitial, clicks;
+`
+
+	var clicks int64
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		u, err := scanEventURL(tx.QueryRow(ctx, q, domain, alias))
+		if err != nil {
+			return err
+		}
+		clicks = u.Clicks
+		if !slices.Contains(thresholds, clicks) {
+			return nil
+		}
+		return addEvent(ctx, tx, model.NewEvent(model.EventClicks, u))
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return 0, err
+		}
+		return 0, fmt.Errorf("repository: add click: %w", err)
+	}
+
+	return clicks, nil
+}
+
+func (r *Repo) Delete(ctx context.Context, domain, alias string) error {
+	const q = `
+	DELETE FROM urls WHERE domain = $1 AND alias = $2
+	RETURNING domain, long_url, alias, disabled, interstitial, clicks;
+`
+
+	err := r.withEvent(ctx, model.EventDeleted, q, domain, alias)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("repository: delete url: %w", err)
+	}
+	r.written(domain, alias)
+
+	return nil
+}
+
+// withEvent выполняет изменение q, которое возвращает поля ссылки, см. scanEventURL,
+// и той же транзакцией пишет в outbox событие типа t. Если ссылка не найдена, возвращает ErrNotFound.
+func (r *Repo) withEvent(ctx context.Context, t model.EventType, q string, args ...any) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		u, err := scanEventURL(tx.QueryRow(ctx, q, args...))
+		if err != nil {
+			return err
+		}
+		return addEvent(ctx, tx, model.NewEvent(t, u))
+	})
+}
+
+// scanEventURL читает поля ссылки для события: domain, long_url, alias, disabled, interstitial, clicks.
+func scanEventURL(row pgx.Row) (*model.URL, error) {
+	u := new(model.URL)
+	err := row.Scan(&u.Domain, &u.LongURL, &u.Alias, &u.Disabled, &u.Interstitial, &u.Clicks)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	return u, err
+}
+
+// Import вставляет ссылки пачкой, пропуская записи, чей алиас или длинный URL уже заняты.
+// ID выдаются из последовательности, created_at сохраняется, если задан. Возвращает число вставленных записей.
+func (r *Repo) Import(ctx context.Context, urls []*model.URL) (int, error) {
+	const q = `
+	INSERT INTO urls (domain, long_url, alias, created_at, disabled, interstitial)
+	SELECT domain, long_url, alias, COALESCE(created_at, NOW()), disabled, interstitial
+	FROM unnest($1::TEXT[], $2::TEXT[], $3::TEXT[], $4::TIMESTAMPTZ[], $5::BOOLEAN[], $6::BOOLEAN[])
+		AS t(domain, long_url, alias, created_at, disabled, interstitial)
+	ON CONFLICT DO NOTHING
+	RETURNING domain, long_url, alias, disabled, interstitial;
+`
+
+	var (
+		domains      = make([]string, len(urls))
+		longURLs     = make([]string, len(urls))
+		aliases      = make([]string, len(urls))
+		createdAt    = make([]*time.Time, len(urls))
+		disabled     = make([]bool, len(urls))
+		interstitial = make([]bool, len(urls))
+	)
+	for i, u := range urls {
+		domains[i] = u.Domain
+		longURLs[i] = u.LongURL
+		aliases[i] = u.Alias
+		if !u.CreatedAt.IsZero() {
+			createdAt[i] = &u.CreatedAt
+		}
+		disabled[i] = u.Disabled
+		interstitial[i] = u.Interstitial
+	}
+
+	// События о сохранённых записях пишутся в outbox той же транзакцией
+	imported := 0
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, q, domains, longURLs, aliases, createdAt, disabled, interstitial)
+		if err != nil {
+			return err
+		}
+		inserted, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*model.URL, error) {
+			u := new(model.URL)
+			err := row.Scan(&u.Domain, &u.LongURL, &u.Alias, &u.Disabled, &u.Interstitial)
+			return u, err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, u := range inserted {
+			if err := addEvent(ctx, tx, model.NewEvent(model.EventCreated, u)); err != nil {
+				return err
+			}
+		}
+		imported = len(inserted)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("repository: import urls: %w", err)
+	}
+	for _, u := range urls {
+		r.written(u.Domain, u.Alias)
+	}
+
+	return imported, nil
+}
@@ -7,9 +7,9 @@ import (
 	"time"
 
 	"github.com/Rasulikus/url-shortener/internal/model"
-	"github.com/Rasulikus/url-shortener/internal/repository"
+	"github.com/Rasulikus/url-shortener/internal/repository/repositorytest"
+	urlService "github.com/Rasulikus/url-shortener/internal/service/url"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -60,15 +60,15 @@ func insertURL(t *testing.T, ctx context.Context, pool *pgxpool.Pool, url *model
 
 	u := new(model.URL)
 	const q = `
-	INSERT INTO urls (long_url, alias)
-	VALUES ($1, $2)
-	RETURNING id, long_url, alias, created_at;
+	INSERT INTO urls (domain, long_url, alias)
+	VALUES ($1, $2, $3)
+	RETURNING id, domain, long_url, alias, created_at;
 `
 
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
-	err := pool.QueryRow(ctx, q, url.LongURL, url.Alias).Scan(&u.ID, &u.LongURL, &u.Alias, &u.CreatedAt)
+	err := pool.QueryRow(ctx, q, url.Domain, url.LongURL, url.Alias).Scan(&u.ID, &u.Domain, &u.LongURL, &u.Alias, &u.CreatedAt)
 	require.NoError(t, err)
 
 	require.NotZero(t, u.ID)
@@ -80,49 +80,10 @@ func insertURL(t *testing.T, ctx context.Context, pool *pgxpool.Pool, url *model
 	return u
 }
 
-func TestRepo_GetByAlias(t *testing.T) {
-	s := setupTestSuite(t)
-
-	u := &model.URL{
-		LongURL: "https://rkrkrkrk.com",
-		Alias:   "aa",
-	}
-	newU := insertURL(t, s.ctx, s.pool, u)
-
-	cases := []struct {
-		name  string
-		alias string
-		check func(t *testing.T, get *model.URL, err error)
-	}{
-		{
-			name:  "found",
-			alias: "aa",
-			check: func(t *testing.T, get *model.URL, err error) {
-				require.NoError(t, err)
-				assert.Equal(t, newU.ID, get.ID)
-				assert.Equal(t, newU.LongURL, get.LongURL)
-				assert.Equal(t, newU.Alias, get.Alias)
-				assert.True(t, newU.CreatedAt.Equal(get.CreatedAt))
-			},
-		},
-		{
-			name:  "not found",
-			alias: "bb",
-			check: func(t *testing.T, get *model.URL, err error) {
-				require.Nil(t, get)
-				require.ErrorIs(t, err, repository.ErrNotFound)
-			},
-		},
-	}
-
-	for _, tc := range cases {
-		t.Run(tc.name, func(t *testing.T) {
-			ctx, candel := s.ctx2s()
-			defer candel()
-			get, err := s.urlRepo.GetByAlias(ctx, tc.alias)
-			tc.check(t, get, err)
-		})
-	}
+func TestRepo(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) urlService.URLRepository {
+		return setupTestSuite(t).urlRepo
+	})
 }
 
 func TestRepo_GetByAliasCtxCancelErr(t *testing.T) {
@@ -137,113 +98,36 @@ func TestRepo_GetByAliasCtxCancelErr(t *testing.T) {
 	ctx, cancel := s.ctx2s()
 	cancel()
 	t.Run("ctx err", func(t *testing.T) {
-		get, err := s.urlRepo.GetByAlias(ctx, newU.Alias)
+		get, err := s.urlRepo.GetByAlias(ctx, newU.Domain, newU.Alias)
 		require.ErrorIs(t, err, context.Canceled)
 		require.Nil(t, get)
 	})
 }
 
-func TestRepo_GetLongURLByAlias(t *testing.T) {
+func TestRepo_LeaseIDs(t *testing.T) {
 	s := setupTestSuite(t)
 
-	u := &model.URL{
-		LongURL: "https://rkrkrkrk.com",
-		Alias:   "aa",
-	}
-
-	newU := insertURL(t, s.ctx, s.pool, u)
-
-	cases := []struct {
-		name  string
-		alias string
-		check func(t *testing.T, get string, err error)
-	}{
-		{
-			name:  "found",
-			alias: u.Alias,
-			check: func(t *testing.T, get string, err error) {
-				require.NoError(t, err)
-				assert.Equal(t, newU.LongURL, get)
-			},
-		},
-		{
-			name:  "not found",
-			alias: "bb",
-			check: func(t *testing.T, get string, err error) {
-				require.Zero(t, get)
-				require.ErrorIs(t, err, repository.ErrNotFound)
-			},
-		},
-	}
+	ctx, cancel := s.ctx2s()
+	defer cancel()
 
-	for _, tc := range cases {
-		t.Run(tc.name, func(t *testing.T) {
-			ctx, candel := s.ctx2s()
-			defer candel()
-			get, err := s.urlRepo.GetLongURLByAlias(ctx, tc.alias)
-			tc.check(t, get, err)
-		})
-	}
+	first, err := s.urlRepo.LeaseIDs(ctx, 100)
+	require.NoError(t, err)
+	require.Len(t, first, 100)
+	require.Equal(t, uint64(1), first[0])
 
-}
+	second, err := s.urlRepo.LeaseIDs(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, uint64(101), second[0])
 
-func TestRepo_CreateOrGet(t *testing.T) {
-	s := setupTestSuite(t)
+	last, err := s.urlRepo.GetLastID(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(110), last)
 
-	u := &model.URL{
+	// Запись без ID получает следующее значение той же последовательности
+	u, _, err := s.urlRepo.CreateOrGet(ctx, &model.URL{
 		LongURL: "https://rkrkrkrk.com",
 		Alias:   "aa",
-	}
-	uWithExistingLongURL := &model.URL{
-		LongURL: u.LongURL,
-		Alias:   "bb",
-	}
-	uWithExistingAlias := &model.URL{
-		LongURL: "htttp://aaaa.com",
-		Alias:   "aa",
-	}
-	cases := []struct {
-		name  string
-		url   *model.URL
-		check func(t *testing.T, get *model.URL, err error)
-	}{
-		{
-			name: "created",
-			url:  u,
-			check: func(t *testing.T, get *model.URL, err error) {
-				require.NoError(t, err)
-				assert.NotZero(t, get.ID)
-				assert.Equal(t, u.LongURL, get.LongURL)
-				assert.Equal(t, u.Alias, get.Alias)
-				assert.Equal(t, u.CreatedAt, get.CreatedAt)
-				assert.WithinDuration(t, time.Now(), u.CreatedAt, 2*time.Second)
-			},
-		},
-		{
-			name: "get",
-			url:  uWithExistingLongURL,
-			check: func(t *testing.T, get *model.URL, err error) {
-				require.NoError(t, err)
-				assert.Equal(t, get, u)
-				assert.Equal(t, get, uWithExistingLongURL)
-			},
-		},
-		{
-			name: "err conflict",
-			url:  uWithExistingAlias,
-			check: func(t *testing.T, get *model.URL, err error) {
-				require.Nil(t, get)
-				require.ErrorIs(t, err, repository.ErrConflict)
-			},
-		},
-	}
-
-	for _, tc := range cases {
-		t.Run(tc.name, func(t *testing.T) {
-			ctx, cancel := s.ctx2s()
-			defer cancel()
-			get, err := s.urlRepo.CreateOrGet(ctx, tc.url)
-			tc.check(t, get, err)
-		})
-	}
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(111), u.ID)
 }
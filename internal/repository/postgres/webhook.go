@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+// addEvent пишет событие в outbox в транзакции изменения ссылки.
+func addEvent(ctx context.Context, tx pgx.Tx, ev *model.Event) error {
+	const q = `
+	INSERT INTO webhook_outbox (event) VALUES ($1);
+`
+
+	if _, err := tx.Exec(ctx, q, ev); err != nil {
+		return fmt.Errorf("write outbox: %w", err)
+	}
+	return nil
+}
+
+func scanDelivery(row pgx.CollectableRow) (*model.Delivery, error) {
+	d := new(model.Delivery)
+	err := row.Scan(&d.ID, &d.Webhook, &d.Event, &d.Status, &d.Attempts, &d.NextAttemptAt, &d.LastError, &d.CreatedAt)
+	return d, err
+}
+
+func (r *Repo) Events(ctx context.Context, limit int) ([]*model.Event, error) {
+	const q = `
+	SELECT id, event FROM webhook_outbox ORDER BY id LIMIT $1;
+`
+
+	rows, err := r.pool.Query(ctx, q, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository: list events: %w", err)
+	}
+
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*model.Event, error) {
+		var (
+			id int64
+			ev *model.Event
+		)
+		if err := row.Scan(&id, &ev); err != nil {
+			return nil, err
+		}
+		ev.ID = id
+		return ev, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("repository: list events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *Repo) Dispatch(ctx context.Context, eventID int64, deliveries []*model.Delivery) error {
+	const (
+		qDelete = `
+	DELETE FROM webhook_outbox WHERE id = $1;
+`
+		qInsert = `
+	INSERT INTO webhook_deliveries (webhook, event, status, attempts, next_attempt_at, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id;
+`
+	)
+
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, qDelete, eventID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return repository.ErrNotFound
+		}
+
+		for _, d := range deliveries {
+			err := tx.QueryRow(ctx, qInsert, d.Webhook, d.Event, string(d.Status), d.Attempts, d.NextAttemptAt, d.CreatedAt).Scan(&d.ID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("repository: dispatch event: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimDeliveries пропускает строки, заблокированные другим экземпляром, поэтому
+// одновременные вызовы получают разные доставки.
+func (r *Repo) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.Delivery, error) {
+	const q = `
+	UPDATE webhook_deliveries SET next_attempt_at = $2
+	WHERE id IN (
+		SELECT id FROM webhook_deliveries
+		WHERE status = 'pending' AND next_attempt_at <= $1
+		ORDER BY next_attempt_at
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING id, webhook, event, status, attempts, next_attempt_at, last_error, created_at;
+`
+
+	rows, err := r.pool.Query(ctx, q, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository: claim deliveries: %w", err)
+	}
+
+	list, err := pgx.CollectRows(rows, scanDelivery)
+	if err != nil {
+		return nil, fmt.Errorf("repository: claim deliveries: %w", err)
+	}
+
+	return list, nil
+}
+
+func (r *Repo) UpdateDelivery(ctx context.Context, d *model.Delivery) error {
+	const q = `
+	UPDATE webhook_deliveries SET status = $2, attempts = $3, next_attempt_at = $4, last_error = $5 WHERE id = $1;
+`
+
+	tag, err := r.pool.Exec(ctx, q, d.ID, string(d.Status), d.Attempts, d.NextAttemptAt, d.LastError)
+	if err != nil {
+		return fmt.Errorf("repository: update delivery: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *Repo) DeleteDelivery(ctx context.Context, id int64) error {
+	const q = `
+	DELETE FROM webhook_deliveries WHERE id = $1;
+`
+
+	tag, err := r.pool.Exec(ctx, q, id)
+	if err != nil {
+		return fmt.Errorf("repository: delete delivery: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *Repo) ListDeliveries(ctx context.Context, f repository.DeliveryFilter) ([]*model.Delivery, error) {
+	const q = `
+	SELECT id, webhook, event, status, attempts, next_attempt_at, last_error, created_at
+	FROM webhook_deliveries
+	WHERE id > $1 AND ($3::TEXT = '' OR status = $3)
+	ORDER BY id
+	LIMIT $2;
+`
+
+	rows, err := r.pool.Query(ctx, q, f.AfterID, f.Limit, string(f.Status))
+	if err != nil {
+		return nil, fmt.Errorf("repository: list deliveries: %w", err)
+	}
+
+	list, err := pgx.CollectRows(rows, scanDelivery)
+	if err != nil {
+		return nil, fmt.Errorf("repository: list deliveries: %w", err)
+	}
+
+	return list, nil
+}
@@ -2,9 +2,53 @@ package repository
 
 import (
 	"errors"
+	"fmt"
+
+	"github.com/Rasulikus/url-shortener/internal/apperr"
+	"github.com/Rasulikus/url-shortener/internal/model"
 )
 
 var (
 	ErrNotFound = errors.New("repository: not found")
+	ErrDisabled = errors.New("repository: disabled")
 	ErrConflict = errors.New("repository: conflict")
+
+	// ErrIDConflict — ID, выданный генератором алиасов, уже занят. Повтор с новым ID
+	// и алиасом может пройти.
+	ErrIDConflict = fmt.Errorf("%w: id is already taken", ErrConflict)
+
+	// ErrAliasConflict — алиас уже занят другой ссылкой.
+	ErrAliasConflict error = &apperr.Error{
+		Code:    apperr.CodeAliasConflict,
+		Message: "alias is already taken",
+		Field:   "alias",
+		Err:     ErrConflict,
+	}
+	// ErrLongURLConflict — длинный URL уже сохранён под другим алиасом.
+	ErrLongURLConflict error = &apperr.Error{
+		Code:    apperr.CodeLongURLConflict,
+		Message: "long_url is already saved under another alias",
+		Field:   "long_url",
+		Err:     ErrConflict,
+	}
 )
+
+// ListFilter задаёт страницу выборки ссылок, упорядоченной по ID.
+type ListFilter struct {
+	// AfterID — вернуть записи с ID больше указанного.
+	AfterID int64
+	// Limit — максимальное количество записей.
+	Limit int
+	// Broken — только ссылки, помеченные проверкой как неработающие.
+	Broken bool
+}
+
+// DeliveryFilter задаёт страницу выборки доставок вебхуков, упорядоченной по ID.
+type DeliveryFilter struct {
+	// AfterID — вернуть записи с ID больше указанного.
+	AfterID int64
+	// Limit — максимальное количество записей.
+	Limit int
+	// Status — только доставки в этом состоянии, пусто — все.
+	Status model.DeliveryStatus
+}
@@ -0,0 +1,712 @@
+// Package repositorytest содержит общий набор тестов поведения для реализаций
+// urlService.URLRepository. Каждое хранилище запускает его из своих тестов, чтобы
+// все реализации держались одной семантики.
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	urlService "github.com/Rasulikus/url-shortener/internal/service/url"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory возвращает пустое хранилище. Вызывается для каждого теста отдельно.
+type Factory func(t *testing.T) urlService.URLRepository
+
+// idLeaser — хранилища, которые резервируют ID в общей последовательности.
+type idLeaser interface {
+	LeaseIDs(ctx context.Context, n uint64) ([]uint64, error)
+}
+
+// concurrency — сколько горутин одновременно обращается к хранилищу в тестах гонок.
+const concurrency = 16
+
+// Run запускает все тесты набора для хранилища из newRepo.
+func Run(t *testing.T, newRepo Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, ctx context.Context, repo urlService.URLRepository)
+	}{
+		{"CreateOrGet/Created", testCreated},
+		{"CreateOrGet/DedupByLongURL", testDedup},
+		{"CreateOrGet/AliasConflict", testAliasConflict},
+		{"CreateOrGet/WithID", testWithID},
+		{"CreateOrGet/IDConflict", testIDConflict},
+		{"GetByAlias", testGetByAlias},
+		{"GetLongURLByAlias", testGetLongURLByAlias},
+		{"GetLastID/Monotonic", testLastIDMonotonic},
+		{"List", testList},
+		{"SetDisabled", testSetDisabled},
+		{"SetInterstitial", testSetInterstitial},
+		{"SetMetadata", testSetMetadata},
+		{"SetHealth", testSetHealth},
+		{"Delete", testDelete},
+		{"Clicks", testClicks},
+		{"Import", testImport},
+		{"Import/LeasedIDs", testImportLeasedIDs},
+		{"Domains/Namespaces", testDomainNamespaces},
+		{"Domains/Import", testDomainImport},
+		{"Outbox/Events", testOutboxEvents},
+		{"Outbox/Import", testOutboxImport},
+		{"Outbox/Deliveries", testDeliveries},
+		{"Concurrent/SameLongURL", testConcurrentSameLongURL},
+		{"Concurrent/SameAlias", testConcurrentSameAlias},
+		{"Concurrent/Distinct", testConcurrentDistinct},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			tc.fn(t, ctx, newRepo(t))
+		})
+	}
+}
+
+func create(t *testing.T, ctx context.Context, repo urlService.URLRepository, longURL, alias string) *model.URL {
+	t.Helper()
+
+	u, _, err := repo.CreateOrGet(ctx, &model.URL{LongURL: longURL, Alias: alias})
+	require.NoError(t, err)
+
+	return u
+}
+
+// nextIDs возвращает n ID, которые генератор алиасов мог бы выдать этому хранилищу.
+func nextIDs(t *testing.T, ctx context.Context, repo urlService.URLRepository, n int) []int64 {
+	t.Helper()
+
+	ids := make([]int64, n)
+	if l, ok := repo.(idLeaser); ok {
+		leased, err := l.LeaseIDs(ctx, uint64(n))
+		require.NoError(t, err)
+		require.Len(t, leased, n)
+		for i, id := range leased {
+			ids[i] = int64(id)
+		}
+		return ids
+	}
+
+	last, err := repo.GetLastID(ctx)
+	require.NoError(t, err)
+	for i := range ids {
+		ids[i] = int64(last) + int64(i) + 1
+	}
+	return ids
+}
+
+func testCreated(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	u, created, err := repo.CreateOrGet(ctx, &model.URL{LongURL: "https://rkrkrkrk.com", Alias: "aa"})
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	assert.NotZero(t, u.ID)
+	assert.Equal(t, "https://rkrkrkrk.com", u.LongURL)
+	assert.Equal(t, "aa", u.Alias)
+	assert.False(t, u.Disabled)
+	assert.WithinDuration(t, time.Now(), u.CreatedAt, 5*time.Second)
+
+	got, err := repo.GetByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, got.ID)
+	assert.True(t, u.CreatedAt.Equal(got.CreatedAt))
+}
+
+func testDedup(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	u := create(t, ctx, repo, "https://rkrkrkrk.com", "aa")
+
+	// Тот же long URL с другим алиасом возвращает существующую запись
+	again, created, err := repo.CreateOrGet(ctx, &model.URL{LongURL: "https://rkrkrkrk.com", Alias: "bb"})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, u.ID, again.ID)
+	assert.Equal(t, "aa", again.Alias)
+	assert.True(t, u.CreatedAt.Equal(again.CreatedAt))
+
+	_, err = repo.GetByAlias(ctx, "", "bb")
+	require.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func testAliasConflict(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	create(t, ctx, repo, "https://rkrkrkrk.com", "aa")
+
+	got, _, err := repo.CreateOrGet(ctx, &model.URL{LongURL: "https://other.com", Alias: "aa"})
+	require.Nil(t, got)
+	require.ErrorIs(t, err, repository.ErrConflict)
+	require.ErrorIs(t, err, repository.ErrAliasConflict)
+
+	// Конфликтная запись не сохранилась
+	longURL, err := repo.GetLongURLByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+	assert.Equal(t, "https://rkrkrkrk.com", longURL)
+}
+
+func testWithID(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	ids := nextIDs(t, ctx, repo, 1)
+
+	u, _, err := repo.CreateOrGet(ctx, &model.URL{ID: ids[0], LongURL: "https://rkrkrkrk.com", Alias: "aa"})
+	require.NoError(t, err)
+	require.Equal(t, ids[0], u.ID)
+
+	// При повторе long URL возвращается исходная запись, новый ID игнорируется
+	again, _, err := repo.CreateOrGet(ctx, &model.URL{ID: ids[0] + 1000, LongURL: "https://rkrkrkrk.com", Alias: "bb"})
+	require.NoError(t, err)
+	require.Equal(t, u.ID, again.ID)
+	require.Equal(t, "aa", again.Alias)
+
+	// Запись без ID получает ID после уже выданных
+	next := create(t, ctx, repo, "https://other.com", "cc")
+	require.Greater(t, next.ID, u.ID)
+}
+
+func testIDConflict(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	ids := nextIDs(t, ctx, repo, 1)
+
+	_, _, err := repo.CreateOrGet(ctx, &model.URL{ID: ids[0], LongURL: "https://rkrkrkrk.com", Alias: "aa"})
+	require.NoError(t, err)
+
+	// Занятый ID с новыми long URL и алиасом — конфликт, который сервис повторяет с новым ID
+	got, _, err := repo.CreateOrGet(ctx, &model.URL{ID: ids[0], LongURL: "https://other.com", Alias: "bb"})
+	require.Nil(t, got)
+	require.ErrorIs(t, err, repository.ErrConflict)
+	require.ErrorIs(t, err, repository.ErrIDConflict)
+
+	_, err = repo.GetByAlias(ctx, "", "bb")
+	require.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func testGetByAlias(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	u := create(t, ctx, repo, "https://rkrkrkrk.com", "aa")
+
+	cases := []struct {
+		name  string
+		alias string
+		check func(t *testing.T, get *model.URL, err error)
+	}{
+		{
+			name:  "found",
+			alias: "aa",
+			check: func(t *testing.T, get *model.URL, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, u.ID, get.ID)
+				assert.Equal(t, u.LongURL, get.LongURL)
+				assert.Equal(t, u.Alias, get.Alias)
+				assert.True(t, u.CreatedAt.Equal(get.CreatedAt))
+			},
+		},
+		{
+			name:  "not found",
+			alias: "bb",
+			check: func(t *testing.T, get *model.URL, err error) {
+				require.Nil(t, get)
+				require.ErrorIs(t, err, repository.ErrNotFound)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			get, err := repo.GetByAlias(ctx, "", tc.alias)
+			tc.check(t, get, err)
+		})
+	}
+}
+
+func testGetLongURLByAlias(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	create(t, ctx, repo, "https://rkrkrkrk.com", "aa")
+	create(t, ctx, repo, "https://disabled.com", "dd")
+	require.NoError(t, repo.SetDisabled(ctx, "", "dd", true))
+
+	cases := []struct {
+		name    string
+		alias   string
+		want    string
+		wantErr error
+	}{
+		{name: "found", alias: "aa", want: "https://rkrkrkrk.com"},
+		{name: "not found", alias: "bb", wantErr: repository.ErrNotFound},
+		{name: "disabled", alias: "dd", wantErr: repository.ErrDisabled},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := repo.GetLongURLByAlias(ctx, "", tc.alias)
+			if tc.wantErr != nil {
+				require.Zero(t, got)
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func testLastIDMonotonic(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	prev, err := repo.GetLastID(ctx)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		u := create(t, ctx, repo, fmt.Sprintf("https://%d.com", i), fmt.Sprintf("a%d", i))
+
+		last, err := repo.GetLastID(ctx)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, last, uint64(u.ID))
+		require.Greater(t, last, prev)
+		prev = last
+	}
+
+	// Удаление не откатывает счётчик, иначе алиасы из ID повторились бы
+	require.NoError(t, repo.Delete(ctx, "", "a4"))
+
+	last, err := repo.GetLastID(ctx)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, last, prev)
+
+	u := create(t, ctx, repo, "https://after-delete.com", "b0")
+	require.Greater(t, uint64(u.ID), prev)
+}
+
+func testList(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	page, err := repo.List(ctx, repository.ListFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, page)
+
+	for _, a := range []string{"aa", "bb", "cc"} {
+		create(t, ctx, repo, "https://"+a+".com", a)
+	}
+	require.NoError(t, repo.SetDisabled(ctx, "", "bb", true))
+
+	page, err = repo.List(ctx, repository.ListFilter{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, "aa", page[0].Alias)
+	assert.Equal(t, "bb", page[1].Alias)
+	assert.True(t, page[1].Disabled, "list includes disabled links")
+	assert.Less(t, page[0].ID, page[1].ID)
+
+	page, err = repo.List(ctx, repository.ListFilter{AfterID: page[1].ID, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, "cc", page[0].Alias)
+
+	page, err = repo.List(ctx, repository.ListFilter{AfterID: page[0].ID, Limit: 2})
+	require.NoError(t, err)
+	require.Empty(t, page)
+}
+
+func testSetDisabled(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	create(t, ctx, repo, "https://rkrkrkrk.com", "aa")
+
+	require.NoError(t, repo.SetDisabled(ctx, "", "aa", true))
+
+	_, err := repo.GetLongURLByAlias(ctx, "", "aa")
+	require.ErrorIs(t, err, repository.ErrDisabled)
+
+	u, err := repo.GetByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+	assert.True(t, u.Disabled)
+
+	// CreateOrGet для отключённой ссылки возвращает её, не включая обратно
+	again := create(t, ctx, repo, "https://rkrkrkrk.com", "bb")
+	assert.Equal(t, "aa", again.Alias)
+	assert.True(t, again.Disabled)
+
+	require.NoError(t, repo.SetDisabled(ctx, "", "aa", false))
+
+	got, err := repo.GetLongURLByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+	assert.Equal(t, "https://rkrkrkrk.com", got)
+
+	require.ErrorIs(t, repo.SetDisabled(ctx, "", "bb", true), repository.ErrNotFound)
+}
+
+func testSetInterstitial(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	create(t, ctx, repo, "https://aa.com", "aa")
+
+	require.NoError(t, repo.SetInterstitial(ctx, "", "aa", true))
+
+	u, err := repo.GetByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+	assert.True(t, u.Interstitial)
+
+	// Флаг не мешает обычному переходу и возвращается при дедупликации
+	longURL, err := repo.GetLongURLByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+	assert.Equal(t, "https://aa.com", longURL)
+
+	again, _, err := repo.CreateOrGet(ctx, &model.URL{LongURL: "https://aa.com", Alias: "zz"})
+	require.NoError(t, err)
+	assert.True(t, again.Interstitial)
+
+	require.NoError(t, repo.SetInterstitial(ctx, "", "aa", false))
+	u, err = repo.GetByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+	assert.False(t, u.Interstitial)
+
+	require.ErrorIs(t, repo.SetInterstitial(ctx, "", "bb", true), repository.ErrNotFound)
+}
+
+func testSetMetadata(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	create(t, ctx, repo, "https://aa.com", "aa")
+
+	u, err := repo.GetByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+	assert.Nil(t, u.Meta)
+
+	meta := &model.Metadata{
+		Title:       "Заголовок",
+		Description: "Описание",
+		ImageURL:    "https://aa.com/cover.png",
+		FaviconURL:  "https://aa.com/favicon.ico",
+		FetchedAt:   time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, repo.SetMetadata(ctx, "", "aa", meta))
+
+	u, err = repo.GetByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+	require.NotNil(t, u.Meta)
+	assert.Equal(t, meta.Title, u.Meta.Title)
+	assert.Equal(t, meta.Description, u.Meta.Description)
+	assert.Equal(t, meta.ImageURL, u.Meta.ImageURL)
+	assert.Equal(t, meta.FaviconURL, u.Meta.FaviconURL)
+	assert.True(t, meta.FetchedAt.Equal(u.Meta.FetchedAt))
+
+	// Метаданные возвращаются при дедупликации и в списке
+	again, _, err := repo.CreateOrGet(ctx, &model.URL{LongURL: "https://aa.com", Alias: "zz"})
+	require.NoError(t, err)
+	require.NotNil(t, again.Meta)
+	assert.Equal(t, meta.Title, again.Meta.Title)
+
+	list, err := repo.List(ctx, repository.ListFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.NotNil(t, list[0].Meta)
+	assert.Equal(t, meta.Title, list[0].Meta.Title)
+
+	// Ошибка загрузки сохраняется так же
+	require.NoError(t, repo.SetMetadata(ctx, "", "aa", &model.Metadata{Error: "timeout", FetchedAt: meta.FetchedAt}))
+	u, err = repo.GetByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+	assert.Equal(t, "timeout", u.Meta.Error)
+	assert.Empty(t, u.Meta.Title)
+
+	require.ErrorIs(t, repo.SetMetadata(ctx, "", "bb", meta), repository.ErrNotFound)
+}
+
+func testSetHealth(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	create(t, ctx, repo, "https://aa.com", "aa")
+	create(t, ctx, repo, "https://bb.com", "bb")
+	create(t, ctx, repo, "https://cc.com", "cc")
+
+	u, err := repo.GetByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+	assert.Nil(t, u.Health)
+
+	broken := &model.Health{
+		Status:    404,
+		Latency:   150 * time.Millisecond,
+		Redirects: []string{"https://aa.com/", "https://aa.com/404"},
+		Failures:  3,
+		Broken:    true,
+		CheckedAt: time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, repo.SetHealth(ctx, "", "aa", broken))
+	require.NoError(t, repo.SetHealth(ctx, "", "bb", &model.Health{Status: 200, CheckedAt: broken.CheckedAt}))
+	require.NoError(t, repo.SetHealth(ctx, "", "cc", broken))
+
+	u, err = repo.GetByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+	require.NotNil(t, u.Health)
+	assert.Equal(t, broken.Status, u.Health.Status)
+	assert.Equal(t, broken.Latency, u.Health.Latency)
+	assert.Equal(t, broken.Redirects, u.Health.Redirects)
+	assert.Equal(t, broken.Failures, u.Health.Failures)
+	assert.True(t, u.Health.Broken)
+	assert.True(t, broken.CheckedAt.Equal(u.Health.CheckedAt))
+
+	// Фильтр списка по неработающим ссылкам
+	list, err := repo.List(ctx, repository.ListFilter{Limit: 10, Broken: true})
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "aa", list[0].Alias)
+	assert.Equal(t, "cc", list[1].Alias)
+
+	list, err = repo.List(ctx, repository.ListFilter{AfterID: list[0].ID, Limit: 10, Broken: true})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "cc", list[0].Alias)
+
+	// Ссылка, снова ставшая доступной, пропадает из фильтра
+	require.NoError(t, repo.SetHealth(ctx, "", "aa", &model.Health{Status: 200, CheckedAt: broken.CheckedAt}))
+	list, err = repo.List(ctx, repository.ListFilter{Limit: 10, Broken: true})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "cc", list[0].Alias)
+
+	list, err = repo.List(ctx, repository.ListFilter{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, list, 3)
+
+	require.ErrorIs(t, repo.SetHealth(ctx, "", "dd", broken), repository.ErrNotFound)
+}
+
+func testDelete(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	create(t, ctx, repo, "https://rkrkrkrk.com", "aa")
+
+	require.NoError(t, repo.Delete(ctx, "", "aa"))
+
+	_, err := repo.GetByAlias(ctx, "", "aa")
+	require.ErrorIs(t, err, repository.ErrNotFound)
+	_, err = repo.GetLongURLByAlias(ctx, "", "aa")
+	require.ErrorIs(t, err, repository.ErrNotFound)
+
+	// После удаления освобождаются и алиас, и long URL
+	create(t, ctx, repo, "https://rkrkrkrk.com", "bb")
+	create(t, ctx, repo, "https://other.com", "aa")
+
+	require.ErrorIs(t, repo.Delete(ctx, "", "cc"), repository.ErrNotFound)
+}
+
+func testImport(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	create(t, ctx, repo, "https://aa.com", "aa")
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	n, err := repo.Import(ctx, []*model.URL{
+		{LongURL: "https://bb.com", Alias: "bb", CreatedAt: createdAt, Disabled: true},
+		{LongURL: "https://other.com", Alias: "aa"},
+		{LongURL: "https://aa.com", Alias: "cc"},
+		{LongURL: "https://dd.com", Alias: "dd", Interstitial: true},
+		{LongURL: "https://dd.com", Alias: "ee"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	bb, err := repo.GetByAlias(ctx, "", "bb")
+	require.NoError(t, err)
+	assert.True(t, bb.CreatedAt.Equal(createdAt))
+	assert.True(t, bb.Disabled)
+	assert.NotZero(t, bb.ID)
+
+	dd, err := repo.GetByAlias(ctx, "", "dd")
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), dd.CreatedAt, 5*time.Second)
+	assert.NotEqual(t, bb.ID, dd.ID)
+	assert.False(t, bb.Interstitial)
+	assert.True(t, dd.Interstitial)
+
+	for _, alias := range []string{"cc", "ee"} {
+		_, err = repo.GetByAlias(ctx, "", alias)
+		require.ErrorIs(t, err, repository.ErrNotFound)
+	}
+
+	aa, err := repo.GetLongURLByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+	assert.Equal(t, "https://aa.com", aa)
+}
+
+// testImportLeasedIDs проверяет, что импорт не занимает ID, уже выданные генератору
+// алиасов, и страницы List не повторяют записи.
+func testImportLeasedIDs(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	ids := nextIDs(t, ctx, repo, 2)
+
+	n, err := repo.Import(ctx, []*model.URL{
+		{LongURL: "https://aa.com", Alias: "aa"},
+		{LongURL: "https://bb.com", Alias: "bb"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	for i, id := range ids {
+		u, _, err := repo.CreateOrGet(ctx, &model.URL{ID: id, LongURL: fmt.Sprintf("https://%d.com", i), Alias: fmt.Sprintf("x%d", i)})
+		require.NoError(t, err)
+		require.Equal(t, id, u.ID)
+	}
+
+	seen := make(map[int64]bool)
+	var afterID int64
+	for {
+		page, err := repo.List(ctx, repository.ListFilter{AfterID: afterID, Limit: 1})
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		require.False(t, seen[page[0].ID], "id %d listed twice", page[0].ID)
+		seen[page[0].ID] = true
+		afterID = page[0].ID
+	}
+	assert.Len(t, seen, 4)
+}
+
+func testDomainNamespaces(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	def := create(t, ctx, repo, "https://rkrkrkrk.com", "aa")
+
+	// Тот же алиас на другом домене ведёт на другую ссылку
+	brand, _, err := repo.CreateOrGet(ctx, &model.URL{Domain: "brand.link", LongURL: "https://brand.com", Alias: "aa"})
+	require.NoError(t, err)
+	assert.Equal(t, "brand.link", brand.Domain)
+	assert.NotEqual(t, def.ID, brand.ID)
+
+	// Long URL дедуплицируется только в пределах домена
+	again, _, err := repo.CreateOrGet(ctx, &model.URL{Domain: "go.link", LongURL: "https://rkrkrkrk.com", Alias: "bb"})
+	require.NoError(t, err)
+	assert.NotEqual(t, def.ID, again.ID)
+	assert.Equal(t, "bb", again.Alias)
+
+	longURL, err := repo.GetLongURLByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+	assert.Equal(t, "https://rkrkrkrk.com", longURL)
+	longURL, err = repo.GetLongURLByAlias(ctx, "brand.link", "aa")
+	require.NoError(t, err)
+	assert.Equal(t, "https://brand.com", longURL)
+	_, err = repo.GetLongURLByAlias(ctx, "brand.link", "bb")
+	require.ErrorIs(t, err, repository.ErrNotFound)
+
+	got, err := repo.GetByAlias(ctx, "brand.link", "aa")
+	require.NoError(t, err)
+	assert.Equal(t, brand.ID, got.ID)
+	assert.Equal(t, "brand.link", got.Domain)
+
+	// Отключение и удаление затрагивают только свой домен
+	require.NoError(t, repo.SetDisabled(ctx, "brand.link", "aa", true))
+	_, err = repo.GetLongURLByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Delete(ctx, "", "aa"))
+	_, err = repo.GetByAlias(ctx, "brand.link", "aa")
+	require.NoError(t, err)
+	require.ErrorIs(t, repo.Delete(ctx, "", "aa"), repository.ErrNotFound)
+
+	page, err := repo.List(ctx, repository.ListFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, "brand.link", page[0].Domain)
+	assert.Equal(t, "go.link", page[1].Domain)
+}
+
+func testDomainImport(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	create(t, ctx, repo, "https://aa.com", "aa")
+
+	n, err := repo.Import(ctx, []*model.URL{
+		{Domain: "brand.link", LongURL: "https://brand.com", Alias: "aa"},
+		{Domain: "brand.link", LongURL: "https://aa.com", Alias: "bb"},
+		{Domain: "brand.link", LongURL: "https://other.com", Alias: "aa"},
+		{LongURL: "https://other.com", Alias: "aa"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	got, err := repo.GetByAlias(ctx, "brand.link", "aa")
+	require.NoError(t, err)
+	assert.Equal(t, "https://brand.com", got.LongURL)
+	assert.Equal(t, "brand.link", got.Domain)
+
+	longURL, err := repo.GetLongURLByAlias(ctx, "brand.link", "bb")
+	require.NoError(t, err)
+	assert.Equal(t, "https://aa.com", longURL)
+
+	longURL, err = repo.GetLongURLByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+	assert.Equal(t, "https://aa.com", longURL)
+}
+
+// parallel запускает fn в concurrency горутинах одновременно.
+func parallel(fn func(i int)) {
+	var (
+		wg    sync.WaitGroup
+		start = make(chan struct{})
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			fn(i)
+		}()
+	}
+	close(start)
+	wg.Wait()
+}
+
+func testConcurrentSameLongURL(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	var (
+		results = make([]*model.URL, concurrency)
+		errs    = make([]error, concurrency)
+	)
+	parallel(func(i int) {
+		results[i], _, errs[i] = repo.CreateOrGet(ctx, &model.URL{
+			LongURL: "https://rkrkrkrk.com",
+			Alias:   fmt.Sprintf("a%d", i),
+		})
+	})
+
+	// Все вызовы получают одну и ту же запись
+	for i := range results {
+		require.NoError(t, errs[i])
+		assert.Equal(t, results[0].ID, results[i].ID)
+		assert.Equal(t, results[0].Alias, results[i].Alias)
+	}
+
+	page, err := repo.List(ctx, repository.ListFilter{Limit: concurrency})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+}
+
+func testConcurrentSameAlias(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	errs := make([]error, concurrency)
+	parallel(func(i int) {
+		_, _, errs[i] = repo.CreateOrGet(ctx, &model.URL{
+			LongURL: fmt.Sprintf("https://%d.com", i),
+			Alias:   "aa",
+		})
+	})
+
+	// Алиас достаётся ровно одной ссылке, остальные получают конфликт алиаса
+	created := 0
+	for _, err := range errs {
+		if err == nil {
+			created++
+			continue
+		}
+		require.ErrorIs(t, err, repository.ErrAliasConflict)
+	}
+	require.Equal(t, 1, created)
+
+	page, err := repo.List(ctx, repository.ListFilter{Limit: concurrency})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+}
+
+func testConcurrentDistinct(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	var (
+		results = make([]*model.URL, concurrency)
+		errs    = make([]error, concurrency)
+	)
+	parallel(func(i int) {
+		results[i], _, errs[i] = repo.CreateOrGet(ctx, &model.URL{
+			LongURL: fmt.Sprintf("https://%d.com", i),
+			Alias:   fmt.Sprintf("a%d", i),
+		})
+	})
+
+	ids := make(map[int64]struct{}, concurrency)
+	for i := range results {
+		require.NoError(t, errs[i])
+		ids[results[i].ID] = struct{}{}
+	}
+	require.Len(t, ids, concurrency, "ids must be unique")
+
+	last, err := repo.GetLastID(ctx)
+	require.NoError(t, err)
+	for id := range ids {
+		require.LessOrEqual(t, uint64(id), last)
+	}
+}
@@ -0,0 +1,197 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	urlService "github.com/Rasulikus/url-shortener/internal/service/url"
+	"github.com/Rasulikus/url-shortener/internal/service/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookRepo возвращает хранилище как outbox вебхуков или пропускает тест.
+func webhookRepo(t *testing.T, repo urlService.URLRepository) webhook.Repository {
+	t.Helper()
+
+	wr, ok := repo.(webhook.Repository)
+	if !ok {
+		t.Skip("repository does not implement webhook.Repository")
+	}
+	return wr
+}
+
+func events(t *testing.T, ctx context.Context, repo webhook.Repository) []*model.Event {
+	t.Helper()
+
+	list, err := repo.Events(ctx, 100)
+	require.NoError(t, err)
+	return list
+}
+
+func testClicks(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	create(t, ctx, repo, "https://aa.com", "aa")
+
+	for want := int64(1); want <= 3; want++ {
+		clicks, err := repo.AddClick(ctx, "", "aa", nil)
+		require.NoError(t, err)
+		assert.Equal(t, want, clicks)
+	}
+
+	u, err := repo.GetByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), u.Clicks)
+
+	_, err = repo.AddClick(ctx, "", "bb", nil)
+	require.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func testOutboxEvents(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	wr := webhookRepo(t, repo)
+
+	create(t, ctx, repo, "https://aa.com", "aa")
+	// Существующая ссылка не порождает событие
+	create(t, ctx, repo, "https://aa.com", "bb")
+	require.NoError(t, repo.SetDisabled(ctx, "", "aa", true))
+	require.NoError(t, repo.SetInterstitial(ctx, "", "aa", true))
+	for range 3 {
+		_, err := repo.AddClick(ctx, "", "aa", []int64{2, 10})
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.Delete(ctx, "", "aa"))
+
+	// Неудачные изменения тоже не пишут событий
+	require.ErrorIs(t, repo.SetDisabled(ctx, "", "zz", true), repository.ErrNotFound)
+	require.ErrorIs(t, repo.Delete(ctx, "", "zz"), repository.ErrNotFound)
+
+	list := events(t, ctx, wr)
+	require.Len(t, list, 5)
+
+	wantTypes := []model.EventType{
+		model.EventCreated, model.EventUpdated, model.EventUpdated, model.EventClicks, model.EventDeleted,
+	}
+	for i, ev := range list {
+		assert.Equal(t, wantTypes[i], ev.Type)
+		assert.Equal(t, "aa", ev.URL.Alias)
+		assert.Equal(t, "https://aa.com", ev.URL.LongURL)
+		assert.WithinDuration(t, time.Now(), ev.CreatedAt, 5*time.Second)
+		if i > 0 {
+			assert.Greater(t, ev.ID, list[i-1].ID)
+		}
+	}
+
+	assert.False(t, list[0].URL.Disabled)
+	assert.True(t, list[1].URL.Disabled)
+	assert.False(t, list[1].URL.Interstitial)
+	assert.True(t, list[2].URL.Interstitial)
+	assert.Equal(t, int64(2), list[3].Clicks)
+	assert.Zero(t, list[4].Clicks)
+
+	limited, err := wr.Events(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, limited, 2)
+	assert.Equal(t, list[0].ID, limited[0].ID)
+}
+
+func testOutboxImport(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	wr := webhookRepo(t, repo)
+
+	create(t, ctx, repo, "https://aa.com", "aa")
+	before := events(t, ctx, wr)
+	require.Len(t, before, 1)
+
+	n, err := repo.Import(ctx, []*model.URL{
+		{LongURL: "https://bb.com", Alias: "bb", Disabled: true},
+		// Пропущенные записи событий не создают
+		{LongURL: "https://other.com", Alias: "aa"},
+		{LongURL: "https://cc.com", Alias: "cc", Interstitial: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	list := events(t, ctx, wr)
+	require.Len(t, list, 3)
+	for i, ev := range list[1:] {
+		assert.Equal(t, model.EventCreated, ev.Type)
+		assert.Greater(t, ev.ID, list[i].ID)
+	}
+	assert.Equal(t, "bb", list[1].URL.Alias)
+	assert.Equal(t, "https://bb.com", list[1].URL.LongURL)
+	assert.True(t, list[1].URL.Disabled)
+	assert.Equal(t, "cc", list[2].URL.Alias)
+	assert.True(t, list[2].URL.Interstitial)
+}
+
+func testDeliveries(t *testing.T, ctx context.Context, repo urlService.URLRepository) {
+	wr := webhookRepo(t, repo)
+
+	create(t, ctx, repo, "https://aa.com", "aa")
+	list := events(t, ctx, wr)
+	require.Len(t, list, 1)
+	ev := list[0]
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	deliveries := []*model.Delivery{
+		{Webhook: "first", Event: ev, Status: model.DeliveryPending, NextAttemptAt: now, CreatedAt: now},
+		{Webhook: "second", Event: ev, Status: model.DeliveryPending, NextAttemptAt: now.Add(time.Hour), CreatedAt: now},
+	}
+	require.NoError(t, wr.Dispatch(ctx, ev.ID, deliveries))
+	assert.NotZero(t, deliveries[0].ID)
+	assert.Greater(t, deliveries[1].ID, deliveries[0].ID)
+
+	// Событие уходит из outbox вместе с созданием доставок
+	assert.Empty(t, events(t, ctx, wr))
+	require.ErrorIs(t, wr.Dispatch(ctx, ev.ID, nil), repository.ErrNotFound)
+
+	// Забирается только наступившая доставка, и до конца аренды повторно её не выдают
+	claimed, err := wr.ClaimDeliveries(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	d := claimed[0]
+	assert.Equal(t, deliveries[0].ID, d.ID)
+	assert.Equal(t, "first", d.Webhook)
+	assert.Equal(t, ev.ID, d.Event.ID)
+	assert.Equal(t, "aa", d.Event.URL.Alias)
+	assert.True(t, d.NextAttemptAt.Equal(now.Add(time.Minute)))
+
+	claimed, err = wr.ClaimDeliveries(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	d.Status = model.DeliveryDead
+	d.Attempts = 3
+	d.LastError = "unexpected status 500"
+	require.NoError(t, wr.UpdateDelivery(ctx, d))
+
+	claimed, err = wr.ClaimDeliveries(ctx, now.Add(2*time.Hour), time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, deliveries[1].ID, claimed[0].ID)
+
+	dead, err := wr.ListDeliveries(ctx, repository.DeliveryFilter{Limit: 10, Status: model.DeliveryDead})
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, d.ID, dead[0].ID)
+	assert.Equal(t, 3, dead[0].Attempts)
+	assert.Equal(t, "unexpected status 500", dead[0].LastError)
+
+	all, err := wr.ListDeliveries(ctx, repository.DeliveryFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+
+	page, err := wr.ListDeliveries(ctx, repository.DeliveryFilter{AfterID: all[0].ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, all[1].ID, page[0].ID)
+
+	require.NoError(t, wr.DeleteDelivery(ctx, d.ID))
+	require.ErrorIs(t, wr.DeleteDelivery(ctx, d.ID), repository.ErrNotFound)
+	require.ErrorIs(t, wr.UpdateDelivery(ctx, d), repository.ErrNotFound)
+
+	all, err = wr.ListDeliveries(ctx, repository.DeliveryFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+}
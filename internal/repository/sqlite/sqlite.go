@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/migrate"
+	"github.com/Rasulikus/url-shortener/migrations"
+	"github.com/rs/zerolog/log"
+	_ "modernc.org/sqlite"
+)
+
+// Config — настройки хранилища.
+type Config struct {
+	// Path — путь к файлу базы.
+	Path string
+	// BusyTimeout — сколько ждать, пока другой процесс держит блокировку записи.
+	BusyTimeout time.Duration
+}
+
+// Open открывает файл базы SQLite и применяет встроенные миграции.
+// Журнал в режиме WAL позволяет читать параллельно с записью, в том числе из другого процесса.
+func Open(cfg Config) (*sql.DB, error) {
+	if dir := filepath.Dir(cfg.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create sqlite dir: %w", err)
+		}
+	}
+
+	q := url.Values{}
+	q.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", cfg.BusyTimeout.Milliseconds()))
+	q.Add("_pragma", "journal_mode(WAL)")
+	q.Add("_pragma", "synchronous(NORMAL)")
+	q.Set("_txlock", "immediate")
+
+	db, err := sql.Open("sqlite", "file:"+cfg.Path+"?"+q.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if err := applyMigrations(ctx, db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to apply sqlite migrations: %w", err)
+	}
+
+	return db, nil
+}
+
+// applyMigrations применяет недостающие миграции из migrations.SQLiteFS.
+// Версия схемы хранится в PRAGMA user_version.
+func applyMigrations(ctx context.Context, db *sql.DB) error {
+	fsys, err := fs.Sub(migrations.SQLiteFS, "sqlite")
+	if err != nil {
+		return err
+	}
+	list, err := migrate.Parse(fsys)
+	if err != nil {
+		return err
+	}
+
+	var version int64
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+
+	for _, m := range list {
+		if m.Version <= version {
+			continue
+		}
+
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, m.Up); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+		}
+		// PRAGMA не принимает параметры, версия подставляется в текст запроса
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", m.Version)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+
+		log.Info().
+			Int64("version", m.Version).
+			Str("name", m.Name).
+			Msg("sqlite migration applied")
+	}
+
+	return nil
+}
@@ -0,0 +1,464 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// conflictError определяет вид конфликта по коду и тексту ошибки SQLite,
+// который содержит имена столбцов: "UNIQUE constraint failed: urls.domain, urls.alias".
+func conflictError(err *sqlite.Error) error {
+	switch {
+	case err.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return repository.ErrIDConflict
+	case strings.Contains(err.Error(), "urls.alias"):
+		return repository.ErrAliasConflict
+	case strings.Contains(err.Error(), "urls.long_url"):
+		return repository.ErrLongURLConflict
+	default:
+		return repository.ErrConflict
+	}
+}
+
+// asConflict возвращает ошибку конфликта, если err — нарушение уникальности.
+func asConflict(err error) (error, bool) {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			return conflictError(sqliteErr), true
+		}
+	}
+	return nil, false
+}
+
+// jsonColumn читает значение из столбца, где оно хранится в JSON (metadata, health). NULL — nil.
+type jsonColumn[T any] struct {
+	v **T
+}
+
+func (c jsonColumn[T]) Scan(src any) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		*c.v = nil
+		return nil
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	default:
+		return fmt.Errorf("unexpected json column type %T", src)
+	}
+
+	v := new(T)
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("decode json column: %w", err)
+	}
+	*c.v = v
+	return nil
+}
+
+// jsonValue кодирует значение для столбца с JSON, nil записывается как NULL.
+func jsonValue[T any](v *T) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+type Repo struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) (*Repo, error) {
+	if db == nil {
+		return nil, errors.New("repository: sqlite db is nil")
+	}
+
+	return &Repo{
+		db: db,
+	}, nil
+}
+
+// GetLastID возвращает последнее значение счётчика ID из sqlite_sequence.
+// Как и последовательность в Postgres, учитывает зарезервированные, но ещё не сохранённые ID.
+func (r *Repo) GetLastID(ctx context.Context) (uint64, error) {
+	const q = `
+	SELECT COALESCE((SELECT seq FROM sqlite_sequence WHERE name = 'urls'), 0);
+`
+	var id uint64
+	err := r.db.QueryRowContext(ctx, q).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("repository: GetLastID: %w", err)
+	}
+	return id, nil
+}
+
+// LeaseIDs резервирует n ID, сдвигая счётчик sqlite_sequence. Записи, ID которых
+// выбирает SQLite, получают значения после зарезервированных, поэтому ID не повторяются
+// даже при нескольких процессах с одним файлом базы.
+func (r *Repo) LeaseIDs(ctx context.Context, n uint64) ([]uint64, error) {
+	const (
+		qInit = `
+	INSERT INTO sqlite_sequence (name, seq)
+	SELECT 'urls', 0
+	WHERE NOT EXISTS (SELECT 1 FROM sqlite_sequence WHERE name = 'urls');
+`
+		qLease = `
+	UPDATE sqlite_sequence SET seq = seq + ? WHERE name = 'urls' RETURNING seq;
+`
+	)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("repository: LeaseIDs: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, qInit); err != nil {
+		return nil, fmt.Errorf("repository: LeaseIDs: %w", err)
+	}
+
+	var last uint64
+	if err := tx.QueryRowContext(ctx, qLease, int64(n)).Scan(&last); err != nil {
+		return nil, fmt.Errorf("repository: LeaseIDs: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("repository: LeaseIDs: %w", err)
+	}
+
+	ids := make([]uint64, n)
+	for i := range ids {
+		ids[i] = last - n + 1 + uint64(i)
+	}
+	return ids, nil
+}
+
+func (r *Repo) CreateOrGet(ctx context.Context, u *model.URL) (*model.URL, bool, error) {
+	// NULL в INTEGER PRIMARY KEY означает, что ID выберет SQLite
+	const (
+		qInsert = `
+	INSERT INTO urls (id, domain, long_url, alias, created_at)
+	VALUES (NULLIF(?, 0), ?, ?, ?, ?)
+	ON CONFLICT (domain, long_url) DO NOTHING
+	RETURNING id, domain, long_url, alias, created_at, disabled, interstitial, metadata, health, clicks;
+`
+		qExisting = `
+	SELECT id, domain, long_url, alias, created_at, disabled, interstitial, metadata, health, clicks
+	FROM urls WHERE domain = ? AND long_url = ?;
+`
+	)
+
+	var created bool
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, qInsert, u.ID, u.Domain, u.LongURL, u.Alias, time.Now().UTC()).
+			Scan(&u.ID, &u.Domain, &u.LongURL, &u.Alias, &u.CreatedAt, &u.Disabled, &u.Interstitial, jsonColumn[model.Metadata]{&u.Meta}, jsonColumn[model.Health]{&u.Health}, &u.Clicks)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Длинный URL уже сохранён: возвращаем существующую запись без события
+			return tx.QueryRowContext(ctx, qExisting, u.Domain, u.LongURL).
+				Scan(&u.ID, &u.Domain, &u.LongURL, &u.Alias, &u.CreatedAt, &u.Disabled, &u.Interstitial, jsonColumn[model.Metadata]{&u.Meta}, jsonColumn[model.Health]{&u.Health}, &u.Clicks)
+		}
+		if err != nil {
+			return err
+		}
+		created = true
+		return addEvent(ctx, tx, model.NewEvent(model.EventCreated, u))
+	})
+	if err != nil {
+		if conflict, ok := asConflict(err); ok {
+			return nil, false, conflict
+		}
+		return nil, false, fmt.Errorf("repository: insert u: %w", err)
+	}
+
+	return u, created, nil
+}
+
+func (r *Repo) GetByAlias(ctx context.Context, domain, alias string) (*model.URL, error) {
+	const q = `
+	SELECT id, domain, long_url, alias, created_at, disabled, interstitial, metadata, health, clicks FROM urls WHERE domain = ? AND alias = ?;
+`
+
+	u := new(model.URL)
+	err := r.db.QueryRowContext(ctx, q, domain, alias).Scan(&u.ID, &u.Domain, &u.LongURL, &u.Alias, &u.CreatedAt, &u.Disabled, &u.Interstitial, jsonColumn[model.Metadata]{&u.Meta}, jsonColumn[model.Health]{&u.Health}, &u.Clicks)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("repository: get url by alias: %w", err)
+	}
+
+	return u, nil
+}
+
+func (r *Repo) GetLongURLByAlias(ctx context.Context, domain, alias string) (string, error) {
+	const q = `
+	SELECT long_url, disabled FROM urls WHERE domain = ? AND alias = ?;
+`
+
+	var (
+		longURL  string
+		disabled bool
+	)
+	err := r.db.QueryRowContext(ctx, q, domain, alias).Scan(&longURL, &disabled)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", repository.ErrNotFound
+		}
+		return "", fmt.Errorf("repository: get long url by alias: %w", err)
+	}
+	if disabled {
+		return "", repository.ErrDisabled
+	}
+
+	return longURL, nil
+}
+
+func (r *Repo) List(ctx context.Context, f repository.ListFilter) ([]*model.URL, error) {
+	const q = `
+	SELECT id, domain, long_url, alias, created_at, disabled, interstitial, metadata, health, clicks
+	FROM urls
+	WHERE id > ? AND (? = 0 OR broken)
+	ORDER BY id
+	LIMIT ?;
+`
+
+	rows, err := r.db.QueryContext(ctx, q, f.AfterID, f.Broken, f.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository: list urls: %w", err)
+	}
+	defer rows.Close()
+
+	var urls []*model.URL
+	for rows.Next() {
+		u := new(model.URL)
+		if err := rows.Scan(&u.ID, &u.Domain, &u.LongURL, &u.Alias, &u.CreatedAt, &u.Disabled, &u.Interstitial, jsonColumn[model.Metadata]{&u.Meta}, jsonColumn[model.Health]{&u.Health}, &u.Clicks); err != nil {
+			return nil, fmt.Errorf("repository: list urls: %w", err)
+		}
+		urls = append(urls, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: list urls: %w", err)
+	}
+
+	return urls, nil
+}
+
+func (r *Repo) SetDisabled(ctx context.Context, domain, alias string, disabled bool) error {
+	const q = `
+	UPDATE urls SET disabled = ? WHERE domain = ? AND alias = ?
+	RETURNING domain, long_url, alias, disabled, interstitial, clicks;
+`
+
+	err := r.withEvent(ctx, model.EventUpdated, q, disabled, domain, alias)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("repository: set disabled: %w", err)
+	}
+
+	return err
+}
+
+func (r *Repo) SetInterstitial(ctx context.Context, domain, alias string, interstitial bool) error {
+	const q = `
+	UPDATE urls SET interstitial = ? WHERE domain = ? AND alias = ?
+	RETURNING domain, long_url, alias, disabled, interstitial, clicks;
+`
+
+	err := r.withEvent(ctx, model.EventUpdated, q, interstitial, domain, alias)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("repository: set interstitial: %w", err)
+	}
+
+	return err
+}
+
+func (r *Repo) SetMetadata(ctx context.Context, domain, alias string, meta *model.Metadata) error {
+	const q = `
+	UPDATE urls SET metadata = ? WHERE domain = ? AND alias = ?;
+`
+
+	v, err := jsonValue(meta)
+	if err != nil {
+		return fmt.Errorf("repository: set metadata: %w", err)
+	}
+
+	res, err := r.db.ExecContext(ctx, q, v, domain, alias)
+	if err != nil {
+		return fmt.Errorf("repository: set metadata: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("repository: set metadata: %w", err)
+	} else if n == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *Repo) SetHealth(ctx context.Context, domain, alias string, h *model.Health) error {
+	const q = `
+	UPDATE urls SET health = ?, broken = ? WHERE domain = ? AND alias = ?;
+`
+
+	v, err := jsonValue(h)
+	if err != nil {
+		return fmt.Errorf("repository: set health: %w", err)
+	}
+
+	res, err := r.db.ExecContext(ctx, q, v, h != nil && h.Broken, domain, alias)
+	if err != nil {
+		return fmt.Errorf("repository: set health: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("repository: set health: %w", err)
+	} else if n == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *Repo) AddClick(ctx context.Context, domain, alias string, thresholds []int64) (int64, error) {
+	const q = `
+	UPDATE urls SET clicks = clicks + 1 WHERE domain = ? AND alias = ?
+	RETURNING domain, long_url, alias, disabled, interstitial, clicks;
+`
+
+	var clicks int64
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		u, err := scanEventURL(tx.QueryRowContext(ctx, q, domain, alias))
+		if err != nil {
+			return err
+		}
+		clicks = u.Clicks
+		if !slices.Contains(thresholds, clicks) {
+			return nil
+		}
+		return addEvent(ctx, tx, model.NewEvent(model.EventClicks, u))
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return 0, err
+		}
+		return 0, fmt.Errorf("repository: add click: %w", err)
+	}
+
+	return clicks, nil
+}
+
+func (r *Repo) Delete(ctx context.Context, domain, alias string) error {
+	const q = `
+	DELETE FROM urls WHERE domain = ? AND alias = ?
+	RETURNING domain, long_url, alias, disabled, interstitial, clicks;
+`
+
+	err := r.withEvent(ctx, model.EventDeleted, q, domain, alias)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("repository: delete url: %w", err)
+	}
+
+	return err
+}
+
+// withTx выполняет fn в транзакции и фиксирует её, если fn не вернула ошибку.
+func (r *Repo) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// withEvent выполняет изменение q, которое возвращает поля ссылки, см. scanEventURL,
+// и той же транзакцией пишет в outbox событие типа t. Если ссылка не найдена, возвращает ErrNotFound.
+func (r *Repo) withEvent(ctx context.Context, t model.EventType, q string, args ...any) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		u, err := scanEventURL(tx.QueryRowContext(ctx, q, args...))
+		if err != nil {
+			return err
+		}
+		return addEvent(ctx, tx, model.NewEvent(t, u))
+	})
+}
+
+// scanEventURL читает поля ссылки для события: domain, long_url, alias, disabled, interstitial, clicks.
+func scanEventURL(row *sql.Row) (*model.URL, error) {
+	u := new(model.URL)
+	err := row.Scan(&u.Domain, &u.LongURL, &u.Alias, &u.Disabled, &u.Interstitial, &u.Clicks)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	return u, err
+}
+
+// Import вставляет ссылки одной транзакцией, пропуская записи, чей алиас или длинный URL уже заняты.
+func (r *Repo) Import(ctx context.Context, urls []*model.URL) (int, error) {
+	const q = `
+	INSERT INTO urls (domain, long_url, alias, created_at, disabled, interstitial)
+	VALUES (?, ?, ?, ?, ?, ?)
+	ON CONFLICT DO NOTHING;
+`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("repository: import urls: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, q)
+	if err != nil {
+		return 0, fmt.Errorf("repository: import urls: %w", err)
+	}
+	defer stmt.Close()
+
+	imported := 0
+	for _, u := range urls {
+		createdAt := u.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+
+		res, err := stmt.ExecContext(ctx, u.Domain, u.LongURL, u.Alias, createdAt.UTC(), u.Disabled, u.Interstitial)
+		if err != nil {
+			return 0, fmt.Errorf("repository: import urls: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("repository: import urls: %w", err)
+		}
+		if n == 0 {
+			continue
+		}
+		if err := addEvent(ctx, tx, model.NewEvent(model.EventCreated, u)); err != nil {
+			return 0, fmt.Errorf("repository: import urls: %w", err)
+		}
+		imported++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("repository: import urls: %w", err)
+	}
+
+	return imported, nil
+}
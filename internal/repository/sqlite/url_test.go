@@ -0,0 +1,186 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	"github.com/Rasulikus/url-shortener/internal/repository/repositorytest"
+	urlService "github.com/Rasulikus/url-shortener/internal/service/url"
+	"github.com/Rasulikus/url-shortener/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig(t *testing.T) Config {
+	t.Helper()
+
+	return Config{
+		Path:        filepath.Join(t.TempDir(), "data", "test.sqlite"),
+		BusyTimeout: time.Second,
+	}
+}
+
+func openDB(t *testing.T, cfg Config) *sql.DB {
+	t.Helper()
+
+	db, err := Open(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db
+}
+
+func newRepo(t *testing.T) *Repo {
+	t.Helper()
+
+	r, err := NewRepository(openDB(t, testConfig(t)))
+	require.NoError(t, err)
+
+	return r
+}
+
+func TestRepo(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) urlService.URLRepository {
+		return newRepo(t)
+	})
+}
+
+func TestRepo_CreateOrGetWithID(t *testing.T) {
+	ctx := context.Background()
+	repo := newRepo(t)
+
+	u, _, err := repo.CreateOrGet(ctx, &model.URL{
+		ID:      10,
+		LongURL: "https://rkrkrkrk.com",
+		Alias:   "aa",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(10), u.ID)
+
+	// Запись без ID получает ID после уже выданных
+	next, _, err := repo.CreateOrGet(ctx, &model.URL{
+		LongURL: "https://other.com",
+		Alias:   "bb",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(11), next.ID)
+
+	lastID, err := repo.GetLastID(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 11, lastID)
+
+	// Повторно выданный ID — конфликт, а не перезапись
+	_, _, err = repo.CreateOrGet(ctx, &model.URL{
+		ID:      10,
+		LongURL: "https://third.com",
+		Alias:   "cc",
+	})
+	require.ErrorIs(t, err, repository.ErrConflict)
+}
+
+func TestRepo_Reopen(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig(t)
+
+	db, err := Open(cfg)
+	require.NoError(t, err)
+	repo, err := NewRepository(db)
+	require.NoError(t, err)
+
+	u, _, err := repo.CreateOrGet(ctx, &model.URL{LongURL: "https://rkrkrkrk.com", Alias: "aa"})
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	repo, err = NewRepository(openDB(t, cfg))
+	require.NoError(t, err)
+
+	got, err := repo.GetByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, got.ID)
+
+	lastID, err := repo.GetLastID(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, u.ID, lastID)
+}
+
+func TestRepo_LeaseIDs(t *testing.T) {
+	ctx := context.Background()
+	repo := newRepo(t)
+
+	ids, err := repo.LeaseIDs(ctx, 3)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1, 2, 3}, ids)
+
+	// Следующая аренда продолжает счётчик
+	ids, err = repo.LeaseIDs(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{4, 5}, ids)
+
+	// ID, выбранный базой, идёт после арендованных
+	u, _, err := repo.CreateOrGet(ctx, &model.URL{LongURL: "https://rkrkrkrk.com", Alias: "aa"})
+	require.NoError(t, err)
+	require.EqualValues(t, 6, u.ID)
+
+	lastID, err := repo.GetLastID(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 6, lastID)
+}
+
+func TestOpen_MigrationsIdempotent(t *testing.T) {
+	cfg := testConfig(t)
+
+	db, err := Open(cfg)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db = openDB(t, cfg)
+
+	var version int
+	require.NoError(t, db.QueryRow("PRAGMA user_version").Scan(&version))
+	require.Equal(t, 6, version)
+}
+
+func TestOpen_MigrateDomain(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig(t)
+	require.NoError(t, os.MkdirAll(filepath.Dir(cfg.Path), 0o755))
+
+	// База в схеме до появления доменов: одна ссылка и зарезервированные ID
+	init, err := fs.ReadFile(migrations.SQLiteFS, "sqlite/0001_init.up.sql")
+	require.NoError(t, err)
+	old, err := sql.Open("sqlite", "file:"+cfg.Path)
+	require.NoError(t, err)
+	for _, q := range []string{
+		string(init),
+		`INSERT INTO urls (long_url, alias) VALUES ('https://rkrkrkrk.com', 'aa')`,
+		`UPDATE sqlite_sequence SET seq = 10 WHERE name = 'urls'`,
+		`PRAGMA user_version = 1`,
+	} {
+		_, err := old.Exec(q)
+		require.NoError(t, err)
+	}
+	require.NoError(t, old.Close())
+
+	repo, err := NewRepository(openDB(t, cfg))
+	require.NoError(t, err)
+
+	got, err := repo.GetByAlias(ctx, "", "aa")
+	require.NoError(t, err)
+	assert.Equal(t, "https://rkrkrkrk.com", got.LongURL)
+
+	lastID, err := repo.GetLastID(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, lastID, "leased ids must survive the table rebuild")
+
+	// Тот же алиас на другом домене больше не конфликтует
+	u, _, err := repo.CreateOrGet(ctx, &model.URL{Domain: "brand.link", LongURL: "https://other.com", Alias: "aa"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 11, u.ID)
+}
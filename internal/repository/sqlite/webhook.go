@@ -0,0 +1,208 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+)
+
+// addEvent пишет событие в outbox в транзакции изменения ссылки.
+func addEvent(ctx context.Context, tx *sql.Tx, ev *model.Event) error {
+	const q = `
+	INSERT INTO webhook_outbox (event) VALUES (?);
+`
+
+	v, err := jsonValue(ev)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, q, v)
+	}
+	if err != nil {
+		return fmt.Errorf("write outbox: %w", err)
+	}
+	return nil
+}
+
+// scanDeliveries читает доставки из столбцов id, webhook, event, status, attempts,
+// next_attempt_at, last_error, created_at.
+func scanDeliveries(rows *sql.Rows) ([]*model.Delivery, error) {
+	defer rows.Close()
+
+	var list []*model.Delivery
+	for rows.Next() {
+		var (
+			d    = new(model.Delivery)
+			next int64
+		)
+		err := rows.Scan(&d.ID, &d.Webhook, jsonColumn[model.Event]{&d.Event}, &d.Status, &d.Attempts, &next, &d.LastError, &d.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		d.NextAttemptAt = time.UnixMilli(next).UTC()
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+func (r *Repo) Events(ctx context.Context, limit int) ([]*model.Event, error) {
+	const q = `
+	SELECT id, event FROM webhook_outbox ORDER BY id LIMIT ?;
+`
+
+	rows, err := r.db.QueryContext(ctx, q, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository: list events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*model.Event
+	for rows.Next() {
+		var (
+			id int64
+			ev *model.Event
+		)
+		if err := rows.Scan(&id, jsonColumn[model.Event]{&ev}); err != nil {
+			return nil, fmt.Errorf("repository: list events: %w", err)
+		}
+		ev.ID = id
+		events = append(events, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: list events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *Repo) Dispatch(ctx context.Context, eventID int64, deliveries []*model.Delivery) error {
+	const (
+		qDelete = `
+	DELETE FROM webhook_outbox WHERE id = ?;
+`
+		qInsert = `
+	INSERT INTO webhook_deliveries (webhook, event, status, attempts, next_attempt_at, created_at)
+	VALUES (?, ?, ?, ?, ?, ?)
+	RETURNING id;
+`
+	)
+
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, qDelete, eventID)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return repository.ErrNotFound
+		}
+
+		for _, d := range deliveries {
+			ev, err := jsonValue(d.Event)
+			if err != nil {
+				return err
+			}
+			err = tx.QueryRowContext(ctx, qInsert, d.Webhook, ev, string(d.Status), d.Attempts, d.NextAttemptAt.UnixMilli(), d.CreatedAt.UTC()).
+				Scan(&d.ID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("repository: dispatch event: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repo) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.Delivery, error) {
+	const q = `
+	UPDATE webhook_deliveries SET next_attempt_at = ?
+	WHERE id IN (
+		SELECT id FROM webhook_deliveries
+		WHERE status = 'pending' AND next_attempt_at <= ?
+		ORDER BY next_attempt_at
+		LIMIT ?
+	)
+	RETURNING id, webhook, event, status, attempts, next_attempt_at, last_error, created_at;
+`
+
+	rows, err := r.db.QueryContext(ctx, q, now.Add(lease).UnixMilli(), now.UnixMilli(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository: claim deliveries: %w", err)
+	}
+
+	list, err := scanDeliveries(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repository: claim deliveries: %w", err)
+	}
+
+	return list, nil
+}
+
+func (r *Repo) UpdateDelivery(ctx context.Context, d *model.Delivery) error {
+	const q = `
+	UPDATE webhook_deliveries SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ? WHERE id = ?;
+`
+
+	res, err := r.db.ExecContext(ctx, q, string(d.Status), d.Attempts, d.NextAttemptAt.UnixMilli(), d.LastError, d.ID)
+	if err != nil {
+		return fmt.Errorf("repository: update delivery: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("repository: update delivery: %w", err)
+	} else if n == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *Repo) DeleteDelivery(ctx context.Context, id int64) error {
+	const q = `
+	DELETE FROM webhook_deliveries WHERE id = ?;
+`
+
+	res, err := r.db.ExecContext(ctx, q, id)
+	if err != nil {
+		return fmt.Errorf("repository: delete delivery: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("repository: delete delivery: %w", err)
+	} else if n == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *Repo) ListDeliveries(ctx context.Context, f repository.DeliveryFilter) ([]*model.Delivery, error) {
+	const q = `
+	SELECT id, webhook, event, status, attempts, next_attempt_at, last_error, created_at
+	FROM webhook_deliveries
+	WHERE id > ? AND (? = '' OR status = ?)
+	ORDER BY id
+	LIMIT ?;
+`
+
+	rows, err := r.db.QueryContext(ctx, q, f.AfterID, string(f.Status), string(f.Status), f.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository: list deliveries: %w", err)
+	}
+
+	list, err := scanDeliveries(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repository: list deliveries: %w", err)
+	}
+
+	return list, nil
+}
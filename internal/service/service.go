@@ -2,11 +2,29 @@ package service
 
 import (
 	"errors"
+	"fmt"
+
+	"github.com/Rasulikus/url-shortener/internal/apperr"
 )
 
 var (
 	ErrInvalidInput  = errors.New("service: invalid input")
 	ErrNotFound      = errors.New("service: not found")
+	ErrDisabled      = errors.New("service: disabled")
 	ErrConflict      = errors.New("service: conflict")
+	ErrUnknownDomain = errors.New("service: unknown domain")
+	// ErrUnavailable — запрос не может быть выполнен, пока оператор не изменит настройки.
+	ErrUnavailable   = errors.New("service: unavailable")
 	ErrInternalError = errors.New("service: internal error")
 )
+
+// InvalidField возвращает ErrInvalidInput для поля field с кодом и сообщением
+// ошибки проверки err, например из validate.URL.
+func InvalidField(field string, err error) error {
+	return fmt.Errorf("%w: %w", ErrInvalidInput, apperr.WithField(err, field))
+}
+
+// InvalidParam возвращает ErrInvalidInput для параметра name с сообщением msg.
+func InvalidParam(name, msg string) error {
+	return &apperr.Error{Code: apperr.CodeInvalidParameter, Message: msg, Field: name, Err: ErrInvalidInput}
+}
@@ -0,0 +1,218 @@
+package url
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+type LinkChecker interface {
+	// Check запрашивает страницу longURL и возвращает статус ответа, время запроса
+	// и цепочку редиректов. Ошибка — ответ не получен.
+	Check(ctx context.Context, longURL string) (*model.Health, error)
+}
+
+// HealthConfig задаёт периодическую проверку ссылок.
+type HealthConfig struct {
+	// Interval — как часто проверять каждую ссылку.
+	Interval time.Duration
+	// Workers — количество одновременных проверок.
+	Workers int
+	// FailureThreshold — после скольких неудачных проверок подряд ссылка считается неработающей.
+	FailureThreshold int
+}
+
+// healthPageSize — сколько ссылок читается из хранилища за раз при обходе.
+const healthPageSize = 100
+
+type healthChecker struct {
+	s   *Service
+	c   LinkChecker
+	cfg HealthConfig
+}
+
+// StartHealthCheck запускает фоновую проверку доступности страниц назначения.
+// Раз в Interval обходятся все включённые http(s)-ссылки; ссылки, проверенные
+// недавно, например до перезапуска, пропускаются. Возвращаемая функция
+// останавливает проверку и дожидается воркеров.
+func (s *Service) StartHealthCheck(c LinkChecker, cfg HealthConfig) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	h := &healthChecker{s: s, c: c, cfg: cfg}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		h.run(ctx)
+	}()
+
+	log.Info().
+		Dur("interval", cfg.Interval).
+		Int("workers", cfg.Workers).
+		Int("failure_threshold", cfg.FailureThreshold).
+		Msg("url health check started")
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+func (h *healthChecker) run(ctx context.Context) {
+	ticker := time.NewTicker(h.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		h.round(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// round проверяет все ссылки, которым пора на проверку.
+func (h *healthChecker) round(ctx context.Context) {
+	var (
+		jobs = make(chan *model.URL)
+		wg   sync.WaitGroup
+	)
+	for range h.cfg.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range jobs {
+				h.check(ctx, u)
+			}
+		}()
+	}
+	defer func() {
+		close(jobs)
+		wg.Wait()
+	}()
+
+	start := time.Now()
+	var afterID int64
+	for {
+		urls, err := h.s.urlRepo.List(ctx, repository.ListFilter{AfterID: afterID, Limit: healthPageSize})
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Error().
+					Err(err).
+					Int64("after_id", afterID).
+					Msg("failed to list urls for health check")
+			}
+			return
+		}
+		if len(urls) == 0 {
+			break
+		}
+		afterID = urls[len(urls)-1].ID
+
+		for _, u := range urls {
+			if !h.due(u, start) {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- u:
+			}
+		}
+	}
+
+	log.Debug().
+		Dur("duration", time.Since(start)).
+		Msg("url health check round finished")
+}
+
+// due сообщает, пора ли проверять ссылку. Ссылка, проверенная меньше половины
+// интервала назад, ждёт следующего обхода.
+func (h *healthChecker) due(u *model.URL, now time.Time) bool {
+	if u.Disabled {
+		return false
+	}
+	if !strings.HasPrefix(u.LongURL, "http://") && !strings.HasPrefix(u.LongURL, "https://") {
+		return false
+	}
+	return u.Health == nil || now.Sub(u.Health.CheckedAt) >= h.cfg.Interval/2
+}
+
+func (h *healthChecker) check(ctx context.Context, u *model.URL) {
+	res, err := h.c.Check(ctx, u.LongURL)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		res = &model.Health{Error: err.Error()}
+	}
+	res.CheckedAt = time.Now().UTC()
+
+	h.s.metrics.healthChecks.Add(1)
+	if failed(res) {
+		h.s.metrics.healthCheckFailures.Add(1)
+
+		res.Failures = 1
+		if u.Health != nil {
+			res.Failures = u.Health.Failures + 1
+		}
+	}
+	res.Broken = res.Failures >= h.cfg.FailureThreshold
+
+	switch wasBroken := u.Health != nil && u.Health.Broken; {
+	case res.Broken && !wasBroken:
+		log.Warn().
+			Str("domain", u.Domain).
+			Str("alias", u.Alias).
+			Str("long_url", u.LongURL).
+			Int("status", res.Status).
+			Str("error", res.Error).
+			Int("failures", res.Failures).
+			Msg("url marked as broken")
+	case !res.Broken && wasBroken:
+		log.Info().
+			Str("domain", u.Domain).
+			Str("alias", u.Alias).
+			Str("long_url", u.LongURL).
+			Msg("url is reachable again")
+	}
+
+	if err := h.s.urlRepo.SetHealth(ctx, u.Domain, u.Alias, res); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			log.Debug().
+				Str("domain", u.Domain).
+				Str("alias", u.Alias).
+				Msg("url deleted before health was saved")
+			return
+		}
+		log.Error().
+			Err(err).
+			Str("domain", u.Domain).
+			Str("alias", u.Alias).
+			Msg("failed to save url health")
+	}
+}
+
+// failed сообщает, что проверка говорит о мёртвой ссылке: ответа нет, страница
+// не найдена или сервер с ошибкой. Остальные 4xx, например 403 и 429 для
+// ботов, не означают, что страницы нет.
+func failed(h *model.Health) bool {
+	switch {
+	case h.Status == 0:
+		return true
+	case h.Status == http.StatusNotFound, h.Status == http.StatusGone:
+		return true
+	default:
+		return h.Status >= http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,149 @@
+package url
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	"github.com/Rasulikus/url-shortener/internal/service/url/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type checkerFunc func(ctx context.Context, longURL string) (*model.Health, error)
+
+func (f checkerFunc) Check(ctx context.Context, longURL string) (*model.Health, error) {
+	return f(ctx, longURL)
+}
+
+var testHealth = HealthConfig{
+	Interval:         time.Hour,
+	Workers:          2,
+	FailureThreshold: 3,
+}
+
+func TestHealthChecker_Check(t *testing.T) {
+	cases := []struct {
+		name     string
+		prev     *model.Health
+		checkRet *model.Health
+		checkErr error
+		want     model.Health
+	}{
+		{
+			name:     "first check ok",
+			checkRet: &model.Health{Status: http.StatusOK, Latency: time.Millisecond},
+			want:     model.Health{Status: http.StatusOK, Latency: time.Millisecond},
+		},
+		{
+			name:     "first failure",
+			checkRet: &model.Health{Status: http.StatusNotFound},
+			want:     model.Health{Status: http.StatusNotFound, Failures: 1},
+		},
+		{
+			name:     "threshold reached",
+			prev:     &model.Health{Status: http.StatusBadGateway, Failures: 2},
+			checkRet: &model.Health{Status: http.StatusGone},
+			want:     model.Health{Status: http.StatusGone, Failures: 3, Broken: true},
+		},
+		{
+			name:     "no response",
+			prev:     &model.Health{Failures: 5, Broken: true},
+			checkErr: errors.New("linkcheck: connection refused"),
+			want:     model.Health{Error: "linkcheck: connection refused", Failures: 6, Broken: true},
+		},
+		{
+			name:     "recovered",
+			prev:     &model.Health{Status: http.StatusNotFound, Failures: 4, Broken: true},
+			checkRet: &model.Health{Status: http.StatusOK, Redirects: []string{"https://example.com/"}},
+			want:     model.Health{Status: http.StatusOK, Redirects: []string{"https://example.com/"}},
+		},
+		{
+			name:     "forbidden for bots is not a failure",
+			prev:     &model.Health{Status: http.StatusNotFound, Failures: 1},
+			checkRet: &model.Health{Status: http.StatusForbidden},
+			want:     model.Health{Status: http.StatusForbidden},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mocks.MockURLRepository)
+			s := newService(t, repo)
+
+			var got *model.Health
+			repo.On("SetHealth", mock.Anything, "brand.link", "aa", mock.Anything).
+				Run(func(args mock.Arguments) { got = args.Get(3).(*model.Health) }).
+				Return(nil).
+				Once()
+
+			h := &healthChecker{
+				s: s,
+				c: checkerFunc(func(context.Context, string) (*model.Health, error) {
+					return tc.checkRet, tc.checkErr
+				}),
+				cfg: testHealth,
+			}
+			h.check(context.Background(), &model.URL{Domain: "brand.link", Alias: "aa", LongURL: "https://example.com", Health: tc.prev})
+
+			require.NotNil(t, got)
+			require.False(t, got.CheckedAt.IsZero())
+			got.CheckedAt = time.Time{}
+			require.Equal(t, tc.want, *got)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_StartHealthCheck(t *testing.T) {
+	repo := new(mocks.MockURLRepository)
+	s := newService(t, repo)
+
+	recent := &model.Health{Status: http.StatusOK, CheckedAt: time.Now()}
+	stale := &model.Health{Status: http.StatusOK, CheckedAt: time.Now().Add(-time.Hour)}
+
+	repo.On("List", mock.Anything, repository.ListFilter{AfterID: 0, Limit: healthPageSize}).
+		Return([]*model.URL{
+			{ID: 1, Alias: "aa", LongURL: "https://aa.com"},
+			{ID: 2, Alias: "bb", LongURL: "https://bb.com", Disabled: true},
+			{ID: 3, Alias: "cc", LongURL: "ssh://cc.com"},
+		}, nil).
+		Once()
+	repo.On("List", mock.Anything, repository.ListFilter{AfterID: 3, Limit: healthPageSize}).
+		Return([]*model.URL{
+			{ID: 4, Alias: "dd", LongURL: "https://dd.com", Health: recent},
+			{ID: 5, Alias: "ee", LongURL: "https://ee.com", Health: stale},
+		}, nil).
+		Once()
+	repo.On("List", mock.Anything, repository.ListFilter{AfterID: 5, Limit: healthPageSize}).
+		Return([]*model.URL{}, nil)
+
+	checked := make(chan string, 10)
+	repo.On("SetHealth", mock.Anything, "", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { checked <- args.String(2) }).
+		Return(nil)
+
+	stop := s.StartHealthCheck(checkerFunc(func(context.Context, string) (*model.Health, error) {
+		return &model.Health{Status: http.StatusOK}, nil
+	}), testHealth)
+
+	var got []string
+	for range 2 {
+		select {
+		case alias := <-checked:
+			got = append(got, alias)
+		case <-time.After(time.Second):
+			t.Fatal("links were not checked")
+		}
+	}
+	stop()
+
+	assert.ElementsMatch(t, []string{"aa", "ee"}, got)
+	assert.Empty(t, checked)
+	assert.Equal(t, int64(2), s.Metrics().HealthChecks)
+}
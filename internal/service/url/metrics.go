@@ -0,0 +1,51 @@
+package url
+
+import "sync/atomic"
+
+// Metrics — счётчики сервиса, публикуются через expvar.
+type Metrics struct {
+	// Created — количество успешно созданных или найденных ссылок.
+	Created int64 `json:"created"`
+	// AliasCollisions — количество коллизий алиасов при создании.
+	AliasCollisions int64 `json:"alias_collisions"`
+	// AliasRetries — количество повторных попыток с новым алиасом.
+	AliasRetries int64 `json:"alias_retries"`
+	// AliasRetriesExhausted — количество запросов, исчерпавших все попытки.
+	AliasRetriesExhausted int64 `json:"alias_retries_exhausted"`
+	// Unfurled — количество загруженных метаданных страниц.
+	Unfurled int64 `json:"unfurled"`
+	// UnfurlFailed — количество страниц, метаданные которых загрузить не удалось.
+	UnfurlFailed int64 `json:"unfurl_failed"`
+	// UnfurlDropped — количество ссылок, не попавших в переполненную очередь загрузки.
+	UnfurlDropped int64 `json:"unfurl_dropped"`
+	// HealthChecks — количество проверок доступности страниц назначения.
+	HealthChecks int64 `json:"health_checks"`
+	// HealthCheckFailures — количество неудачных проверок доступности.
+	HealthCheckFailures int64 `json:"health_check_failures"`
+}
+
+type metrics struct {
+	created               atomic.Int64
+	aliasCollisions       atomic.Int64
+	aliasRetries          atomic.Int64
+	aliasRetriesExhausted atomic.Int64
+	unfurled              atomic.Int64
+	unfurlFailed          atomic.Int64
+	unfurlDropped         atomic.Int64
+	healthChecks          atomic.Int64
+	healthCheckFailures   atomic.Int64
+}
+
+func (m *metrics) snapshot() Metrics {
+	return Metrics{
+		Created:               m.created.Load(),
+		AliasCollisions:       m.aliasCollisions.Load(),
+		AliasRetries:          m.aliasRetries.Load(),
+		AliasRetriesExhausted: m.aliasRetriesExhausted.Load(),
+		Unfurled:              m.unfurled.Load(),
+		UnfurlFailed:          m.unfurlFailed.Load(),
+		UnfurlDropped:         m.unfurlDropped.Load(),
+		HealthChecks:          m.healthChecks.Load(),
+		HealthCheckFailures:   m.healthCheckFailures.Load(),
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"context"
 
 	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -38,8 +39,86 @@ func (_m *MockURLRepository) EXPECT() *MockURLRepository_Expecter {
 	return &MockURLRepository_Expecter{mock: &_m.Mock}
 }
 
+// AddClick provides a mock function for the type MockURLRepository
+func (_mock *MockURLRepository) AddClick(ctx context.Context, domain string, alias string, thresholds []int64) (int64, error) {
+	ret := _mock.Called(ctx, domain, alias, thresholds)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddClick")
+	}
+
+	var r0 int64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []int64) (int64, error)); ok {
+		return returnFunc(ctx, domain, alias, thresholds)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []int64) int64); ok {
+		r0 = returnFunc(ctx, domain, alias, thresholds)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, []int64) error); ok {
+		r1 = returnFunc(ctx, domain, alias, thresholds)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockURLRepository_AddClick_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddClick'
+type MockURLRepository_AddClick_Call struct {
+	*mock.Call
+}
+
+// AddClick is a helper method to define mock.On call
+//   - ctx context.Context
+//   - domain string
+//   - alias string
+//   - thresholds []int64
+func (_e *MockURLRepository_Expecter) AddClick(ctx interface{}, domain interface{}, alias interface{}, thresholds interface{}) *MockURLRepository_AddClick_Call {
+	return &MockURLRepository_AddClick_Call{Call: _e.mock.On("AddClick", ctx, domain, alias, thresholds)}
+}
+
+func (_c *MockURLRepository_AddClick_Call) Run(run func(ctx context.Context, domain string, alias string, thresholds []int64)) *MockURLRepository_AddClick_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 []int64
+		if args[3] != nil {
+			arg3 = args[3].([]int64)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockURLRepository_AddClick_Call) Return(n int64, err error) *MockURLRepository_AddClick_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockURLRepository_AddClick_Call) RunAndReturn(run func(ctx context.Context, domain string, alias string, thresholds []int64) (int64, error)) *MockURLRepository_AddClick_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CreateOrGet provides a mock function for the type MockURLRepository
-func (_mock *MockURLRepository) CreateOrGet(ctx context.Context, u *model.URL) (*model.URL, error) {
+func (_mock *MockURLRepository) CreateOrGet(ctx context.Context, u *model.URL) (*model.URL, bool, error) {
 	ret := _mock.Called(ctx, u)
 
 	if len(ret) == 0 {
@@ -47,8 +126,9 @@ func (_mock *MockURLRepository) CreateOrGet(ctx context.Context, u *model.URL) (
 	}
 
 	var r0 *model.URL
-	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, *model.URL) (*model.URL, error)); ok {
+	var r1 bool
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *model.URL) (*model.URL, bool, error)); ok {
 		return returnFunc(ctx, u)
 	}
 	if returnFunc, ok := ret.Get(0).(func(context.Context, *model.URL) *model.URL); ok {
@@ -58,12 +138,17 @@ func (_mock *MockURLRepository) CreateOrGet(ctx context.Context, u *model.URL) (
 			r0 = ret.Get(0).(*model.URL)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, *model.URL) error); ok {
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *model.URL) bool); ok {
 		r1 = returnFunc(ctx, u)
 	} else {
-		r1 = ret.Error(1)
+		r1 = ret.Get(1).(bool)
 	}
-	return r0, r1
+	if returnFunc, ok := ret.Get(2).(func(context.Context, *model.URL) error); ok {
+		r2 = returnFunc(ctx, u)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
 }
 
 // MockURLRepository_CreateOrGet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateOrGet'
@@ -96,55 +181,121 @@ func (_c *MockURLRepository_CreateOrGet_Call) Run(run func(ctx context.Context,
 	return _c
 }
 
-func (_c *MockURLRepository_CreateOrGet_Call) Return(uRL *model.URL, err error) *MockURLRepository_CreateOrGet_Call {
-	_c.Call.Return(uRL, err)
+func (_c *MockURLRepository_CreateOrGet_Call) Return(uRL *model.URL, created bool, err error) *MockURLRepository_CreateOrGet_Call {
+	_c.Call.Return(uRL, created, err)
 	return _c
 }
 
-func (_c *MockURLRepository_CreateOrGet_Call) RunAndReturn(run func(ctx context.Context, u *model.URL) (*model.URL, error)) *MockURLRepository_CreateOrGet_Call {
+func (_c *MockURLRepository_CreateOrGet_Call) RunAndReturn(run func(ctx context.Context, u *model.URL) (*model.URL, bool, error)) *MockURLRepository_CreateOrGet_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetLongURLByAlias provides a mock function for the type MockURLRepository
-func (_mock *MockURLRepository) GetLongURLByAlias(ctx context.Context, alias string) (string, error) {
-	ret := _mock.Called(ctx, alias)
+// Delete provides a mock function for the type MockURLRepository
+func (_mock *MockURLRepository) Delete(ctx context.Context, domain string, alias string) error {
+	ret := _mock.Called(ctx, domain, alias)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetLongURLByAlias")
+		panic("no return value specified for Delete")
 	}
 
-	var r0 string
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = returnFunc(ctx, domain, alias)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockURLRepository_Delete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Delete'
+type MockURLRepository_Delete_Call struct {
+	*mock.Call
+}
+
+// Delete is a helper method to define mock.On call
+//   - ctx context.Context
+//   - domain string
+//   - alias string
+func (_e *MockURLRepository_Expecter) Delete(ctx interface{}, domain interface{}, alias interface{}) *MockURLRepository_Delete_Call {
+	return &MockURLRepository_Delete_Call{Call: _e.mock.On("Delete", ctx, domain, alias)}
+}
+
+func (_c *MockURLRepository_Delete_Call) Run(run func(ctx context.Context, domain string, alias string)) *MockURLRepository_Delete_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockURLRepository_Delete_Call) Return(err error) *MockURLRepository_Delete_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockURLRepository_Delete_Call) RunAndReturn(run func(ctx context.Context, domain string, alias string) error) *MockURLRepository_Delete_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetByAlias provides a mock function for the type MockURLRepository
+func (_mock *MockURLRepository) GetByAlias(ctx context.Context, domain string, alias string) (*model.URL, error) {
+	ret := _mock.Called(ctx, domain, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByAlias")
+	}
+
+	var r0 *model.URL
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
-		return returnFunc(ctx, alias)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*model.URL, error)); ok {
+		return returnFunc(ctx, domain, alias)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) string); ok {
-		r0 = returnFunc(ctx, alias)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *model.URL); ok {
+		r0 = returnFunc(ctx, domain, alias)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.URL)
+		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, alias)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, domain, alias)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockURLRepository_GetLongURLByAlias_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLongURLByAlias'
-type MockURLRepository_GetLongURLByAlias_Call struct {
+// MockURLRepository_GetByAlias_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetByAlias'
+type MockURLRepository_GetByAlias_Call struct {
 	*mock.Call
 }
 
-// GetLongURLByAlias is a helper method to define mock.On call
+// GetByAlias is a helper method to define mock.On call
 //   - ctx context.Context
+//   - domain string
 //   - alias string
-func (_e *MockURLRepository_Expecter) GetLongURLByAlias(ctx interface{}, alias interface{}) *MockURLRepository_GetLongURLByAlias_Call {
-	return &MockURLRepository_GetLongURLByAlias_Call{Call: _e.mock.On("GetLongURLByAlias", ctx, alias)}
+func (_e *MockURLRepository_Expecter) GetByAlias(ctx interface{}, domain interface{}, alias interface{}) *MockURLRepository_GetByAlias_Call {
+	return &MockURLRepository_GetByAlias_Call{Call: _e.mock.On("GetByAlias", ctx, domain, alias)}
 }
 
-func (_c *MockURLRepository_GetLongURLByAlias_Call) Run(run func(ctx context.Context, alias string)) *MockURLRepository_GetLongURLByAlias_Call {
+func (_c *MockURLRepository_GetByAlias_Call) Run(run func(ctx context.Context, domain string, alias string)) *MockURLRepository_GetByAlias_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -154,25 +305,30 @@ func (_c *MockURLRepository_GetLongURLByAlias_Call) Run(run func(ctx context.Con
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
 		run(
 			arg0,
 			arg1,
+			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *MockURLRepository_GetLongURLByAlias_Call) Return(s string, err error) *MockURLRepository_GetLongURLByAlias_Call {
-	_c.Call.Return(s, err)
+func (_c *MockURLRepository_GetByAlias_Call) Return(uRL *model.URL, err error) *MockURLRepository_GetByAlias_Call {
+	_c.Call.Return(uRL, err)
 	return _c
 }
 
-func (_c *MockURLRepository_GetLongURLByAlias_Call) RunAndReturn(run func(ctx context.Context, alias string) (string, error)) *MockURLRepository_GetLongURLByAlias_Call {
+func (_c *MockURLRepository_GetByAlias_Call) RunAndReturn(run func(ctx context.Context, domain string, alias string) (*model.URL, error)) *MockURLRepository_GetByAlias_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetNextID provides a mock function for the type MockURLRepository
+// GetLastID provides a mock function for the type MockURLRepository
 func (_mock *MockURLRepository) GetLastID(ctx context.Context) (uint64, error) {
 	ret := _mock.Called(ctx)
 
@@ -198,18 +354,18 @@ func (_mock *MockURLRepository) GetLastID(ctx context.Context) (uint64, error) {
 	return r0, r1
 }
 
-// MockURLRepository_GetNextID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLastID'
-type MockURLRepository_GetNextID_Call struct {
+// MockURLRepository_GetLastID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLastID'
+type MockURLRepository_GetLastID_Call struct {
 	*mock.Call
 }
 
-// GetNextID is a helper method to define mock.On call
+// GetLastID is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *MockURLRepository_Expecter) GetNextID(ctx interface{}) *MockURLRepository_GetNextID_Call {
-	return &MockURLRepository_GetNextID_Call{Call: _e.mock.On("GetLastID", ctx)}
+func (_e *MockURLRepository_Expecter) GetLastID(ctx interface{}) *MockURLRepository_GetLastID_Call {
+	return &MockURLRepository_GetLastID_Call{Call: _e.mock.On("GetLastID", ctx)}
 }
 
-func (_c *MockURLRepository_GetNextID_Call) Run(run func(ctx context.Context)) *MockURLRepository_GetNextID_Call {
+func (_c *MockURLRepository_GetLastID_Call) Run(run func(ctx context.Context)) *MockURLRepository_GetLastID_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -222,12 +378,494 @@ func (_c *MockURLRepository_GetNextID_Call) Run(run func(ctx context.Context)) *
 	return _c
 }
 
-func (_c *MockURLRepository_GetNextID_Call) Return(v uint64, err error) *MockURLRepository_GetNextID_Call {
+func (_c *MockURLRepository_GetLastID_Call) Return(v uint64, err error) *MockURLRepository_GetLastID_Call {
 	_c.Call.Return(v, err)
 	return _c
 }
 
-func (_c *MockURLRepository_GetNextID_Call) RunAndReturn(run func(ctx context.Context) (uint64, error)) *MockURLRepository_GetNextID_Call {
+func (_c *MockURLRepository_GetLastID_Call) RunAndReturn(run func(ctx context.Context) (uint64, error)) *MockURLRepository_GetLastID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetLongURLByAlias provides a mock function for the type MockURLRepository
+func (_mock *MockURLRepository) GetLongURLByAlias(ctx context.Context, domain string, alias string) (string, error) {
+	ret := _mock.Called(ctx, domain, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLongURLByAlias")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+		return returnFunc(ctx, domain, alias)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = returnFunc(ctx, domain, alias)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, domain, alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockURLRepository_GetLongURLByAlias_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLongURLByAlias'
+type MockURLRepository_GetLongURLByAlias_Call struct {
+	*mock.Call
+}
+
+// GetLongURLByAlias is a helper method to define mock.On call
+//   - ctx context.Context
+//   - domain string
+//   - alias string
+func (_e *MockURLRepository_Expecter) GetLongURLByAlias(ctx interface{}, domain interface{}, alias interface{}) *MockURLRepository_GetLongURLByAlias_Call {
+	return &MockURLRepository_GetLongURLByAlias_Call{Call: _e.mock.On("GetLongURLByAlias", ctx, domain, alias)}
+}
+
+func (_c *MockURLRepository_GetLongURLByAlias_Call) Run(run func(ctx context.Context, domain string, alias string)) *MockURLRepository_GetLongURLByAlias_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockURLRepository_GetLongURLByAlias_Call) Return(s string, err error) *MockURLRepository_GetLongURLByAlias_Call {
+	_c.Call.Return(s, err)
+	return _c
+}
+
+func (_c *MockURLRepository_GetLongURLByAlias_Call) RunAndReturn(run func(ctx context.Context, domain string, alias string) (string, error)) *MockURLRepository_GetLongURLByAlias_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Import provides a mock function for the type MockURLRepository
+func (_mock *MockURLRepository) Import(ctx context.Context, urls []*model.URL) (int, error) {
+	ret := _mock.Called(ctx, urls)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Import")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []*model.URL) (int, error)); ok {
+		return returnFunc(ctx, urls)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []*model.URL) int); ok {
+		r0 = returnFunc(ctx, urls)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []*model.URL) error); ok {
+		r1 = returnFunc(ctx, urls)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockURLRepository_Import_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Import'
+type MockURLRepository_Import_Call struct {
+	*mock.Call
+}
+
+// Import is a helper method to define mock.On call
+//   - ctx context.Context
+//   - urls []*model.URL
+func (_e *MockURLRepository_Expecter) Import(ctx interface{}, urls interface{}) *MockURLRepository_Import_Call {
+	return &MockURLRepository_Import_Call{Call: _e.mock.On("Import", ctx, urls)}
+}
+
+func (_c *MockURLRepository_Import_Call) Run(run func(ctx context.Context, urls []*model.URL)) *MockURLRepository_Import_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []*model.URL
+		if args[1] != nil {
+			arg1 = args[1].([]*model.URL)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockURLRepository_Import_Call) Return(n int, err error) *MockURLRepository_Import_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockURLRepository_Import_Call) RunAndReturn(run func(ctx context.Context, urls []*model.URL) (int, error)) *MockURLRepository_Import_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// List provides a mock function for the type MockURLRepository
+func (_mock *MockURLRepository) List(ctx context.Context, f repository.ListFilter) ([]*model.URL, error) {
+	ret := _mock.Called(ctx, f)
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 []*model.URL
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, repository.ListFilter) ([]*model.URL, error)); ok {
+		return returnFunc(ctx, f)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, repository.ListFilter) []*model.URL); ok {
+		r0 = returnFunc(ctx, f)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.URL)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, repository.ListFilter) error); ok {
+		r1 = returnFunc(ctx, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockURLRepository_List_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'List'
+type MockURLRepository_List_Call struct {
+	*mock.Call
+}
+
+// List is a helper method to define mock.On call
+//   - ctx context.Context
+//   - f repository.ListFilter
+func (_e *MockURLRepository_Expecter) List(ctx interface{}, f interface{}) *MockURLRepository_List_Call {
+	return &MockURLRepository_List_Call{Call: _e.mock.On("List", ctx, f)}
+}
+
+func (_c *MockURLRepository_List_Call) Run(run func(ctx context.Context, f repository.ListFilter)) *MockURLRepository_List_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 repository.ListFilter
+		if args[1] != nil {
+			arg1 = args[1].(repository.ListFilter)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockURLRepository_List_Call) Return(uRLs []*model.URL, err error) *MockURLRepository_List_Call {
+	_c.Call.Return(uRLs, err)
+	return _c
+}
+
+func (_c *MockURLRepository_List_Call) RunAndReturn(run func(ctx context.Context, f repository.ListFilter) ([]*model.URL, error)) *MockURLRepository_List_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetDisabled provides a mock function for the type MockURLRepository
+func (_mock *MockURLRepository) SetDisabled(ctx context.Context, domain string, alias string, disabled bool) error {
+	ret := _mock.Called(ctx, domain, alias, disabled)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetDisabled")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, bool) error); ok {
+		r0 = returnFunc(ctx, domain, alias, disabled)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockURLRepository_SetDisabled_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetDisabled'
+type MockURLRepository_SetDisabled_Call struct {
+	*mock.Call
+}
+
+// SetDisabled is a helper method to define mock.On call
+//   - ctx context.Context
+//   - domain string
+//   - alias string
+//   - disabled bool
+func (_e *MockURLRepository_Expecter) SetDisabled(ctx interface{}, domain interface{}, alias interface{}, disabled interface{}) *MockURLRepository_SetDisabled_Call {
+	return &MockURLRepository_SetDisabled_Call{Call: _e.mock.On("SetDisabled", ctx, domain, alias, disabled)}
+}
+
+func (_c *MockURLRepository_SetDisabled_Call) Run(run func(ctx context.Context, domain string, alias string, disabled bool)) *MockURLRepository_SetDisabled_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 bool
+		if args[3] != nil {
+			arg3 = args[3].(bool)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockURLRepository_SetDisabled_Call) Return(err error) *MockURLRepository_SetDisabled_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockURLRepository_SetDisabled_Call) RunAndReturn(run func(ctx context.Context, domain string, alias string, disabled bool) error) *MockURLRepository_SetDisabled_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetHealth provides a mock function for the type MockURLRepository
+func (_mock *MockURLRepository) SetHealth(ctx context.Context, domain string, alias string, h *model.Health) error {
+	ret := _mock.Called(ctx, domain, alias, h)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetHealth")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, *model.Health) error); ok {
+		r0 = returnFunc(ctx, domain, alias, h)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockURLRepository_SetHealth_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetHealth'
+type MockURLRepository_SetHealth_Call struct {
+	*mock.Call
+}
+
+// SetHealth is a helper method to define mock.On call
+//   - ctx context.Context
+//   - domain string
+//   - alias string
+//   - h *model.Health
+func (_e *MockURLRepository_Expecter) SetHealth(ctx interface{}, domain interface{}, alias interface{}, h interface{}) *MockURLRepository_SetHealth_Call {
+	return &MockURLRepository_SetHealth_Call{Call: _e.mock.On("SetHealth", ctx, domain, alias, h)}
+}
+
+func (_c *MockURLRepository_SetHealth_Call) Run(run func(ctx context.Context, domain string, alias string, h *model.Health)) *MockURLRepository_SetHealth_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 *model.Health
+		if args[3] != nil {
+			arg3 = args[3].(*model.Health)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockURLRepository_SetHealth_Call) Return(err error) *MockURLRepository_SetHealth_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockURLRepository_SetHealth_Call) RunAndReturn(run func(ctx context.Context, domain string, alias string, h *model.Health) error) *MockURLRepository_SetHealth_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetInterstitial provides a mock function for the type MockURLRepository
+func (_mock *MockURLRepository) SetInterstitial(ctx context.Context, domain string, alias string, interstitial bool) error {
+	ret := _mock.Called(ctx, domain, alias, interstitial)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetInterstitial")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, bool) error); ok {
+		r0 = returnFunc(ctx, domain, alias, interstitial)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockURLRepository_SetInterstitial_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetInterstitial'
+type MockURLRepository_SetInterstitial_Call struct {
+	*mock.Call
+}
+
+// SetInterstitial is a helper method to define mock.On call
+//   - ctx context.Context
+//   - domain string
+//   - alias string
+//   - interstitial bool
+func (_e *MockURLRepository_Expecter) SetInterstitial(ctx interface{}, domain interface{}, alias interface{}, interstitial interface{}) *MockURLRepository_SetInterstitial_Call {
+	return &MockURLRepository_SetInterstitial_Call{Call: _e.mock.On("SetInterstitial", ctx, domain, alias, interstitial)}
+}
+
+func (_c *MockURLRepository_SetInterstitial_Call) Run(run func(ctx context.Context, domain string, alias string, interstitial bool)) *MockURLRepository_SetInterstitial_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 bool
+		if args[3] != nil {
+			arg3 = args[3].(bool)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockURLRepository_SetInterstitial_Call) Return(err error) *MockURLRepository_SetInterstitial_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockURLRepository_SetInterstitial_Call) RunAndReturn(run func(ctx context.Context, domain string, alias string, interstitial bool) error) *MockURLRepository_SetInterstitial_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetMetadata provides a mock function for the type MockURLRepository
+func (_mock *MockURLRepository) SetMetadata(ctx context.Context, domain string, alias string, meta *model.Metadata) error {
+	ret := _mock.Called(ctx, domain, alias, meta)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetMetadata")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, *model.Metadata) error); ok {
+		r0 = returnFunc(ctx, domain, alias, meta)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockURLRepository_SetMetadata_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetMetadata'
+type MockURLRepository_SetMetadata_Call struct {
+	*mock.Call
+}
+
+// SetMetadata is a helper method to define mock.On call
+//   - ctx context.Context
+//   - domain string
+//   - alias string
+//   - meta *model.Metadata
+func (_e *MockURLRepository_Expecter) SetMetadata(ctx interface{}, domain interface{}, alias interface{}, meta interface{}) *MockURLRepository_SetMetadata_Call {
+	return &MockURLRepository_SetMetadata_Call{Call: _e.mock.On("SetMetadata", ctx, domain, alias, meta)}
+}
+
+func (_c *MockURLRepository_SetMetadata_Call) Run(run func(ctx context.Context, domain string, alias string, meta *model.Metadata)) *MockURLRepository_SetMetadata_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 *model.Metadata
+		if args[3] != nil {
+			arg3 = args[3].(*model.Metadata)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockURLRepository_SetMetadata_Call) Return(err error) *MockURLRepository_SetMetadata_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockURLRepository_SetMetadata_Call) RunAndReturn(run func(ctx context.Context, domain string, alias string, meta *model.Metadata) error) *MockURLRepository_SetMetadata_Call {
 	_c.Call.Return(run)
 	return _c
 }
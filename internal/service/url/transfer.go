@@ -0,0 +1,191 @@
+package url
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Rasulikus/url-shortener/internal/apperr"
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	"github.com/Rasulikus/url-shortener/internal/service"
+	"github.com/Rasulikus/url-shortener/internal/utils/linkio"
+	"github.com/Rasulikus/url-shortener/internal/utils/validate"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// exportPageSize — сколько записей читается из хранилища за один запрос при экспорте.
+	exportPageSize = 500
+	// importBatchSize — сколько записей сохраняется в хранилище за один запрос при импорте.
+	importBatchSize = 500
+	// maxImportErrors — сколько ошибок разбора сохраняется в отчёте об импорте.
+	maxImportErrors = 100
+)
+
+// URLReader — источник записей для импорта, например linkio.Decoder.
+type URLReader interface {
+	// Decode возвращает следующую запись или io.EOF, когда записи закончились.
+	// Ошибка с linkio.ErrInvalidRecord означает, что пропущена одна строка.
+	Decode() (*model.URL, error)
+	// Line возвращает номер строки последней прочитанной записи.
+	Line() int
+}
+
+// ImportError описывает строку, которую не удалось импортировать.
+type ImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// ImportResult — итог импорта.
+type ImportResult struct {
+	// Total — количество прочитанных записей, включая ошибочные.
+	Total int `json:"total"`
+	// Imported — количество сохранённых записей.
+	Imported int `json:"imported"`
+	// Skipped — записи, чей алиас или long URL на их домене уже заняты.
+	Skipped int `json:"skipped"`
+	// Invalid — записи, не прошедшие разбор или проверку.
+	Invalid int `json:"invalid"`
+	// Errors — первые maxImportErrors ошибок.
+	Errors []ImportError `json:"errors,omitempty"`
+}
+
+func (r *ImportResult) addError(line int, err error) {
+	r.Invalid++
+	if len(r.Errors) < maxImportErrors {
+		r.Errors = append(r.Errors, ImportError{Line: line, Error: err.Error()})
+	}
+}
+
+// Export передаёт fn все записи в порядке ID, читая хранилище постранично.
+// Ошибка fn прерывает экспорт и возвращается как есть.
+func (s *Service) Export(ctx context.Context, fn func(u *model.URL) error) error {
+	var afterID int64
+	for {
+		urls, err := s.urlRepo.List(ctx, repository.ListFilter{
+			AfterID: afterID,
+			Limit:   exportPageSize,
+		})
+		if err != nil {
+			log.Error().
+				Err(err).
+				Int64("after_id", afterID).
+				Msg("failed to export urls")
+
+			return service.ErrInternalError
+		}
+
+		for _, u := range urls {
+			if err := fn(u); err != nil {
+				return err
+			}
+		}
+
+		if len(urls) < exportPageSize {
+			return nil
+		}
+		afterID = urls[len(urls)-1].ID
+	}
+}
+
+// Import читает записи из r, проверяет и канонизирует их и сохраняет пачками,
+// сохраняя алиасы и created_at. ID исходной системы не переносятся.
+// Ошибочные строки попадают в отчёт и не прерывают импорт.
+func (s *Service) Import(ctx context.Context, r URLReader) (*ImportResult, error) {
+	var (
+		res   = new(ImportResult)
+		batch = make([]*model.URL, 0, importBatchSize)
+	)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		n, err := s.urlRepo.Import(ctx, batch)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Int("batch", len(batch)).
+				Msg("failed to import urls")
+
+			return service.ErrInternalError
+		}
+
+		res.Imported += n
+		res.Skipped += len(batch) - n
+		batch = batch[:0]
+
+		return nil
+	}
+
+	for {
+		u, err := r.Decode()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if errors.Is(err, linkio.ErrInvalidRecord) {
+				res.Total++
+				res.addError(r.Line(), err)
+				continue
+			}
+			log.Error().
+				Err(err).
+				Int("line", r.Line()).
+				Msg("failed to read import")
+
+			return nil, &apperr.Error{
+				Code:    apperr.CodeInvalidInput,
+				Message: err.Error(),
+				Details: map[string]any{"line": r.Line()},
+				Err:     fmt.Errorf("%w: %w", service.ErrInvalidInput, err),
+			}
+		}
+		res.Total++
+
+		longURL, err := validate.CanonicalURL(u.LongURL)
+		if err != nil {
+			res.addError(r.Line(), err)
+			continue
+		}
+		if err := validate.Alias(u.Alias); err != nil {
+			res.addError(r.Line(), err)
+			continue
+		}
+		domain, err := s.domain(u.Domain)
+		if err != nil {
+			res.addError(r.Line(), err)
+			continue
+		}
+
+		batch = append(batch, &model.URL{
+			Domain:       domain,
+			LongURL:      longURL,
+			Alias:        u.Alias,
+			CreatedAt:    u.CreatedAt,
+			Disabled:     u.Disabled,
+			Interstitial: u.Interstitial,
+		})
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Int("total", res.Total).
+		Int("imported", res.Imported).
+		Int("skipped", res.Skipped).
+		Int("invalid", res.Invalid).
+		Msg("urls imported")
+
+	return res, nil
+}
@@ -0,0 +1,143 @@
+package url
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	"github.com/Rasulikus/url-shortener/internal/service"
+	"github.com/Rasulikus/url-shortener/internal/service/url/mocks"
+	"github.com/Rasulikus/url-shortener/internal/utils/linkio"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Export(t *testing.T) {
+	repo := new(mocks.MockURLRepository)
+
+	first := make([]*model.URL, exportPageSize)
+	for i := range first {
+		first[i] = &model.URL{ID: int64(i + 1), Alias: "a"}
+	}
+	last := []*model.URL{{ID: exportPageSize + 1, Alias: "b"}}
+
+	repo.EXPECT().
+		List(mock.Anything, repository.ListFilter{Limit: exportPageSize}).
+		Return(first, nil).
+		Once()
+	repo.EXPECT().
+		List(mock.Anything, repository.ListFilter{AfterID: exportPageSize, Limit: exportPageSize}).
+		Return(last, nil).
+		Once()
+
+	s := newService(t, repo)
+
+	var got []*model.URL
+	err := s.Export(context.Background(), func(u *model.URL) error {
+		got = append(got, u)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, got, exportPageSize+1)
+	require.Equal(t, "b", got[exportPageSize].Alias)
+	repo.AssertExpectations(t)
+}
+
+func TestService_Export_CallbackError(t *testing.T) {
+	repo := new(mocks.MockURLRepository)
+	repo.EXPECT().
+		List(mock.Anything, mock.Anything).
+		Return([]*model.URL{{ID: 1}, {ID: 2}}, nil).
+		Once()
+
+	s := newService(t, repo)
+
+	stop := errors.New("stop")
+	err := s.Export(context.Background(), func(u *model.URL) error {
+		return stop
+	})
+	require.ErrorIs(t, err, stop)
+}
+
+func TestService_Export_RepoError(t *testing.T) {
+	repo := new(mocks.MockURLRepository)
+	repo.EXPECT().
+		List(mock.Anything, mock.Anything).
+		Return(nil, errors.New("db down")).
+		Once()
+
+	s := newService(t, repo)
+
+	err := s.Export(context.Background(), func(u *model.URL) error { return nil })
+	require.ErrorIs(t, err, service.ErrInternalError)
+}
+
+func TestService_Import(t *testing.T) {
+	in := `{"alias":"aa","long_url":"HTTPS://Example.com:443/x","created_at":"2024-01-02T03:04:05Z"}
+not json
+{"alias":"bad alias","long_url":"https://b.com"}
+{"alias":"cc","long_url":"not a url"}
+{"alias":"dd","long_url":"https://d.com","disabled":true}
+{"alias":"ee","long_url":"https://e.com","domain":"Brand.Link"}
+{"alias":"ff","long_url":"https://f.com","domain":"unknown.com"}
+`
+	repo := new(mocks.MockURLRepository)
+	repo.EXPECT().
+		Import(mock.Anything, []*model.URL{
+			{
+				LongURL:   "https://example.com/x",
+				Alias:     "aa",
+				CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			},
+			{LongURL: "https://d.com", Alias: "dd", Disabled: true},
+			{Domain: "brand.link", LongURL: "https://e.com", Alias: "ee"},
+		}).
+		Return(2, nil).
+		Once()
+
+	s := newService(t, repo)
+
+	res, err := s.Import(context.Background(), linkio.NewDecoder(strings.NewReader(in), linkio.FormatJSONL))
+	require.NoError(t, err)
+	require.Equal(t, 7, res.Total)
+	require.Equal(t, 2, res.Imported)
+	require.Equal(t, 1, res.Skipped)
+	require.Equal(t, 4, res.Invalid)
+	require.Len(t, res.Errors, 4)
+	require.Equal(t, 2, res.Errors[0].Line)
+	require.Equal(t, 3, res.Errors[1].Line)
+	require.Equal(t, 4, res.Errors[2].Line)
+	require.Equal(t, 7, res.Errors[3].Line)
+	require.Contains(t, res.Errors[3].Error, "unknown domain")
+	repo.AssertExpectations(t)
+}
+
+func TestService_Import_RepoError(t *testing.T) {
+	repo := new(mocks.MockURLRepository)
+	repo.EXPECT().
+		Import(mock.Anything, mock.Anything).
+		Return(0, errors.New("db down")).
+		Once()
+
+	s := newService(t, repo)
+
+	in := `{"alias":"aa","long_url":"https://a.com"}`
+	res, err := s.Import(context.Background(), linkio.NewDecoder(strings.NewReader(in), linkio.FormatJSONL))
+	require.Nil(t, res)
+	require.ErrorIs(t, err, service.ErrInternalError)
+}
+
+func TestService_Import_ReadError(t *testing.T) {
+	s := newService(t, new(mocks.MockURLRepository))
+
+	readErr := errors.New("body too large")
+	res, err := s.Import(context.Background(), linkio.NewDecoder(iotest.ErrReader(readErr), linkio.FormatJSONL))
+	require.Nil(t, res)
+	require.ErrorIs(t, err, service.ErrInvalidInput)
+	require.ErrorIs(t, err, readErr)
+}
@@ -0,0 +1,150 @@
+package url
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+type MetadataFetcher interface {
+	// Fetch загружает страницу longURL и возвращает её метаданные.
+	Fetch(ctx context.Context, longURL string) (*model.Metadata, error)
+}
+
+// UnfurlConfig задаёт фоновую загрузку метаданных.
+type UnfurlConfig struct {
+	// Workers — количество одновременных загрузок.
+	Workers int
+	// QueueSize — длина очереди; ссылки сверх неё пропускаются.
+	QueueSize int
+}
+
+// unfurler загружает метаданные страниц назначения в фоне.
+type unfurler struct {
+	s     *Service
+	f     MetadataFetcher
+	queue chan *model.URL
+
+	mu sync.Mutex
+	// pending — ссылки в очереди или в работе, чтобы не загружать одну страницу дважды.
+	pending map[string]struct{}
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// StartUnfurl запускает фоновую загрузку метаданных для ссылок, которые
+// CreateOrGetURL вернул без них. Вызывается до начала обработки запросов.
+// Возвращаемая функция останавливает загрузку и дожидается воркеров.
+func (s *Service) StartUnfurl(f MetadataFetcher, cfg UnfurlConfig) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	u := &unfurler{
+		s:       s,
+		f:       f,
+		queue:   make(chan *model.URL, cfg.QueueSize),
+		pending: make(map[string]struct{}),
+		cancel:  cancel,
+	}
+	for range cfg.Workers {
+		u.wg.Add(1)
+		go u.run(ctx)
+	}
+	s.unfurl = u
+
+	log.Info().
+		Int("workers", cfg.Workers).
+		Int("queue_size", cfg.QueueSize).
+		Msg("url unfurler started")
+
+	return func() {
+		cancel()
+		u.wg.Wait()
+	}
+}
+
+// enqueue ставит ссылку в очередь, не блокируясь.
+func (u *unfurler) enqueue(url *model.URL) {
+	k := key(url.Domain, url.Alias)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if _, ok := u.pending[k]; ok {
+		return
+	}
+	select {
+	case u.queue <- url:
+		u.pending[k] = struct{}{}
+	default:
+		u.s.metrics.unfurlDropped.Add(1)
+
+		log.Warn().
+			Str("domain", url.Domain).
+			Str("alias", url.Alias).
+			Msg("unfurl queue is full")
+	}
+}
+
+func (u *unfurler) run(ctx context.Context) {
+	defer u.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case url := <-u.queue:
+			u.fetch(ctx, url)
+
+			u.mu.Lock()
+			delete(u.pending, key(url.Domain, url.Alias))
+			u.mu.Unlock()
+		}
+	}
+}
+
+// fetch загружает и сохраняет метаданные ссылки. Ошибка загрузки тоже сохраняется,
+// чтобы не повторять её для каждого запроса той же ссылки.
+func (u *unfurler) fetch(ctx context.Context, url *model.URL) {
+	meta, err := u.f.Fetch(ctx, url.LongURL)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		u.s.metrics.unfurlFailed.Add(1)
+
+		log.Debug().
+			Err(err).
+			Str("url", url.LongURL).
+			Msg("failed to unfurl url")
+
+		meta = &model.Metadata{Error: err.Error()}
+	} else {
+		u.s.metrics.unfurled.Add(1)
+	}
+	meta.FetchedAt = time.Now().UTC()
+
+	if err := u.s.urlRepo.SetMetadata(ctx, url.Domain, url.Alias, meta); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			log.Debug().
+				Str("domain", url.Domain).
+				Str("alias", url.Alias).
+				Msg("url deleted before metadata was saved")
+			return
+		}
+		log.Error().
+			Err(err).
+			Str("domain", url.Domain).
+			Str("alias", url.Alias).
+			Msg("failed to save url metadata")
+	}
+}
+
+func key(domain, alias string) string {
+	return domain + "/" + alias
+}
@@ -0,0 +1,153 @@
+package url
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	"github.com/Rasulikus/url-shortener/internal/service/url/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fetcherFunc func(ctx context.Context, longURL string) (*model.Metadata, error)
+
+func (f fetcherFunc) Fetch(ctx context.Context, longURL string) (*model.Metadata, error) {
+	return f(ctx, longURL)
+}
+
+func TestService_Unfurl(t *testing.T) {
+	cases := []struct {
+		name     string
+		fetchRet *model.Metadata
+		fetchErr error
+		saveErr  error
+		want     model.Metadata
+		metrics  Metrics
+	}{
+		{
+			name:     "success",
+			fetchRet: &model.Metadata{Title: "Example"},
+			want:     model.Metadata{Title: "Example"},
+			metrics:  Metrics{Created: 1, Unfurled: 1},
+		},
+		{
+			name:     "fetch error is stored",
+			fetchErr: errors.New("unfurl: unexpected status 500"),
+			want:     model.Metadata{Error: "unfurl: unexpected status 500"},
+			metrics:  Metrics{Created: 1, UnfurlFailed: 1},
+		},
+		{
+			name:     "url deleted",
+			fetchRet: &model.Metadata{Title: "Example"},
+			saveErr:  repository.ErrNotFound,
+			want:     model.Metadata{Title: "Example"},
+			metrics:  Metrics{Created: 1, Unfurled: 1},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mocks.MockURLRepository)
+			s := newService(t, repo)
+
+			saved := make(chan *model.Metadata, 1)
+			repo.On("CreateOrGet", mock.Anything, mock.Anything).
+				Return(&model.URL{ID: 1, Domain: "brand.link", Alias: "aa", LongURL: "http://example.com"}, true, nil).
+				Once()
+			repo.On("SetMetadata", mock.Anything, "brand.link", "aa", mock.Anything).
+				Run(func(args mock.Arguments) { saved <- args.Get(3).(*model.Metadata) }).
+				Return(tc.saveErr).
+				Once()
+
+			stop := s.StartUnfurl(fetcherFunc(func(_ context.Context, longURL string) (*model.Metadata, error) {
+				assert.Equal(t, "http://example.com", longURL)
+				return tc.fetchRet, tc.fetchErr
+			}), UnfurlConfig{Workers: 1, QueueSize: 1})
+			defer stop()
+
+			_, err := s.CreateOrGetURL(context.Background(), "brand.link", "http://example.com")
+			require.NoError(t, err)
+
+			var got *model.Metadata
+			select {
+			case got = <-saved:
+			case <-time.After(time.Second):
+				t.Fatal("metadata was not saved")
+			}
+			require.False(t, got.FetchedAt.IsZero())
+			got.FetchedAt = time.Time{}
+			require.Equal(t, tc.want, *got)
+
+			stop()
+			require.Equal(t, tc.metrics, s.Metrics())
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Unfurl_SkipsKnownMetadata(t *testing.T) {
+	repo := new(mocks.MockURLRepository)
+	s := newService(t, repo)
+
+	repo.On("CreateOrGet", mock.Anything, mock.Anything).
+		Return(&model.URL{ID: 1, Alias: "aa", LongURL: "http://example.com", Meta: &model.Metadata{Title: "Example"}}, false, nil).
+		Once()
+
+	stop := s.StartUnfurl(fetcherFunc(func(context.Context, string) (*model.Metadata, error) {
+		t.Error("unexpected fetch")
+		return nil, nil
+	}), UnfurlConfig{Workers: 1, QueueSize: 1})
+
+	_, err := s.CreateOrGetURL(context.Background(), "", "http://example.com")
+	require.NoError(t, err)
+
+	stop()
+	repo.AssertExpectations(t)
+}
+
+func TestService_Unfurl_QueueFull(t *testing.T) {
+	repo := new(mocks.MockURLRepository)
+	s := newService(t, repo)
+
+	for i, alias := range []string{"aa", "bb", "bb", "cc"} {
+		repo.On("CreateOrGet", mock.Anything, mock.Anything).
+			Return(&model.URL{ID: int64(i + 1), Alias: alias, LongURL: "http://example.com/" + alias}, true, nil).
+			Once()
+	}
+	repo.On("SetMetadata", mock.Anything, "", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	// Воркер занят первой ссылкой, вторая ждёт в очереди, третья в неё не помещается
+	var (
+		once    sync.Once
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+	stop := s.StartUnfurl(fetcherFunc(func(ctx context.Context, _ string) (*model.Metadata, error) {
+		once.Do(func() { close(started) })
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return &model.Metadata{}, nil
+	}), UnfurlConfig{Workers: 1, QueueSize: 1})
+
+	_, err := s.CreateOrGetURL(context.Background(), "", "http://example.com/aa")
+	require.NoError(t, err)
+	<-started
+
+	// Повтор ссылки из очереди не считается переполнением
+	for _, u := range []string{"bb", "bb", "cc"} {
+		_, err := s.CreateOrGetURL(context.Background(), "", "http://example.com/"+u)
+		require.NoError(t, err)
+	}
+	require.Equal(t, int64(1), s.Metrics().UnfurlDropped)
+
+	close(release)
+	stop()
+}
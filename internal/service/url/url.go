@@ -3,120 +3,414 @@ package url
 import (
 	"context"
 	"errors"
-	"strings"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"time"
 
+	"github.com/Rasulikus/url-shortener/internal/apperr"
 	"github.com/Rasulikus/url-shortener/internal/model"
 	"github.com/Rasulikus/url-shortener/internal/repository"
 	"github.com/Rasulikus/url-shortener/internal/service"
+	"github.com/Rasulikus/url-shortener/internal/utils/domains"
 	"github.com/Rasulikus/url-shortener/internal/utils/generator"
 	"github.com/Rasulikus/url-shortener/internal/utils/validate"
 	"github.com/rs/zerolog/log"
 )
 
+// URLRepository хранит ссылки. Создание, изменение флагов, удаление и достижение
+// порога переходов пишут событие в outbox той же транзакцией, см. model.Event.
 type URLRepository interface {
 	// GetLastID возвращает последний использованный ID.
 	// Используется для инициализации генератора алиасов.
 	GetLastID(ctx context.Context) (uint64, error)
 
-	// CreateOrGet создаёт новую запись с длинным URL и алиасом.
-	// Если такой long URL уже существует, возвращает существующую запись.
-	// Может вернуть ErrConflict при конфликте уникальности.
-	CreateOrGet(ctx context.Context, u *model.URL) (*model.URL, error)
+	// CreateOrGet создаёт новую запись с длинным URL и алиасом и пишет событие url.created.
+	// Если u.ID не равен 0, запись сохраняется с этим ID, иначе ID выбирает хранилище.
+	// Алиас и long URL уникальны в пределах домена u.Domain.
+	// Если такой long URL на домене уже существует, возвращает существующую запись без события
+	// и created = false. Может вернуть ErrConflict при конфликте уникальности.
+	CreateOrGet(ctx context.Context, u *model.URL) (_ *model.URL, created bool, _ error)
 
-	// GetLongURLByAlias возвращает длинный URL по алиасу.
+	// GetLongURLByAlias возвращает длинный URL по алиасу на домене.
+	// Если алиас не найден, возвращает ErrNotFound, если ссылка отключена — ErrDisabled.
+	GetLongURLByAlias(ctx context.Context, domain, alias string) (string, error)
+
+	// GetByAlias возвращает запись по алиасу на домене, в том числе отключённую.
+	// Если алиас не найден, возвращает ErrNotFound.
+	GetByAlias(ctx context.Context, domain, alias string) (*model.URL, error)
+
+	// List возвращает страницу записей, упорядоченных по ID.
+	List(ctx context.Context, f repository.ListFilter) ([]*model.URL, error)
+
+	// SetDisabled включает или отключает ссылку и пишет событие url.updated.
+	// Если алиас не найден, возвращает ErrNotFound.
+	SetDisabled(ctx context.Context, domain, alias string, disabled bool) error
+
+	// SetInterstitial включает или выключает страницу предпросмотра перед переходом
+	// и пишет событие url.updated.
+	// Если алиас не найден, возвращает ErrNotFound.
+	SetInterstitial(ctx context.Context, domain, alias string, interstitial bool) error
+
+	// SetMetadata сохраняет метаданные страницы назначения.
+	// Если алиас не найден, возвращает ErrNotFound.
+	SetMetadata(ctx context.Context, domain, alias string, meta *model.Metadata) error
+
+	// SetHealth сохраняет результат проверки доступности страницы назначения.
+	// Если алиас не найден, возвращает ErrNotFound.
+	SetHealth(ctx context.Context, domain, alias string, h *model.Health) error
+
+	// AddClick увеличивает счётчик переходов и возвращает новое значение.
+	// Если оно совпало с одним из thresholds, пишет событие url.clicks.
+	// Если алиас не найден, возвращает ErrNotFound.
+	AddClick(ctx context.Context, domain, alias string, thresholds []int64) (int64, error)
+
+	// Delete удаляет ссылку и пишет событие url.deleted.
 	// Если алиас не найден, возвращает ErrNotFound.
-	GetLongURLByAlias(ctx context.Context, alias string) (string, error)
+	Delete(ctx context.Context, domain, alias string) error
+
+	// Import сохраняет записи с их алиасами и created_at, ID выбирает хранилище.
+	// Записи, чей алиас или long URL на их домене уже заняты, пропускаются.
+	// Для каждой сохранённой записи пишет событие url.created.
+	// Возвращает количество сохранённых записей.
+	Import(ctx context.Context, urls []*model.URL) (int, error)
 }
 
 type AliasGenerator interface {
-	// NewAlias генерирует новый алиас для ссылки.
-	NewAlias() (string, error)
+	// NewAlias генерирует новый алиас для ссылки longURL.
+	// attempt — номер попытки начиная с 0; детерминированные генераторы
+	// используют его, чтобы после коллизии выдать другой алиас.
+	// Если алиас выведен из ID, возвращает и этот ID — запись сохраняется с ним.
+	// Иначе id равен 0 и ID выбирает хранилище.
+	NewAlias(ctx context.Context, longURL string, attempt int) (id uint64, alias string, err error)
+}
+
+// RetryPolicy задаёт повторные попытки CreateOrGet при коллизии алиаса.
+type RetryPolicy struct {
+	// MaxAttempts — общее число попыток, включая первую.
+	MaxAttempts int
+	// Backoff — пауза перед второй попыткой, удваивается с каждой следующей.
+	Backoff time.Duration
 }
 
 type Service struct {
-	baseURL string
+	domains *domains.Registry
 	gen     AliasGenerator
 	urlRepo URLRepository
+	// retry можно заменить на лету через SetRetryPolicy.
+	retry   atomic.Pointer[RetryPolicy]
+	metrics metrics
+	// unfurl — фоновая загрузка метаданных, nil — выключена.
+	unfurl *unfurler
+	// clickThresholds — пороги переходов для события url.clicks. Пока список пуст,
+	// переходы не считаются.
+	clickThresholds []int64
 }
 
-func NewService(baseUrl string, gen AliasGenerator, urlRepo URLRepository) (*Service, error) {
+func NewService(domains *domains.Registry, gen AliasGenerator, urlRepo URLRepository, retry RetryPolicy) (*Service, error) {
 	log.Info().Msg("starting new URL Service")
 
-	log.Info().
-		Str("base_url", baseUrl).
-		Int("alias_length", generator.DefaultLength).
-		Msg("url service initialized")
+	if domains == nil {
+		return nil, errors.New("url service: domains registry is nil")
+	}
 
-	return &Service{
-		baseURL: baseUrl,
+	s := &Service{
+		domains: domains,
 		gen:     gen,
 		urlRepo: urlRepo,
-	}, nil
+	}
+	if err := s.SetRetryPolicy(retry); err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("base_url", domains.ShortURL("", "")).
+		Int("max_attempts", retry.MaxAttempts).
+		Dur("retry_backoff", retry.Backoff).
+		Msg("url service initialized")
+
+	return s, nil
 }
 
-func (s *Service) CreateOrGet(ctx context.Context, longURL string) (string, error) {
-	longURL = strings.TrimSpace(longURL)
+// SetRetryPolicy заменяет политику повторов. Запросы, которые уже выполняются,
+// доводят попытки по прежней политике.
+func (s *Service) SetRetryPolicy(retry RetryPolicy) error {
+	if retry.MaxAttempts <= 0 {
+		return fmt.Errorf("url service: max attempts must be positive: %d", retry.MaxAttempts)
+	}
+
+	s.retry.Store(&retry)
+	return nil
+}
+
+// SetClickThresholds включает подсчёт переходов в Click: при достижении одного из
+// порогов хранилище пишет событие url.clicks. Вызывается до начала обслуживания запросов.
+func (s *Service) SetClickThresholds(thresholds []int64) {
+	s.clickThresholds = slices.Clone(thresholds)
+}
+
+// Metrics возвращает текущие значения счётчиков сервиса.
+func (s *Service) Metrics() Metrics {
+	return s.metrics.snapshot()
+}
 
-	if err := validate.URL(longURL); err != nil {
+// domain проверяет, что домен зарегистрирован, и возвращает его имя в том виде,
+// в котором оно хранится. Пустое имя — домен по умолчанию.
+func (s *Service) domain(name string) (string, error) {
+	normalized, err := domains.Normalize(name)
+	if err == nil {
+		if _, ok := s.domains.Lookup(normalized); ok {
+			return normalized, nil
+		}
+	}
+
+	log.Debug().
+		Str("domain", name).
+		Msg("unknown domain")
+
+	return "", &apperr.Error{
+		Code:    apperr.CodeUnknownDomain,
+		Message: fmt.Sprintf("unknown domain %q", name),
+		Field:   "domain",
+		Err:     service.ErrUnknownDomain,
+	}
+}
+
+// errNotFound — на домене нет ссылки с алиасом.
+func errNotFound(domain, alias string) error {
+	return &apperr.Error{
+		Code:    apperr.CodeNotFound,
+		Message: "short link not found",
+		Details: linkDetails(domain, alias),
+		Err:     service.ErrNotFound,
+	}
+}
+
+// errDisabled — ссылка с алиасом отключена.
+func errDisabled(domain, alias string) error {
+	return &apperr.Error{
+		Code:    apperr.CodeDisabled,
+		Message: "short link is disabled",
+		Details: linkDetails(domain, alias),
+		Err:     service.ErrDisabled,
+	}
+}
+
+// linkDetails — подробности ошибки о ссылке; домен по умолчанию не указывается.
+func linkDetails(domain, alias string) map[string]any {
+	d := map[string]any{"alias": alias}
+	if domain != "" {
+		d["domain"] = domain
+	}
+	return d
+}
+
+// CreateOrGet создаёт короткую ссылку на домене или возвращает существующую.
+func (s *Service) CreateOrGet(ctx context.Context, domain, longURL string) (string, error) {
+	u, err := s.CreateOrGetURL(ctx, domain, longURL)
+	if err != nil {
+		return "", err
+	}
+
+	return s.ShortURL(u.Domain, u.Alias), nil
+}
+
+// CreateOrGetURL работает как CreateOrGet, но возвращает сохранённую запись.
+func (s *Service) CreateOrGetURL(ctx context.Context, domain, longURL string) (*model.URL, error) {
+	domain, err := s.domain(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	canonical, err := validate.CanonicalURL(longURL)
+	if err != nil {
 		log.Debug().
 			Str("url", longURL).
 			Err(err).
 			Msg("invalid url")
 
-		return "", service.ErrInvalidInput
+		return nil, service.InvalidField("long_url", err)
 	}
+	longURL = canonical
 
-	alias, err := s.gen.NewAlias()
-	if err != nil {
-		log.Error().
-			Err(err).
-			Msg("failed to generate alias")
+	var (
+		u       *model.URL
+		created bool
+		retry   = s.retry.Load()
+	)
 
-		return "", service.ErrInternalError
-	}
+	for attempt := 0; attempt < retry.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			s.metrics.aliasRetries.Add(1)
 
-	u, err := s.urlRepo.CreateOrGet(ctx, &model.URL{
-		LongURL: longURL,
-		Alias:   alias,
-	})
-	if err != nil {
-		if errors.Is(err, repository.ErrConflict) {
-			log.Warn().
+			if err := wait(ctx, retry.Backoff, attempt); err != nil {
+				return nil, err
+			}
+		}
+
+		var (
+			id    uint64
+			alias string
+		)
+		id, alias, err = s.gen.NewAlias(ctx, longURL, attempt)
+		if errors.Is(err, generator.ErrOverflow) {
+			log.Error().
+				Err(err).
+				Msg("alias space exhausted, increase ALIAS_LENGTH")
+
+			return nil, &apperr.Error{
+				Code:    apperr.CodeAliasSpaceExhausted,
+				Message: "no free aliases of the configured length are left",
+				Err:     service.ErrUnavailable,
+			}
+		}
+		if err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to generate alias")
+
+			return nil, service.ErrInternalError
+		}
+
+		u, created, err = s.urlRepo.CreateOrGet(ctx, &model.URL{
+			ID:      int64(id),
+			Domain:  domain,
+			LongURL: longURL,
+			Alias:   alias,
+		})
+		if err == nil {
+			break
+		}
+
+		// ID мог занять импорт в другом процессе; новая попытка получит другой ID и алиас
+		if !errors.Is(err, repository.ErrAliasConflict) && !errors.Is(err, repository.ErrIDConflict) {
+			if errors.Is(err, repository.ErrConflict) {
+				log.Warn().
+					Err(err).
+					Str("alias", alias).
+					Str("url", longURL).
+					Msg("conflict while creating url")
+
+				return nil, fmt.Errorf("%w: %w", service.ErrConflict, err)
+			}
+
+			log.Error().
 				Err(err).
 				Str("alias", alias).
 				Str("url", longURL).
-				Msg("conflict while creating url")
+				Msg("failed to create url")
 
-			return "", service.ErrConflict
+			return nil, service.ErrInternalError
 		}
 
-		log.Error().
+		s.metrics.aliasCollisions.Add(1)
+
+		log.Warn().
 			Err(err).
 			Str("alias", alias).
 			Str("url", longURL).
-			Msg("failed to create url")
+			Int("attempt", attempt+1).
+			Msg("alias collision while creating url")
+	}
+	if err != nil {
+		s.metrics.aliasRetriesExhausted.Add(1)
 
-		return "", service.ErrInternalError
+		log.Error().
+			Err(err).
+			Str("url", longURL).
+			Int("attempts", retry.MaxAttempts).
+			Msg("alias retries exhausted while creating url")
+
+		return nil, &apperr.Error{
+			Code:    apperr.CodeAliasExhausted,
+			Message: "no free alias found, try again later",
+			Details: map[string]any{"attempts": retry.MaxAttempts},
+			Err:     service.ErrConflict,
+		}
 	}
 
-	log.Info().
-		Int64("id", u.ID).
-		Str("alias", u.Alias).
-		Str("long_url", u.LongURL).
-		Msg("url created")
+	// Существующая запись по тому же long URL не считается созданной
+	if created {
+		s.metrics.created.Add(1)
 
-	return s.baseURL + "/" + u.Alias, nil
+		log.Info().
+			Int64("id", u.ID).
+			Str("domain", u.Domain).
+			Str("alias", u.Alias).
+			Str("long_url", u.LongURL).
+			Msg("url created")
+	}
+
+	if s.unfurl != nil && u.Meta == nil {
+		s.unfurl.enqueue(u)
+	}
+
+	return u, nil
+}
+
+// ShortURL возвращает короткую ссылку для алиаса на домене.
+func (s *Service) ShortURL(domain, alias string) string {
+	return s.domains.ShortURL(domain, alias)
 }
 
-func (s *Service) GetLongURLByAlias(ctx context.Context, a string) (string, error) {
-	longURL, err := s.urlRepo.GetLongURLByAlias(ctx, a)
+// maxRetryBackoff ограничивает паузу между попытками подобрать алиас: клиент ждёт
+// ответа, пока идут повторы.
+const maxRetryBackoff = time.Second
+
+// retryDelay возвращает паузу перед попыткой attempt: backoff, удвоенный attempt-1 раз,
+// но не больше maxRetryBackoff.
+func retryDelay(backoff time.Duration, attempt int) time.Duration {
+	d := backoff << (attempt - 1)
+	if d <= 0 || d > maxRetryBackoff || d>>(attempt-1) != backoff {
+		return maxRetryBackoff
+	}
+	return d
+}
+
+// wait выдерживает экспоненциальную паузу перед попыткой attempt. Если ctx завершился
+// раньше, возвращает его ошибку.
+func wait(ctx context.Context, backoff time.Duration, attempt int) error {
+	if backoff <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(retryDelay(backoff, attempt))
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		log.Warn().
+			Err(ctx.Err()).
+			Int("attempt", attempt+1).
+			Msg("context done while waiting for alias retry")
+
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func (s *Service) GetLongURLByAlias(ctx context.Context, domain, a string) (string, error) {
+	domain, err := s.domain(domain)
+	if err != nil {
+		return "", err
+	}
+
+	longURL, err := s.urlRepo.GetLongURLByAlias(ctx, domain, a)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			log.Warn().
 				Str("alias", a).
 				Msg("alias not found")
 
-			return "", service.ErrNotFound
+			return "", errNotFound(domain, a)
+		}
+		if errors.Is(err, repository.ErrDisabled) {
+			log.Warn().
+				Str("alias", a).
+				Msg("alias disabled")
+
+			return "", errDisabled(domain, a)
 		}
 		log.Error().
 			Err(err).
@@ -131,3 +425,169 @@ func (s *Service) GetLongURLByAlias(ctx context.Context, a string) (string, erro
 
 	return longURL, nil
 }
+
+// Resolve возвращает запись по алиасу на домене, в том числе отключённую.
+func (s *Service) Resolve(ctx context.Context, domain, alias string) (*model.URL, error) {
+	domain, err := s.domain(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := s.urlRepo.GetByAlias(ctx, domain, alias)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, errNotFound(domain, alias)
+		}
+		log.Error().
+			Err(err).
+			Str("alias", alias).
+			Msg("failed to resolve alias")
+
+		return nil, service.ErrInternalError
+	}
+
+	return u, nil
+}
+
+// Follow возвращает запись для перехода по алиасу на домене.
+// В отличие от Resolve, для отключённой ссылки возвращает ErrDisabled.
+// Переход не засчитывается, для этого вызывающий вызывает Click.
+func (s *Service) Follow(ctx context.Context, domain, alias string) (*model.URL, error) {
+	u, err := s.Resolve(ctx, domain, alias)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			log.Warn().
+				Str("alias", alias).
+				Msg("alias not found")
+		}
+		return nil, err
+	}
+	if u.Disabled {
+		log.Warn().
+			Str("alias", alias).
+			Msg("alias disabled")
+
+		return nil, errDisabled(u.Domain, alias)
+	}
+
+	return u, nil
+}
+
+// Click засчитывает переход по ссылке u, полученной из Follow.
+// Ошибка подсчёта не мешает переходу и только логируется.
+func (s *Service) Click(ctx context.Context, u *model.URL) {
+	if len(s.clickThresholds) == 0 {
+		return
+	}
+	if _, err := s.urlRepo.AddClick(ctx, u.Domain, u.Alias, s.clickThresholds); err != nil {
+		log.Error().
+			Err(err).
+			Str("alias", u.Alias).
+			Msg("failed to count click")
+	}
+}
+
+// List возвращает до f.Limit записей с ID больше f.AfterID, подходящих под фильтр.
+func (s *Service) List(ctx context.Context, f repository.ListFilter) ([]*model.URL, error) {
+	if f.Limit <= 0 {
+		return nil, service.InvalidParam("limit", "must be positive")
+	}
+	if f.AfterID < 0 {
+		return nil, service.InvalidParam("after_id", "must not be negative")
+	}
+
+	urls, err := s.urlRepo.List(ctx, f)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Int64("after_id", f.AfterID).
+			Msg("failed to list urls")
+
+		return nil, service.ErrInternalError
+	}
+
+	return urls, nil
+}
+
+// SetDisabled отключает ссылку или включает её обратно.
+func (s *Service) SetDisabled(ctx context.Context, domain, alias string, disabled bool) error {
+	domain, err := s.domain(domain)
+	if err != nil {
+		return err
+	}
+
+	if err := s.urlRepo.SetDisabled(ctx, domain, alias, disabled); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return errNotFound(domain, alias)
+		}
+		log.Error().
+			Err(err).
+			Str("alias", alias).
+			Msg("failed to set disabled")
+
+		return service.ErrInternalError
+	}
+
+	log.Info().
+		Str("domain", domain).
+		Str("alias", alias).
+		Bool("disabled", disabled).
+		Msg("url disabled state changed")
+
+	return nil
+}
+
+// SetInterstitial включает или выключает страницу предпросмотра перед переходом по ссылке.
+func (s *Service) SetInterstitial(ctx context.Context, domain, alias string, interstitial bool) error {
+	domain, err := s.domain(domain)
+	if err != nil {
+		return err
+	}
+
+	if err := s.urlRepo.SetInterstitial(ctx, domain, alias, interstitial); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return errNotFound(domain, alias)
+		}
+		log.Error().
+			Err(err).
+			Str("alias", alias).
+			Msg("failed to set interstitial")
+
+		return service.ErrInternalError
+	}
+
+	log.Info().
+		Str("domain", domain).
+		Str("alias", alias).
+		Bool("interstitial", interstitial).
+		Msg("url interstitial changed")
+
+	return nil
+}
+
+// Delete удаляет ссылку.
+func (s *Service) Delete(ctx context.Context, domain, alias string) error {
+	domain, err := s.domain(domain)
+	if err != nil {
+		return err
+	}
+
+	if err := s.urlRepo.Delete(ctx, domain, alias); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return errNotFound(domain, alias)
+		}
+		log.Error().
+			Err(err).
+			Str("alias", alias).
+			Msg("failed to delete url")
+
+		return service.ErrInternalError
+	}
+
+	log.Info().
+		Str("domain", domain).
+		Str("alias", alias).
+		Msg("url deleted")
+
+	return nil
+}
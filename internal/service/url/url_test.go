@@ -4,27 +4,54 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/Rasulikus/url-shortener/internal/apperr"
 	"github.com/Rasulikus/url-shortener/internal/model"
 	"github.com/Rasulikus/url-shortener/internal/repository"
 	"github.com/Rasulikus/url-shortener/internal/service"
 	"github.com/Rasulikus/url-shortener/internal/service/url/mocks"
+	"github.com/Rasulikus/url-shortener/internal/utils/domains"
 	"github.com/Rasulikus/url-shortener/internal/utils/generator"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
+var testRetry = RetryPolicy{
+	MaxAttempts: 3,
+	Backoff:     time.Millisecond,
+}
+
+func newTestDomains(t *testing.T) *domains.Registry {
+	t.Helper()
+
+	reg, err := domains.New("http://localhost:8080", domains.Domain{}, []domains.Domain{{Name: "brand.link"}})
+	require.NoError(t, err)
+	return reg
+}
+
 func newService(t *testing.T, repo URLRepository) *Service {
 	t.Helper()
 
 	gen, err := generator.NewRandom(generator.DefaultLength)
 	require.NoError(t, err)
 
-	s, err := NewService("http://localhost:8080", gen, repo)
+	s, err := NewService(newTestDomains(t), gen, repo, testRetry)
 	require.NoError(t, err)
 	return s
 }
 
+// requireAppErr проверяет код и поле ошибки приложения в цепочке err.
+func requireAppErr(t *testing.T, err error, code apperr.Code, field string) {
+	t.Helper()
+
+	e, ok := apperr.As(err)
+	require.True(t, ok, "no apperr.Error in %v", err)
+	require.Equal(t, code, e.Code)
+	require.Equal(t, field, e.Field)
+	require.NotEmpty(t, e.Message)
+}
+
 func TestService_GetLongURLByAlias(t *testing.T) {
 	cases := []struct {
 		name      string
@@ -63,13 +90,13 @@ func TestService_GetLongURLByAlias(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			repo := new(mocks.MockURLRepository)
 
-			repo.On("GetLongURLByAlias", mock.Anything, tc.alias).
+			repo.On("GetLongURLByAlias", mock.Anything, "", tc.alias).
 				Return(tc.repoRet, tc.repoErr).
 				Once()
 
 			s := newService(t, repo)
 
-			got, err := s.GetLongURLByAlias(context.Background(), tc.alias)
+			got, err := s.GetLongURLByAlias(context.Background(), "", tc.alias)
 
 			require.Equal(t, tc.wantURL, got)
 
@@ -78,6 +105,9 @@ func TestService_GetLongURLByAlias(t *testing.T) {
 			} else {
 				require.NoError(t, err)
 			}
+			if tc.wantErrIs == service.ErrNotFound {
+				requireAppErr(t, err, apperr.CodeNotFound, "")
+			}
 
 			repo.AssertExpectations(t)
 		})
@@ -97,12 +127,12 @@ func TestService_CreateOrGet_Success(t *testing.T) {
 		})).
 			Return(&model.URL{
 				Alias: "aa",
-			}, nil).
+			}, true, nil).
 			Once()
 
 		s := newService(t, repo)
 
-		got, err := s.CreateOrGet(context.Background(), "http://example.com")
+		got, err := s.CreateOrGet(context.Background(), "", "http://example.com")
 
 		require.NoError(t, err)
 		require.Equal(t, "http://localhost:8080/aa", got)
@@ -111,17 +141,96 @@ func TestService_CreateOrGet_Success(t *testing.T) {
 	})
 }
 
+func TestService_CreateOrGet_Existing(t *testing.T) {
+	repo := new(mocks.MockURLRepository)
+
+	// Хранилище вернуло уже существующую запись по тому же long URL
+	repo.On("CreateOrGet", mock.Anything, mock.Anything).
+		Return(&model.URL{
+			ID:    1,
+			Alias: "aa",
+		}, false, nil).
+		Once()
+
+	s := newService(t, repo)
+
+	got, err := s.CreateOrGet(context.Background(), "", "http://example.com")
+
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:8080/aa", got)
+	require.Zero(t, s.Metrics().Created)
+
+	repo.AssertExpectations(t)
+}
+
+func TestService_CreateOrGet_Domain(t *testing.T) {
+	repo := new(mocks.MockURLRepository)
+
+	repo.On("CreateOrGet", mock.Anything, mock.MatchedBy(func(u *model.URL) bool {
+		return u != nil && u.Domain == "brand.link"
+	})).
+		Return(&model.URL{
+			Domain: "brand.link",
+			Alias:  "aa",
+		}, true, nil).
+		Once()
+
+	s := newService(t, repo)
+
+	got, err := s.CreateOrGet(context.Background(), "Brand.Link", "http://example.com")
+
+	require.NoError(t, err)
+	require.Equal(t, "http://brand.link/aa", got)
+
+	repo.AssertExpectations(t)
+}
+
+func TestService_UnknownDomain(t *testing.T) {
+	repo := new(mocks.MockURLRepository)
+	s := newService(t, repo)
+	ctx := context.Background()
+
+	for _, domain := range []string{"unknown.com", "localhost:8080", "brand.link/x"} {
+		_, err := s.CreateOrGet(ctx, domain, "http://example.com")
+		require.ErrorIs(t, err, service.ErrUnknownDomain)
+		requireAppErr(t, err, apperr.CodeUnknownDomain, "domain")
+
+		_, err = s.GetLongURLByAlias(ctx, domain, "aa")
+		require.ErrorIs(t, err, service.ErrUnknownDomain)
+
+		_, err = s.Resolve(ctx, domain, "aa")
+		require.ErrorIs(t, err, service.ErrUnknownDomain)
+
+		require.ErrorIs(t, s.SetDisabled(ctx, domain, "aa", true), service.ErrUnknownDomain)
+		require.ErrorIs(t, s.Delete(ctx, domain, "aa"), service.ErrUnknownDomain)
+	}
+
+	repo.AssertExpectations(t)
+}
+
 func TestService_CreateOrGet_InvalidInput(t *testing.T) {
-	t.Run("invalid input error", func(t *testing.T) {
-		repo := new(mocks.MockURLRepository)
+	cases := []struct {
+		name     string
+		longURL  string
+		wantCode apperr.Code
+	}{
+		{"no scheme", "noturl", apperr.CodeInvalidURLScheme},
+		{"empty", "", apperr.CodeInvalidURL},
+	}
 
-		s := newService(t, repo)
-		got, err := s.CreateOrGet(context.Background(), "noturl")
-		require.Error(t, err)
-		require.Zero(t, got)
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mocks.MockURLRepository)
 
-		repo.AssertNotCalled(t, "CreateOrGet", mock.Anything, mock.Anything)
-	})
+			s := newService(t, repo)
+			got, err := s.CreateOrGet(context.Background(), "", tc.longURL)
+			require.ErrorIs(t, err, service.ErrInvalidInput)
+			requireAppErr(t, err, tc.wantCode, "long_url")
+			require.Zero(t, got)
+
+			repo.AssertNotCalled(t, "CreateOrGet", mock.Anything, mock.Anything)
+		})
+	}
 }
 
 func TestService_CreateOrGet_Conflict(t *testing.T) {
@@ -134,14 +243,173 @@ func TestService_CreateOrGet_Conflict(t *testing.T) {
 			u.Alias != "" &&
 			u.CreatedAt.IsZero()
 	})).
-		Return(nil, repository.ErrConflict).
+		Return(nil, false, repository.ErrAliasConflict).
+		Times(testRetry.MaxAttempts)
+
+	s := newService(t, repo)
+
+	gotAlias, err := s.CreateOrGet(context.Background(), "", "http://example.com")
+
+	require.ErrorIs(t, err, service.ErrConflict)
+	requireAppErr(t, err, apperr.CodeAliasExhausted, "")
+	require.Zero(t, gotAlias)
+
+	m := s.Metrics()
+	require.EqualValues(t, testRetry.MaxAttempts, m.AliasCollisions)
+	require.EqualValues(t, testRetry.MaxAttempts-1, m.AliasRetries)
+	require.EqualValues(t, 1, m.AliasRetriesExhausted)
+
+	repo.AssertExpectations(t)
+}
+
+func TestService_CreateOrGet_IDConflictRetried(t *testing.T) {
+	repo := new(mocks.MockURLRepository)
+
+	repo.On("CreateOrGet", mock.Anything, mock.Anything).
+		Return(nil, false, repository.ErrIDConflict).
+		Once()
+	repo.On("CreateOrGet", mock.Anything, mock.Anything).
+		Return(&model.URL{ID: 2, LongURL: "http://example.com", Alias: "bb"}, true, nil).
+		Once()
+
+	s := newService(t, repo)
+
+	got, err := s.CreateOrGet(context.Background(), "", "http://example.com")
+
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:8080/bb", got)
+	require.EqualValues(t, 1, s.Metrics().AliasRetries)
+
+	repo.AssertExpectations(t)
+}
+
+func TestService_CreateOrGet_LongURLConflictNotRetried(t *testing.T) {
+	repo := new(mocks.MockURLRepository)
+
+	repo.On("CreateOrGet", mock.Anything, mock.Anything).
+		Return(nil, false, repository.ErrLongURLConflict).
 		Once()
 
 	s := newService(t, repo)
 
-	gotAlias, err := s.CreateOrGet(context.Background(), "http://example.com")
+	gotAlias, err := s.CreateOrGet(context.Background(), "", "http://example.com")
 
 	require.ErrorIs(t, err, service.ErrConflict)
+	requireAppErr(t, err, apperr.CodeLongURLConflict, "long_url")
+	require.Zero(t, gotAlias)
+	require.Zero(t, s.Metrics().AliasRetries)
+
+	repo.AssertExpectations(t)
+}
+
+func TestService_CreateOrGet_RetryCtxCanceled(t *testing.T) {
+	repo := new(mocks.MockURLRepository)
+
+	repo.On("CreateOrGet", mock.Anything, mock.Anything).
+		Return(nil, false, repository.ErrAliasConflict).
+		Once()
+
+	gen, err := generator.NewRandom(generator.DefaultLength)
+	require.NoError(t, err)
+
+	s, err := NewService(newTestDomains(t), gen, repo, RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     time.Hour,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	gotAlias, err := s.CreateOrGet(ctx, "", "http://example.com")
+
+	require.ErrorIs(t, err, context.Canceled)
+	require.Zero(t, gotAlias)
+
+	repo.AssertExpectations(t)
+}
+
+func TestRetryDelay(t *testing.T) {
+	cases := []struct {
+		name    string
+		backoff time.Duration
+		attempt int
+		want    time.Duration
+	}{
+		{"first retry", 10 * time.Millisecond, 1, 10 * time.Millisecond},
+		{"doubled", 10 * time.Millisecond, 3, 40 * time.Millisecond},
+		{"capped", 10 * time.Millisecond, 30, maxRetryBackoff},
+		{"overflow", time.Hour, 63, maxRetryBackoff},
+		{"large base", time.Hour, 1, maxRetryBackoff},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, retryDelay(tc.backoff, tc.attempt))
+		})
+	}
+}
+
+func TestNewService_InvalidRetryPolicy(t *testing.T) {
+	gen, err := generator.NewRandom(generator.DefaultLength)
+	require.NoError(t, err)
+
+	s, err := NewService(newTestDomains(t), gen, new(mocks.MockURLRepository), RetryPolicy{})
+	require.Error(t, err)
+	require.Nil(t, s)
+}
+
+func TestService_CreateOrGet_RetryAfterConflict(t *testing.T) {
+	repo := new(mocks.MockURLRepository)
+
+	var aliases []string
+	isCandidate := mock.MatchedBy(func(u *model.URL) bool {
+		return u != nil && u.LongURL == "http://example.com" && u.Alias != ""
+	})
+
+	repo.On("CreateOrGet", mock.Anything, isCandidate).
+		Run(func(args mock.Arguments) {
+			aliases = append(aliases, args.Get(1).(*model.URL).Alias)
+		}).
+		Return(nil, false, repository.ErrAliasConflict).
+		Once()
+	repo.On("CreateOrGet", mock.Anything, isCandidate).
+		Run(func(args mock.Arguments) {
+			aliases = append(aliases, args.Get(1).(*model.URL).Alias)
+		}).
+		Return(&model.URL{
+			Alias: "bb",
+		}, true, nil).
+		Once()
+
+	s := newService(t, repo)
+
+	got, err := s.CreateOrGet(context.Background(), "", "http://example.com")
+
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:8080/bb", got)
+	require.Len(t, aliases, 2)
+	require.NotEqual(t, aliases[0], aliases[1], "retry must use a fresh alias")
+	require.EqualValues(t, 1, s.Metrics().AliasRetries)
+	require.EqualValues(t, 1, s.Metrics().Created)
+
+	repo.AssertExpectations(t)
+}
+
+func TestService_CreateOrGet_AliasSpaceExhausted(t *testing.T) {
+	repo := new(mocks.MockURLRepository)
+
+	// Алиасов длины 1 ровно len(alphabet) = 63, ID 63 в них уже не помещается
+	gen, err := generator.NewCounter(generator.NewLocalAllocator(62), 1, 1)
+	require.NoError(t, err)
+
+	s, err := NewService(newTestDomains(t), gen, repo, testRetry)
+	require.NoError(t, err)
+
+	gotAlias, err := s.CreateOrGet(context.Background(), "", "http://example.com")
+
+	require.ErrorIs(t, err, service.ErrUnavailable)
+	requireAppErr(t, err, apperr.CodeAliasSpaceExhausted, "")
 	require.Zero(t, gotAlias)
 
 	repo.AssertExpectations(t)
@@ -157,14 +425,294 @@ func TestService_CreateOrGet_UnexpectedRepoError(t *testing.T) {
 			u.Alias != "" &&
 			u.CreatedAt.IsZero()
 	})).
-		Return((*model.URL)(nil), errors.New("db down")).
+		Return((*model.URL)(nil), false, errors.New("db down")).
 		Once()
 
 	s := newService(t, repo)
 
-	gotAlias, err := s.CreateOrGet(context.Background(), "http://example.com")
+	gotAlias, err := s.CreateOrGet(context.Background(), "", "http://example.com")
 	require.ErrorIs(t, err, service.ErrInternalError)
 	require.Zero(t, gotAlias)
 
 	repo.AssertExpectations(t)
 }
+
+func TestService_GetLongURLByAlias_Disabled(t *testing.T) {
+	repo := new(mocks.MockURLRepository)
+
+	repo.On("GetLongURLByAlias", mock.Anything, "", "aa").
+		Return("", repository.ErrDisabled).
+		Once()
+
+	s := newService(t, repo)
+
+	got, err := s.GetLongURLByAlias(context.Background(), "", "aa")
+	require.ErrorIs(t, err, service.ErrDisabled)
+	require.Zero(t, got)
+
+	repo.AssertExpectations(t)
+}
+
+func TestService_Resolve(t *testing.T) {
+	cases := []struct {
+		name      string
+		repoRet   *model.URL
+		repoErr   error
+		wantErrIs error
+	}{
+		{
+			name:    "success",
+			repoRet: &model.URL{ID: 1, LongURL: "http://example.com", Alias: "aa", Disabled: true},
+		},
+		{
+			name:      "not found",
+			repoErr:   repository.ErrNotFound,
+			wantErrIs: service.ErrNotFound,
+		},
+		{
+			name:      "unexpected error",
+			repoErr:   errors.New("some error"),
+			wantErrIs: service.ErrInternalError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mocks.MockURLRepository)
+			repo.On("GetByAlias", mock.Anything, "", "aa").
+				Return(tc.repoRet, tc.repoErr).
+				Once()
+
+			s := newService(t, repo)
+
+			got, err := s.Resolve(context.Background(), "", "aa")
+			if tc.wantErrIs != nil {
+				require.ErrorIs(t, err, tc.wantErrIs)
+				require.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.repoRet, got)
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Follow(t *testing.T) {
+	cases := []struct {
+		name      string
+		repoRet   *model.URL
+		repoErr   error
+		wantErrIs error
+	}{
+		{
+			name:    "success",
+			repoRet: &model.URL{ID: 1, LongURL: "http://example.com", Alias: "aa", Interstitial: true},
+		},
+		{
+			name:      "disabled",
+			repoRet:   &model.URL{ID: 1, LongURL: "http://example.com", Alias: "aa", Disabled: true},
+			wantErrIs: service.ErrDisabled,
+		},
+		{
+			name:      "not found",
+			repoErr:   repository.ErrNotFound,
+			wantErrIs: service.ErrNotFound,
+		},
+		{
+			name:      "unexpected error",
+			repoErr:   errors.New("some error"),
+			wantErrIs: service.ErrInternalError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mocks.MockURLRepository)
+			repo.On("GetByAlias", mock.Anything, "brand.link", "aa").
+				Return(tc.repoRet, tc.repoErr).
+				Once()
+
+			s := newService(t, repo)
+
+			got, err := s.Follow(context.Background(), "Brand.Link", "aa")
+			if tc.wantErrIs != nil {
+				require.ErrorIs(t, err, tc.wantErrIs)
+				require.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.repoRet, got)
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Click(t *testing.T) {
+	cases := []struct {
+		name       string
+		thresholds []int64
+		clickErr   error
+	}{
+		{
+			name:       "counted",
+			thresholds: []int64{10, 100},
+		},
+		{
+			// Ошибка подсчёта не мешает переходу
+			name:       "count failed",
+			thresholds: []int64{10, 100},
+			clickErr:   errors.New("some error"),
+		},
+		{
+			name: "no thresholds",
+		},
+	}
+
+	u := &model.URL{ID: 1, Domain: "brand.link", LongURL: "http://example.com", Alias: "aa"}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := mocks.NewMockURLRepository(t)
+			if len(tc.thresholds) > 0 {
+				repo.EXPECT().AddClick(mock.Anything, "brand.link", "aa", tc.thresholds).Return(10, tc.clickErr).Once()
+			}
+
+			s := newService(t, repo)
+			s.SetClickThresholds(tc.thresholds)
+
+			s.Click(context.Background(), u)
+		})
+	}
+}
+
+func TestService_Follow_NotCounted(t *testing.T) {
+	repo := mocks.NewMockURLRepository(t)
+	repo.EXPECT().GetByAlias(mock.Anything, "brand.link", "aa").
+		Return(&model.URL{ID: 1, Domain: "brand.link", LongURL: "http://example.com", Alias: "aa"}, nil).
+		Once()
+
+	s := newService(t, repo)
+	s.SetClickThresholds([]int64{10, 100})
+
+	// Переход засчитывает только Click, мок упадёт на неожиданном AddClick
+	_, err := s.Follow(context.Background(), "Brand.Link", "aa")
+	require.NoError(t, err)
+}
+
+func TestService_List(t *testing.T) {
+	repo := new(mocks.MockURLRepository)
+
+	want := []*model.URL{{ID: 3, Alias: "aa"}, {ID: 4, Alias: "bb"}}
+	repo.On("List", mock.Anything, repository.ListFilter{AfterID: 2, Limit: 10, Broken: true}).
+		Return(want, nil).
+		Once()
+
+	s := newService(t, repo)
+
+	got, err := s.List(context.Background(), repository.ListFilter{AfterID: 2, Limit: 10, Broken: true})
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	_, err = s.List(context.Background(), repository.ListFilter{})
+	require.ErrorIs(t, err, service.ErrInvalidInput)
+
+	repo.AssertExpectations(t)
+}
+
+func TestService_SetDisabled(t *testing.T) {
+	cases := []struct {
+		name      string
+		repoErr   error
+		wantErrIs error
+	}{
+		{"success", nil, nil},
+		{"not found", repository.ErrNotFound, service.ErrNotFound},
+		{"unexpected error", errors.New("some error"), service.ErrInternalError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mocks.MockURLRepository)
+			repo.On("SetDisabled", mock.Anything, "", "aa", true).
+				Return(tc.repoErr).
+				Once()
+
+			s := newService(t, repo)
+
+			err := s.SetDisabled(context.Background(), "", "aa", true)
+			if tc.wantErrIs != nil {
+				require.ErrorIs(t, err, tc.wantErrIs)
+			} else {
+				require.NoError(t, err)
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_SetInterstitial(t *testing.T) {
+	cases := []struct {
+		name      string
+		repoErr   error
+		wantErrIs error
+	}{
+		{"success", nil, nil},
+		{"not found", repository.ErrNotFound, service.ErrNotFound},
+		{"unexpected error", errors.New("some error"), service.ErrInternalError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mocks.MockURLRepository)
+			repo.On("SetInterstitial", mock.Anything, "", "aa", true).
+				Return(tc.repoErr).
+				Once()
+
+			s := newService(t, repo)
+
+			err := s.SetInterstitial(context.Background(), "", "aa", true)
+			if tc.wantErrIs != nil {
+				require.ErrorIs(t, err, tc.wantErrIs)
+			} else {
+				require.NoError(t, err)
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Delete(t *testing.T) {
+	cases := []struct {
+		name      string
+		repoErr   error
+		wantErrIs error
+	}{
+		{"success", nil, nil},
+		{"not found", repository.ErrNotFound, service.ErrNotFound},
+		{"unexpected error", errors.New("some error"), service.ErrInternalError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(mocks.MockURLRepository)
+			repo.On("Delete", mock.Anything, "", "aa").
+				Return(tc.repoErr).
+				Once()
+
+			s := newService(t, repo)
+
+			err := s.Delete(context.Background(), "", "aa")
+			if tc.wantErrIs != nil {
+				require.ErrorIs(t, err, tc.wantErrIs)
+			} else {
+				require.NoError(t, err)
+			}
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package webhook
+
+import "sync/atomic"
+
+// Metrics — счётчики вебхуков, публикуются через expvar.
+type Metrics struct {
+	// Dispatched — количество событий, разложенных из outbox по подпискам.
+	Dispatched int64 `json:"dispatched"`
+	// Delivered — количество успешных доставок.
+	Delivered int64 `json:"delivered"`
+	// Failed — количество неудачных попыток доставки.
+	Failed int64 `json:"failed"`
+	// Dead — количество доставок, исчерпавших попытки.
+	Dead int64 `json:"dead"`
+	// Replayed — количество доставок, повторённых вручную.
+	Replayed int64 `json:"replayed"`
+}
+
+type metrics struct {
+	dispatched atomic.Int64
+	delivered  atomic.Int64
+	failed     atomic.Int64
+	dead       atomic.Int64
+	replayed   atomic.Int64
+}
+
+func (m *metrics) snapshot() Metrics {
+	return Metrics{
+		Dispatched: m.dispatched.Load(),
+		Delivered:  m.delivered.Load(),
+		Failed:     m.failed.Load(),
+		Dead:       m.dead.Load(),
+		Replayed:   m.replayed.Load(),
+	}
+}
@@ -0,0 +1,434 @@
+// Code generated by mockery; DO NOT EDIT.
+// github.com/vektra/mockery
+// template: testify
+
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// NewMockRepository creates a new instance of MockRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockRepository {
+	mock := &MockRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockRepository is an autogenerated mock type for the Repository type
+type MockRepository struct {
+	mock.Mock
+}
+
+type MockRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockRepository) EXPECT() *MockRepository_Expecter {
+	return &MockRepository_Expecter{mock: &_m.Mock}
+}
+
+// ClaimDeliveries provides a mock function for the type MockRepository
+func (_mock *MockRepository) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.Delivery, error) {
+	ret := _mock.Called(ctx, now, lease, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimDeliveries")
+	}
+
+	var r0 []*model.Delivery
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, time.Time, time.Duration, int) ([]*model.Delivery, error)); ok {
+		return returnFunc(ctx, now, lease, limit)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, time.Time, time.Duration, int) []*model.Delivery); ok {
+		r0 = returnFunc(ctx, now, lease, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Delivery)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, time.Time, time.Duration, int) error); ok {
+		r1 = returnFunc(ctx, now, lease, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockRepository_ClaimDeliveries_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClaimDeliveries'
+type MockRepository_ClaimDeliveries_Call struct {
+	*mock.Call
+}
+
+// ClaimDeliveries is a helper method to define mock.On call
+//   - ctx context.Context
+//   - now time.Time
+//   - lease time.Duration
+//   - limit int
+func (_e *MockRepository_Expecter) ClaimDeliveries(ctx interface{}, now interface{}, lease interface{}, limit interface{}) *MockRepository_ClaimDeliveries_Call {
+	return &MockRepository_ClaimDeliveries_Call{Call: _e.mock.On("ClaimDeliveries", ctx, now, lease, limit)}
+}
+
+func (_c *MockRepository_ClaimDeliveries_Call) Run(run func(ctx context.Context, now time.Time, lease time.Duration, limit int)) *MockRepository_ClaimDeliveries_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 time.Time
+		if args[1] != nil {
+			arg1 = args[1].(time.Time)
+		}
+		var arg2 time.Duration
+		if args[2] != nil {
+			arg2 = args[2].(time.Duration)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRepository_ClaimDeliveries_Call) Return(deliverys []*model.Delivery, err error) *MockRepository_ClaimDeliveries_Call {
+	_c.Call.Return(deliverys, err)
+	return _c
+}
+
+func (_c *MockRepository_ClaimDeliveries_Call) RunAndReturn(run func(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.Delivery, error)) *MockRepository_ClaimDeliveries_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteDelivery provides a mock function for the type MockRepository
+func (_mock *MockRepository) DeleteDelivery(ctx context.Context, id int64) error {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteDelivery")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockRepository_DeleteDelivery_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteDelivery'
+type MockRepository_DeleteDelivery_Call struct {
+	*mock.Call
+}
+
+// DeleteDelivery is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int64
+func (_e *MockRepository_Expecter) DeleteDelivery(ctx interface{}, id interface{}) *MockRepository_DeleteDelivery_Call {
+	return &MockRepository_DeleteDelivery_Call{Call: _e.mock.On("DeleteDelivery", ctx, id)}
+}
+
+func (_c *MockRepository_DeleteDelivery_Call) Run(run func(ctx context.Context, id int64)) *MockRepository_DeleteDelivery_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int64
+		if args[1] != nil {
+			arg1 = args[1].(int64)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRepository_DeleteDelivery_Call) Return(err error) *MockRepository_DeleteDelivery_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockRepository_DeleteDelivery_Call) RunAndReturn(run func(ctx context.Context, id int64) error) *MockRepository_DeleteDelivery_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Dispatch provides a mock function for the type MockRepository
+func (_mock *MockRepository) Dispatch(ctx context.Context, eventID int64, deliveries []*model.Delivery) error {
+	ret := _mock.Called(ctx, eventID, deliveries)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Dispatch")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int64, []*model.Delivery) error); ok {
+		r0 = returnFunc(ctx, eventID, deliveries)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockRepository_Dispatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Dispatch'
+type MockRepository_Dispatch_Call struct {
+	*mock.Call
+}
+
+// Dispatch is a helper method to define mock.On call
+//   - ctx context.Context
+//   - eventID int64
+//   - deliveries []*model.Delivery
+func (_e *MockRepository_Expecter) Dispatch(ctx interface{}, eventID interface{}, deliveries interface{}) *MockRepository_Dispatch_Call {
+	return &MockRepository_Dispatch_Call{Call: _e.mock.On("Dispatch", ctx, eventID, deliveries)}
+}
+
+func (_c *MockRepository_Dispatch_Call) Run(run func(ctx context.Context, eventID int64, deliveries []*model.Delivery)) *MockRepository_Dispatch_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int64
+		if args[1] != nil {
+			arg1 = args[1].(int64)
+		}
+		var arg2 []*model.Delivery
+		if args[2] != nil {
+			arg2 = args[2].([]*model.Delivery)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRepository_Dispatch_Call) Return(err error) *MockRepository_Dispatch_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockRepository_Dispatch_Call) RunAndReturn(run func(ctx context.Context, eventID int64, deliveries []*model.Delivery) error) *MockRepository_Dispatch_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Events provides a mock function for the type MockRepository
+func (_mock *MockRepository) Events(ctx context.Context, limit int) ([]*model.Event, error) {
+	ret := _mock.Called(ctx, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Events")
+	}
+
+	var r0 []*model.Event
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int) ([]*model.Event, error)); ok {
+		return returnFunc(ctx, limit)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int) []*model.Event); ok {
+		r0 = returnFunc(ctx, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Event)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = returnFunc(ctx, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockRepository_Events_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Events'
+type MockRepository_Events_Call struct {
+	*mock.Call
+}
+
+// Events is a helper method to define mock.On call
+//   - ctx context.Context
+//   - limit int
+func (_e *MockRepository_Expecter) Events(ctx interface{}, limit interface{}) *MockRepository_Events_Call {
+	return &MockRepository_Events_Call{Call: _e.mock.On("Events", ctx, limit)}
+}
+
+func (_c *MockRepository_Events_Call) Run(run func(ctx context.Context, limit int)) *MockRepository_Events_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int
+		if args[1] != nil {
+			arg1 = args[1].(int)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRepository_Events_Call) Return(events []*model.Event, err error) *MockRepository_Events_Call {
+	_c.Call.Return(events, err)
+	return _c
+}
+
+func (_c *MockRepository_Events_Call) RunAndReturn(run func(ctx context.Context, limit int) ([]*model.Event, error)) *MockRepository_Events_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListDeliveries provides a mock function for the type MockRepository
+func (_mock *MockRepository) ListDeliveries(ctx context.Context, f repository.DeliveryFilter) ([]*model.Delivery, error) {
+	ret := _mock.Called(ctx, f)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListDeliveries")
+	}
+
+	var r0 []*model.Delivery
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, repository.DeliveryFilter) ([]*model.Delivery, error)); ok {
+		return returnFunc(ctx, f)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, repository.DeliveryFilter) []*model.Delivery); ok {
+		r0 = returnFunc(ctx, f)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Delivery)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, repository.DeliveryFilter) error); ok {
+		r1 = returnFunc(ctx, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockRepository_ListDeliveries_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListDeliveries'
+type MockRepository_ListDeliveries_Call struct {
+	*mock.Call
+}
+
+// ListDeliveries is a helper method to define mock.On call
+//   - ctx context.Context
+//   - f repository.DeliveryFilter
+func (_e *MockRepository_Expecter) ListDeliveries(ctx interface{}, f interface{}) *MockRepository_ListDeliveries_Call {
+	return &MockRepository_ListDeliveries_Call{Call: _e.mock.On("ListDeliveries", ctx, f)}
+}
+
+func (_c *MockRepository_ListDeliveries_Call) Run(run func(ctx context.Context, f repository.DeliveryFilter)) *MockRepository_ListDeliveries_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 repository.DeliveryFilter
+		if args[1] != nil {
+			arg1 = args[1].(repository.DeliveryFilter)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRepository_ListDeliveries_Call) Return(deliverys []*model.Delivery, err error) *MockRepository_ListDeliveries_Call {
+	_c.Call.Return(deliverys, err)
+	return _c
+}
+
+func (_c *MockRepository_ListDeliveries_Call) RunAndReturn(run func(ctx context.Context, f repository.DeliveryFilter) ([]*model.Delivery, error)) *MockRepository_ListDeliveries_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateDelivery provides a mock function for the type MockRepository
+func (_mock *MockRepository) UpdateDelivery(ctx context.Context, d *model.Delivery) error {
+	ret := _mock.Called(ctx, d)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateDelivery")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *model.Delivery) error); ok {
+		r0 = returnFunc(ctx, d)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockRepository_UpdateDelivery_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateDelivery'
+type MockRepository_UpdateDelivery_Call struct {
+	*mock.Call
+}
+
+// UpdateDelivery is a helper method to define mock.On call
+//   - ctx context.Context
+//   - d *model.Delivery
+func (_e *MockRepository_Expecter) UpdateDelivery(ctx interface{}, d interface{}) *MockRepository_UpdateDelivery_Call {
+	return &MockRepository_UpdateDelivery_Call{Call: _e.mock.On("UpdateDelivery", ctx, d)}
+}
+
+func (_c *MockRepository_UpdateDelivery_Call) Run(run func(ctx context.Context, d *model.Delivery)) *MockRepository_UpdateDelivery_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *model.Delivery
+		if args[1] != nil {
+			arg1 = args[1].(*model.Delivery)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRepository_UpdateDelivery_Call) Return(err error) *MockRepository_UpdateDelivery_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockRepository_UpdateDelivery_Call) RunAndReturn(run func(ctx context.Context, d *model.Delivery) error) *MockRepository_UpdateDelivery_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -0,0 +1,173 @@
+// Package webhook рассылает события ссылок подписчикам: раскладывает события из
+// outbox по подпискам, доставляет их с HMAC-подписью и повторяет неудачные попытки.
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	"github.com/Rasulikus/url-shortener/internal/service"
+	"github.com/rs/zerolog/log"
+)
+
+// MaxDeliveriesLimit — наибольший размер страницы доставок.
+const MaxDeliveriesLimit = 1000
+
+type Repository interface {
+	// Events возвращает до limit самых старых событий из outbox.
+	Events(ctx context.Context, limit int) ([]*model.Event, error)
+
+	// Dispatch удаляет событие из outbox и сохраняет его доставки одной транзакцией,
+	// ID доставок выбирает хранилище. Если события уже нет, например его разослал
+	// другой экземпляр, возвращает ErrNotFound.
+	Dispatch(ctx context.Context, eventID int64, deliveries []*model.Delivery) error
+
+	// ClaimDeliveries возвращает до limit ожидающих доставок, попытка которых назначена
+	// не позже now, и переносит их попытку на now+lease, чтобы их не взял другой экземпляр.
+	ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.Delivery, error)
+
+	// UpdateDelivery сохраняет Status, Attempts, NextAttemptAt и LastError доставки.
+	// Если доставка не найдена, возвращает ErrNotFound.
+	UpdateDelivery(ctx context.Context, d *model.Delivery) error
+
+	// DeleteDelivery удаляет доставку.
+	// Если доставка не найдена, возвращает ErrNotFound.
+	DeleteDelivery(ctx context.Context, id int64) error
+
+	// ListDeliveries возвращает страницу доставок, упорядоченных по ID.
+	ListDeliveries(ctx context.Context, f repository.DeliveryFilter) ([]*model.Delivery, error)
+}
+
+// Config задаёт подписки и доставку событий.
+type Config struct {
+	Endpoints []model.Webhook
+	// Secret — ключ HMAC-подписи запросов.
+	Secret string
+	// PollInterval — как часто проверять outbox и очередь доставок.
+	PollInterval time.Duration
+	// Workers — количество одновременных запросов.
+	Workers int
+	// Timeout ограничивает один запрос к подписчику.
+	Timeout time.Duration
+	// MaxAttempts — после скольких неудачных попыток доставка переходит в dead.
+	MaxAttempts int
+	// Backoff — пауза перед второй попыткой, удваивается с каждой следующей.
+	Backoff time.Duration
+}
+
+type Service struct {
+	repo      Repository
+	cfg       Config
+	endpoints map[string]model.Webhook
+	client    *http.Client
+	metrics   metrics
+}
+
+func NewService(repo Repository, cfg Config) (*Service, error) {
+	if repo == nil {
+		return nil, errors.New("webhook service: repository is nil")
+	}
+	if cfg.PollInterval <= 0 {
+		return nil, fmt.Errorf("webhook service: poll interval must be positive: %s", cfg.PollInterval)
+	}
+	if cfg.Workers <= 0 {
+		return nil, fmt.Errorf("webhook service: workers must be positive: %d", cfg.Workers)
+	}
+	if cfg.MaxAttempts <= 0 {
+		return nil, fmt.Errorf("webhook service: max attempts must be positive: %d", cfg.MaxAttempts)
+	}
+
+	endpoints := make(map[string]model.Webhook, len(cfg.Endpoints))
+	for _, e := range cfg.Endpoints {
+		if _, ok := endpoints[e.Name]; ok {
+			return nil, fmt.Errorf("webhook service: duplicate webhook %q", e.Name)
+		}
+		endpoints[e.Name] = e
+	}
+	if len(endpoints) > 0 && cfg.Secret == "" {
+		return nil, errors.New("webhook service: secret is empty")
+	}
+
+	return &Service{
+		repo:      repo,
+		cfg:       cfg,
+		endpoints: endpoints,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}, nil
+}
+
+// Endpoints возвращает подписки в порядке конфигурации.
+func (s *Service) Endpoints() []model.Webhook {
+	return s.cfg.Endpoints
+}
+
+// Metrics возвращает текущие значения счётчиков.
+func (s *Service) Metrics() Metrics {
+	return s.metrics.snapshot()
+}
+
+// Deliveries возвращает до f.Limit доставок с ID больше f.AfterID, подходящих под фильтр.
+func (s *Service) Deliveries(ctx context.Context, f repository.DeliveryFilter) ([]*model.Delivery, error) {
+	if f.Limit <= 0 || f.Limit > MaxDeliveriesLimit {
+		return nil, service.InvalidParam("limit", fmt.Sprintf("must be between 1 and %d", MaxDeliveriesLimit))
+	}
+	if f.AfterID < 0 {
+		return nil, service.InvalidParam("after_id", "must not be negative")
+	}
+	switch f.Status {
+	case "", model.DeliveryPending, model.DeliveryDead:
+	default:
+		return nil, service.InvalidParam("status", fmt.Sprintf("must be %s or %s", model.DeliveryPending, model.DeliveryDead))
+	}
+
+	list, err := s.repo.ListDeliveries(ctx, f)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Int64("after_id", f.AfterID).
+			Msg("failed to list webhook deliveries")
+
+		return nil, service.ErrInternalError
+	}
+
+	return list, nil
+}
+
+// Replay возвращает доставку в очередь: счётчик попыток сбрасывается, первая
+// попытка выполняется при ближайшем опросе.
+func (s *Service) Replay(ctx context.Context, id int64) error {
+	err := s.repo.UpdateDelivery(ctx, &model.Delivery{
+		ID:            id,
+		Status:        model.DeliveryPending,
+		NextAttemptAt: time.Now().UTC(),
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return service.ErrNotFound
+		}
+		log.Error().
+			Err(err).
+			Int64("delivery_id", id).
+			Msg("failed to replay webhook delivery")
+
+		return service.ErrInternalError
+	}
+
+	s.metrics.replayed.Add(1)
+
+	log.Info().
+		Int64("delivery_id", id).
+		Msg("webhook delivery replayed")
+
+	return nil
+}
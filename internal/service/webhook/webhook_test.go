@@ -0,0 +1,135 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	"github.com/Rasulikus/url-shortener/internal/service"
+	"github.com/Rasulikus/url-shortener/internal/service/webhook/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig(endpoints ...model.Webhook) Config {
+	return Config{
+		Endpoints:    endpoints,
+		Secret:       "secret",
+		PollInterval: time.Second,
+		Workers:      2,
+		Timeout:      time.Second,
+		MaxAttempts:  3,
+		Backoff:      time.Minute,
+	}
+}
+
+func TestNewService(t *testing.T) {
+	cases := []struct {
+		name    string
+		modify  func(cfg *Config)
+		wantErr bool
+	}{
+		{"ok", func(*Config) {}, false},
+		{"no endpoints without secret", func(cfg *Config) { cfg.Endpoints, cfg.Secret = nil, "" }, false},
+		{"zero workers", func(cfg *Config) { cfg.Workers = 0 }, true},
+		{"zero poll interval", func(cfg *Config) { cfg.PollInterval = 0 }, true},
+		{"zero max attempts", func(cfg *Config) { cfg.MaxAttempts = 0 }, true},
+		{"empty secret", func(cfg *Config) { cfg.Secret = "" }, true},
+		{"duplicate name", func(cfg *Config) {
+			cfg.Endpoints = append(cfg.Endpoints, model.Webhook{Name: "crm", URL: "https://other.com"})
+		}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testConfig(model.Webhook{Name: "crm", URL: "https://crm.com"})
+			tc.modify(&cfg)
+
+			_, err := NewService(mocks.NewMockRepository(t), cfg)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestService_Deliveries(t *testing.T) {
+	cases := []struct {
+		name    string
+		filter  repository.DeliveryFilter
+		repoErr error
+		wantErr error
+	}{
+		{"ok", repository.DeliveryFilter{Limit: 10, Status: model.DeliveryDead}, nil, nil},
+		{"all statuses", repository.DeliveryFilter{AfterID: 5, Limit: 10}, nil, nil},
+		{"zero limit", repository.DeliveryFilter{}, nil, service.ErrInvalidInput},
+		{"max limit", repository.DeliveryFilter{Limit: MaxDeliveriesLimit}, nil, nil},
+		{"limit too large", repository.DeliveryFilter{Limit: MaxDeliveriesLimit + 1}, nil, service.ErrInvalidInput},
+		{"negative after id", repository.DeliveryFilter{AfterID: -1, Limit: 10}, nil, service.ErrInvalidInput},
+		{"unknown status", repository.DeliveryFilter{Limit: 10, Status: "done"}, nil, service.ErrInvalidInput},
+		{"repository error", repository.DeliveryFilter{Limit: 10}, errors.New("db down"), service.ErrInternalError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := mocks.NewMockRepository(t)
+			s, err := NewService(repo, testConfig())
+			require.NoError(t, err)
+
+			want := []*model.Delivery{{ID: 7}}
+			if tc.wantErr == nil || tc.repoErr != nil {
+				repo.EXPECT().ListDeliveries(mock.Anything, tc.filter).Return(want, tc.repoErr).Once()
+			}
+
+			got, err := s.Deliveries(context.Background(), tc.filter)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestService_Replay(t *testing.T) {
+	cases := []struct {
+		name    string
+		repoErr error
+		wantErr error
+	}{
+		{"ok", nil, nil},
+		{"not found", repository.ErrNotFound, service.ErrNotFound},
+		{"repository error", errors.New("db down"), service.ErrInternalError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := mocks.NewMockRepository(t)
+			s, err := NewService(repo, testConfig())
+			require.NoError(t, err)
+
+			repo.EXPECT().UpdateDelivery(mock.Anything, mock.MatchedBy(func(d *model.Delivery) bool {
+				return d.ID == 7 &&
+					d.Status == model.DeliveryPending &&
+					d.Attempts == 0 &&
+					time.Since(d.NextAttemptAt) < time.Minute
+			})).Return(tc.repoErr).Once()
+
+			err = s.Replay(context.Background(), 7)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				assert.Zero(t, s.Metrics().Replayed)
+				return
+			}
+			require.NoError(t, err)
+			assert.EqualValues(t, 1, s.Metrics().Replayed)
+		})
+	}
+}
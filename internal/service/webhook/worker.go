@@ -0,0 +1,285 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// Заголовки запроса к подписчику.
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+
+	userAgent = "url-shortener-webhook/1.0"
+
+	// batchSize — сколько событий outbox читается за раз.
+	batchSize = 100
+	// maxBackoff ограничивает паузу между попытками.
+	maxBackoff = 6 * time.Hour
+	// maxErrorBody — сколько байт ответа подписчика попадает в LastError.
+	maxErrorBody = 256
+)
+
+// Start запускает фоновую рассылку: раз в PollInterval события из outbox
+// раскладываются по подпискам, а доставки, которым пора, отправляются.
+// Возвращаемая функция останавливает рассылку и дожидается текущих запросов.
+func (s *Service) Start() (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.run(ctx)
+	}()
+
+	log.Info().
+		Int("webhooks", len(s.cfg.Endpoints)).
+		Dur("poll_interval", s.cfg.PollInterval).
+		Int("workers", s.cfg.Workers).
+		Int("max_attempts", s.cfg.MaxAttempts).
+		Msg("webhook delivery started")
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+func (s *Service) run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.dispatch(ctx)
+		s.deliver(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch раскладывает события из outbox по подпискам. Без подписок события
+// просто удаляются из outbox.
+func (s *Service) dispatch(ctx context.Context) {
+	for {
+		events, err := s.repo.Events(ctx, batchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Error().
+					Err(err).
+					Msg("failed to read webhook outbox")
+			}
+			return
+		}
+
+		for _, ev := range events {
+			err := s.repo.Dispatch(ctx, ev.ID, s.deliveries(ev))
+			if errors.Is(err, repository.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Error().
+						Err(err).
+						Int64("event_id", ev.ID).
+						Msg("failed to dispatch webhook event")
+				}
+				return
+			}
+			s.metrics.dispatched.Add(1)
+		}
+
+		if len(events) < batchSize {
+			return
+		}
+	}
+}
+
+// deliveries создаёт доставки события подписчикам.
+func (s *Service) deliveries(ev *model.Event) []*model.Delivery {
+	var (
+		now  = time.Now().UTC()
+		list []*model.Delivery
+	)
+	for _, e := range s.cfg.Endpoints {
+		if !e.Subscribed(ev.Type) {
+			continue
+		}
+		list = append(list, &model.Delivery{
+			Webhook:       e.Name,
+			Event:         ev,
+			Status:        model.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+	return list
+}
+
+// deliver отправляет доставки, которым пора, по Workers штук за раз.
+func (s *Service) deliver(ctx context.Context) {
+	// Аренда покрывает запрос с запасом: если экземпляр упадёт посреди попытки,
+	// доставку после неё возьмёт другой
+	lease := 2 * s.cfg.Timeout
+
+	for {
+		list, err := s.repo.ClaimDeliveries(ctx, time.Now().UTC(), lease, s.cfg.Workers)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Error().
+					Err(err).
+					Msg("failed to claim webhook deliveries")
+			}
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, d := range list {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.attempt(ctx, d)
+			}()
+		}
+		wg.Wait()
+
+		if len(list) < s.cfg.Workers || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// attempt выполняет одну попытку доставки и сохраняет её результат.
+func (s *Service) attempt(ctx context.Context, d *model.Delivery) {
+	err := s.send(ctx, d)
+	if ctx.Err() != nil {
+		// При остановке попытка не засчитывается, доставка вернётся после аренды
+		return
+	}
+
+	if err == nil {
+		s.metrics.delivered.Add(1)
+
+		if err := s.repo.DeleteDelivery(ctx, d.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			log.Error().
+				Err(err).
+				Int64("delivery_id", d.ID).
+				Msg("failed to delete delivered webhook")
+		}
+		return
+	}
+
+	s.metrics.failed.Add(1)
+
+	d.Attempts++
+	d.LastError = err.Error()
+	if d.Attempts >= s.cfg.MaxAttempts {
+		s.metrics.dead.Add(1)
+		d.Status = model.DeliveryDead
+
+		log.Warn().
+			Err(err).
+			Int64("delivery_id", d.ID).
+			Str("webhook", d.Webhook).
+			Int("attempts", d.Attempts).
+			Msg("webhook delivery moved to dead letters")
+	} else {
+		d.NextAttemptAt = time.Now().UTC().Add(backoff(s.cfg.Backoff, d.Attempts))
+
+		log.Debug().
+			Err(err).
+			Int64("delivery_id", d.ID).
+			Str("webhook", d.Webhook).
+			Int("attempts", d.Attempts).
+			Time("next_attempt_at", d.NextAttemptAt).
+			Msg("webhook delivery failed")
+	}
+
+	if err := s.repo.UpdateDelivery(ctx, d); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		log.Error().
+			Err(err).
+			Int64("delivery_id", d.ID).
+			Msg("failed to save webhook delivery")
+	}
+}
+
+// backoff возвращает паузу после attempts неудачных попыток.
+func backoff(base time.Duration, attempts int) time.Duration {
+	d := base << (attempts - 1)
+	if d <= 0 || d > maxBackoff || d>>(attempts-1) != base {
+		return maxBackoff
+	}
+	return d
+}
+
+// send отправляет событие подписчику. Успех — любой ответ 2xx, редиректы не выполняются.
+func (s *Service) send(ctx context.Context, d *model.Delivery) error {
+	e, ok := s.endpoints[d.Webhook]
+	if !ok {
+		return fmt.Errorf("webhook %q is not configured", d.Webhook)
+	}
+
+	body, err := json.Marshal(d.Event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderEvent, string(d.Event.Type))
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(s.cfg.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		if msg := strings.TrimSpace(string(b)); msg != "" {
+			return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, msg)
+		}
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign возвращает подпись запроса: "sha256=" и HMAC-SHA256 строки "timestamp.body" в hex.
+// Метка времени в подписи не даёт повторно отправить перехваченный запрос позже.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
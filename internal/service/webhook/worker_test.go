@@ -0,0 +1,237 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	"github.com/Rasulikus/url-shortener/internal/service/webhook/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent(t model.EventType) *model.Event {
+	return &model.Event{
+		ID:        1,
+		Type:      t,
+		URL:       model.EventURL{Alias: "aa", LongURL: "https://aa.com"},
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func TestSign(t *testing.T) {
+	// Значение посчитано независимо: printf '1700000000.{}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t,
+		"sha256=b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163",
+		Sign("secret", "1700000000", []byte("{}")),
+	)
+	assert.NotEqual(t, Sign("secret", "1700000000", []byte("{}")), Sign("secret", "1700000001", []byte("{}")))
+	assert.NotEqual(t, Sign("secret", "1700000000", []byte("{}")), Sign("other", "1700000000", []byte("{}")))
+}
+
+func TestBackoff(t *testing.T) {
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{20, maxBackoff},
+		{80, maxBackoff},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, backoff(30*time.Second, tc.attempts), "attempts %d", tc.attempts)
+	}
+}
+
+func TestService_Dispatch(t *testing.T) {
+	repo := mocks.NewMockRepository(t)
+	s, err := NewService(repo, testConfig(
+		model.Webhook{Name: "all", URL: "https://all.com"},
+		model.Webhook{Name: "clicks", URL: "https://clicks.com", Events: []model.EventType{model.EventClicks}},
+	))
+	require.NoError(t, err)
+
+	created, clicks, gone := testEvent(model.EventCreated), testEvent(model.EventClicks), testEvent(model.EventDeleted)
+	clicks.ID, gone.ID = 2, 3
+
+	names := func(list []*model.Delivery) []string {
+		var out []string
+		for _, d := range list {
+			out = append(out, d.Webhook)
+		}
+		return out
+	}
+
+	repo.EXPECT().Events(mock.Anything, batchSize).Return([]*model.Event{created, clicks, gone}, nil).Once()
+	repo.EXPECT().Dispatch(mock.Anything, int64(1), mock.MatchedBy(func(list []*model.Delivery) bool {
+		return assert.ObjectsAreEqual([]string{"all"}, names(list))
+	})).Return(nil).Once()
+	repo.EXPECT().Dispatch(mock.Anything, int64(2), mock.MatchedBy(func(list []*model.Delivery) bool {
+		return assert.ObjectsAreEqual([]string{"all", "clicks"}, names(list)) &&
+			list[0].Status == model.DeliveryPending && list[0].Event == clicks
+	})).Return(nil).Once()
+	// Событие уже разослал другой экземпляр
+	repo.EXPECT().Dispatch(mock.Anything, int64(3), mock.Anything).Return(repository.ErrNotFound).Once()
+
+	s.dispatch(context.Background())
+
+	assert.EqualValues(t, 2, s.Metrics().Dispatched)
+}
+
+func TestService_Dispatch_NoEndpoints(t *testing.T) {
+	repo := mocks.NewMockRepository(t)
+	s, err := NewService(repo, testConfig())
+	require.NoError(t, err)
+
+	repo.EXPECT().Events(mock.Anything, batchSize).Return([]*model.Event{testEvent(model.EventCreated)}, nil).Once()
+	repo.EXPECT().Dispatch(mock.Anything, int64(1), []*model.Delivery(nil)).Return(nil).Once()
+
+	s.dispatch(context.Background())
+}
+
+func TestService_Deliver(t *testing.T) {
+	var (
+		got   *http.Request
+		body  []byte
+		reply = http.StatusNoContent
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(reply)
+		_, _ = w.Write([]byte("oops"))
+	}))
+	defer srv.Close()
+
+	ev := testEvent(model.EventCreated)
+	newDelivery := func(attempts int) *model.Delivery {
+		return &model.Delivery{ID: 9, Webhook: "crm", Event: ev, Status: model.DeliveryPending, Attempts: attempts}
+	}
+
+	t.Run("delivered", func(t *testing.T) {
+		repo := mocks.NewMockRepository(t)
+		s, err := NewService(repo, testConfig(model.Webhook{Name: "crm", URL: srv.URL}))
+		require.NoError(t, err)
+		reply = http.StatusNoContent
+
+		repo.EXPECT().ClaimDeliveries(mock.Anything, mock.Anything, 2*time.Second, 2).
+			Return([]*model.Delivery{newDelivery(0)}, nil).Once()
+		repo.EXPECT().DeleteDelivery(mock.Anything, int64(9)).Return(nil).Once()
+
+		s.deliver(context.Background())
+
+		require.NotNil(t, got)
+		assert.Equal(t, "application/json", got.Header.Get("Content-Type"))
+		assert.Equal(t, "url.created", got.Header.Get(HeaderEvent))
+		assert.Equal(t, "9", got.Header.Get(HeaderDelivery))
+		ts := got.Header.Get(HeaderTimestamp)
+		assert.Equal(t, Sign("secret", ts, body), got.Header.Get(HeaderSignature))
+
+		var sent model.Event
+		require.NoError(t, json.Unmarshal(body, &sent))
+		assert.Equal(t, *ev, sent)
+		assert.EqualValues(t, 1, s.Metrics().Delivered)
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		repo := mocks.NewMockRepository(t)
+		s, err := NewService(repo, testConfig(model.Webhook{Name: "crm", URL: srv.URL}))
+		require.NoError(t, err)
+		reply = http.StatusInternalServerError
+
+		repo.EXPECT().ClaimDeliveries(mock.Anything, mock.Anything, mock.Anything, 2).
+			Return([]*model.Delivery{newDelivery(1)}, nil).Once()
+		repo.EXPECT().UpdateDelivery(mock.Anything, mock.MatchedBy(func(d *model.Delivery) bool {
+			wait := time.Until(d.NextAttemptAt)
+			return d.Status == model.DeliveryPending &&
+				d.Attempts == 2 &&
+				d.LastError == "unexpected status 500: oops" &&
+				wait > time.Minute && wait <= 2*time.Minute
+		})).Return(nil).Once()
+
+		s.deliver(context.Background())
+
+		assert.EqualValues(t, 1, s.Metrics().Failed)
+		assert.Zero(t, s.Metrics().Dead)
+	})
+
+	t.Run("dead letter", func(t *testing.T) {
+		repo := mocks.NewMockRepository(t)
+		s, err := NewService(repo, testConfig(model.Webhook{Name: "crm", URL: srv.URL}))
+		require.NoError(t, err)
+		reply = http.StatusFound
+
+		repo.EXPECT().ClaimDeliveries(mock.Anything, mock.Anything, mock.Anything, 2).
+			Return([]*model.Delivery{newDelivery(2)}, nil).Once()
+		repo.EXPECT().UpdateDelivery(mock.Anything, mock.MatchedBy(func(d *model.Delivery) bool {
+			return d.Status == model.DeliveryDead && d.Attempts == 3 && d.LastError == "unexpected status 302: oops"
+		})).Return(nil).Once()
+
+		s.deliver(context.Background())
+
+		assert.EqualValues(t, 1, s.Metrics().Dead)
+	})
+
+	t.Run("unknown webhook", func(t *testing.T) {
+		repo := mocks.NewMockRepository(t)
+		s, err := NewService(repo, testConfig(model.Webhook{Name: "crm", URL: srv.URL}))
+		require.NoError(t, err)
+
+		d := newDelivery(0)
+		d.Webhook = "removed"
+		repo.EXPECT().ClaimDeliveries(mock.Anything, mock.Anything, mock.Anything, 2).
+			Return([]*model.Delivery{d}, nil).Once()
+		repo.EXPECT().UpdateDelivery(mock.Anything, mock.MatchedBy(func(d *model.Delivery) bool {
+			return d.Attempts == 1 && d.LastError == `webhook "removed" is not configured`
+		})).Return(nil).Once()
+
+		s.deliver(context.Background())
+	})
+
+	t.Run("claim error", func(t *testing.T) {
+		repo := mocks.NewMockRepository(t)
+		s, err := NewService(repo, testConfig(model.Webhook{Name: "crm", URL: srv.URL}))
+		require.NoError(t, err)
+
+		repo.EXPECT().ClaimDeliveries(mock.Anything, mock.Anything, mock.Anything, 2).
+			Return(nil, errors.New("db down")).Once()
+
+		s.deliver(context.Background())
+	})
+}
+
+func TestService_Start(t *testing.T) {
+	repo := mocks.NewMockRepository(t)
+	s, err := NewService(repo, testConfig())
+	require.NoError(t, err)
+
+	polled := make(chan struct{}, 1)
+	repo.EXPECT().Events(mock.Anything, batchSize).Return(nil, nil)
+	repo.EXPECT().ClaimDeliveries(mock.Anything, mock.Anything, mock.Anything, 2).
+		Run(func(context.Context, time.Time, time.Duration, int) {
+			select {
+			case polled <- struct{}{}:
+			default:
+			}
+		}).
+		Return(nil, nil)
+
+	stop := s.Start()
+	select {
+	case <-polled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker did not poll")
+	}
+	stop()
+}
@@ -0,0 +1,48 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Rasulikus/url-shortener/internal/apperr"
+	"github.com/Rasulikus/url-shortener/internal/service"
+	"github.com/Rasulikus/url-shortener/internal/utils/requestid"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus преобразует ошибки приложения в статусы gRPC, так же как ErrorToHttp в HTTP.
+func toStatus(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		return status.Error(codes.InvalidArgument, message(err, "invalid input"))
+	case errors.Is(err, service.ErrUnknownDomain):
+		return status.Error(codes.InvalidArgument, message(err, "unknown domain"))
+	case errors.Is(err, service.ErrNotFound):
+		return status.Error(codes.NotFound, message(err, "not found"))
+	case errors.Is(err, service.ErrDisabled):
+		return status.Error(codes.FailedPrecondition, message(err, "disabled"))
+	case errors.Is(err, service.ErrConflict):
+		return status.Error(codes.AlreadyExists, message(err, "conflict"))
+	case errors.Is(err, service.ErrUnavailable):
+		return status.Error(codes.ResourceExhausted, message(err, "service unavailable"))
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		log.Error().
+			Err(err).
+			Str("request_id", requestid.FromContext(ctx)).
+			Msg("grpc request failed")
+
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+// message возвращает сообщение *apperr.Error из цепочки err или def, если её нет.
+func message(err error, def string) string {
+	if e, ok := apperr.As(err); ok {
+		return e.Error()
+	}
+	return def
+}
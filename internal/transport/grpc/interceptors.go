@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/Rasulikus/url-shortener/internal/utils/bearer"
+	"github.com/Rasulikus/url-shortener/internal/utils/requestid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDKey — ключ метаданных с идентификатором запроса.
+var requestIDKey = strings.ToLower(requestid.Header)
+
+// RequestID берёт идентификатор запроса из метаданных x-request-id или создаёт новый,
+// кладёт его в контекст и возвращает клиенту в заголовке ответа.
+func RequestID() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var id string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if v := md.Get(requestIDKey); len(v) > 0 {
+				id = v[0]
+			}
+		}
+		id = requestid.FromClient(id)
+
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+
+		return handler(requestid.With(ctx, id), req)
+	}
+}
+
+// AdminAuth пропускает вызовы methods только с метаданными "authorization: Bearer <token>".
+// Токен запрашивается на каждый вызов, поэтому его можно сменить без перезапуска.
+func AdminAuth(token func() string, methods ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !slices.Contains(methods, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		var header string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if v := md.Get("authorization"); len(v) > 0 {
+				header = v[0]
+			}
+		}
+		if !bearer.Match(header, token()) {
+			return nil, status.Error(codes.Unauthenticated, "unauthorized")
+		}
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,307 @@
+// Code generated by mockery; DO NOT EDIT.
+// github.com/vektra/mockery
+// template: testify
+
+package mocks
+
+import (
+	"context"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// NewMockURLService creates a new instance of MockURLService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockURLService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockURLService {
+	mock := &MockURLService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockURLService is an autogenerated mock type for the URLService type
+type MockURLService struct {
+	mock.Mock
+}
+
+type MockURLService_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockURLService) EXPECT() *MockURLService_Expecter {
+	return &MockURLService_Expecter{mock: &_m.Mock}
+}
+
+// CreateOrGetURL provides a mock function for the type MockURLService
+func (_mock *MockURLService) CreateOrGetURL(ctx context.Context, domain string, longURL string) (*model.URL, error) {
+	ret := _mock.Called(ctx, domain, longURL)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOrGetURL")
+	}
+
+	var r0 *model.URL
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*model.URL, error)); ok {
+		return returnFunc(ctx, domain, longURL)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *model.URL); ok {
+		r0 = returnFunc(ctx, domain, longURL)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.URL)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, domain, longURL)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockURLService_CreateOrGetURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateOrGetURL'
+type MockURLService_CreateOrGetURL_Call struct {
+	*mock.Call
+}
+
+// CreateOrGetURL is a helper method to define mock.On call
+//   - ctx context.Context
+//   - domain string
+//   - longURL string
+func (_e *MockURLService_Expecter) CreateOrGetURL(ctx interface{}, domain interface{}, longURL interface{}) *MockURLService_CreateOrGetURL_Call {
+	return &MockURLService_CreateOrGetURL_Call{Call: _e.mock.On("CreateOrGetURL", ctx, domain, longURL)}
+}
+
+func (_c *MockURLService_CreateOrGetURL_Call) Run(run func(ctx context.Context, domain string, longURL string)) *MockURLService_CreateOrGetURL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockURLService_CreateOrGetURL_Call) Return(uRL *model.URL, err error) *MockURLService_CreateOrGetURL_Call {
+	_c.Call.Return(uRL, err)
+	return _c
+}
+
+func (_c *MockURLService_CreateOrGetURL_Call) RunAndReturn(run func(ctx context.Context, domain string, longURL string) (*model.URL, error)) *MockURLService_CreateOrGetURL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Delete provides a mock function for the type MockURLService
+func (_mock *MockURLService) Delete(ctx context.Context, domain string, alias string) error {
+	ret := _mock.Called(ctx, domain, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = returnFunc(ctx, domain, alias)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockURLService_Delete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Delete'
+type MockURLService_Delete_Call struct {
+	*mock.Call
+}
+
+// Delete is a helper method to define mock.On call
+//   - ctx context.Context
+//   - domain string
+//   - alias string
+func (_e *MockURLService_Expecter) Delete(ctx interface{}, domain interface{}, alias interface{}) *MockURLService_Delete_Call {
+	return &MockURLService_Delete_Call{Call: _e.mock.On("Delete", ctx, domain, alias)}
+}
+
+func (_c *MockURLService_Delete_Call) Run(run func(ctx context.Context, domain string, alias string)) *MockURLService_Delete_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockURLService_Delete_Call) Return(err error) *MockURLService_Delete_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockURLService_Delete_Call) RunAndReturn(run func(ctx context.Context, domain string, alias string) error) *MockURLService_Delete_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Resolve provides a mock function for the type MockURLService
+func (_mock *MockURLService) Resolve(ctx context.Context, domain string, alias string) (*model.URL, error) {
+	ret := _mock.Called(ctx, domain, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Resolve")
+	}
+
+	var r0 *model.URL
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*model.URL, error)); ok {
+		return returnFunc(ctx, domain, alias)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *model.URL); ok {
+		r0 = returnFunc(ctx, domain, alias)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.URL)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, domain, alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockURLService_Resolve_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Resolve'
+type MockURLService_Resolve_Call struct {
+	*mock.Call
+}
+
+// Resolve is a helper method to define mock.On call
+//   - ctx context.Context
+//   - domain string
+//   - alias string
+func (_e *MockURLService_Expecter) Resolve(ctx interface{}, domain interface{}, alias interface{}) *MockURLService_Resolve_Call {
+	return &MockURLService_Resolve_Call{Call: _e.mock.On("Resolve", ctx, domain, alias)}
+}
+
+func (_c *MockURLService_Resolve_Call) Run(run func(ctx context.Context, domain string, alias string)) *MockURLService_Resolve_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockURLService_Resolve_Call) Return(uRL *model.URL, err error) *MockURLService_Resolve_Call {
+	_c.Call.Return(uRL, err)
+	return _c
+}
+
+func (_c *MockURLService_Resolve_Call) RunAndReturn(run func(ctx context.Context, domain string, alias string) (*model.URL, error)) *MockURLService_Resolve_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ShortURL provides a mock function for the type MockURLService
+func (_mock *MockURLService) ShortURL(domain string, alias string) string {
+	ret := _mock.Called(domain, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ShortURL")
+	}
+
+	var r0 string
+	if returnFunc, ok := ret.Get(0).(func(string, string) string); ok {
+		r0 = returnFunc(domain, alias)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	return r0
+}
+
+// MockURLService_ShortURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ShortURL'
+type MockURLService_ShortURL_Call struct {
+	*mock.Call
+}
+
+// ShortURL is a helper method to define mock.On call
+//   - domain string
+//   - alias string
+func (_e *MockURLService_Expecter) ShortURL(domain interface{}, alias interface{}) *MockURLService_ShortURL_Call {
+	return &MockURLService_ShortURL_Call{Call: _e.mock.On("ShortURL", domain, alias)}
+}
+
+func (_c *MockURLService_ShortURL_Call) Run(run func(domain string, alias string)) *MockURLService_ShortURL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockURLService_ShortURL_Call) Return(s string) *MockURLService_ShortURL_Call {
+	_c.Call.Return(s)
+	return _c
+}
+
+func (_c *MockURLService_ShortURL_Call) RunAndReturn(run func(domain string, alias string) string) *MockURLService_ShortURL_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -0,0 +1,121 @@
+// Package grpc — gRPC API сервиса поверх того же url.Service, что и HTTP API.
+// Контракт описан в api/proto/shortener/v1/shortener.proto.
+package grpc
+
+import (
+	"context"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	pb "github.com/Rasulikus/url-shortener/internal/transport/grpc/shortenerv1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MaxBatchSize — сколько ссылок можно создать одним вызовом BatchCreate.
+const MaxBatchSize = 1000
+
+type URLService interface {
+	// CreateOrGetURL создаёт ссылку для longURL на домене или возвращает уже существующую.
+	CreateOrGetURL(ctx context.Context, domain, longURL string) (*model.URL, error)
+
+	// ShortURL возвращает короткую ссылку для алиаса на домене.
+	ShortURL(domain, alias string) string
+
+	// Resolve возвращает запись по алиасу на домене, в том числе отключённую.
+	Resolve(ctx context.Context, domain, alias string) (*model.URL, error)
+
+	// Delete удаляет ссылку.
+	Delete(ctx context.Context, domain, alias string) error
+}
+
+type Server struct {
+	pb.UnimplementedURLShortenerServer
+
+	s URLService
+}
+
+func NewServer(s URLService) *Server {
+	return &Server{
+		s: s,
+	}
+}
+
+// New создаёт gRPC-сервер с зарегистрированным API. Delete доступен только с
+// токеном adminToken, как административный HTTP API.
+func New(s URLService, adminToken func() string) *grpc.Server {
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		RequestID(),
+		AdminAuth(adminToken, pb.URLShortener_Delete_FullMethodName),
+	))
+	pb.RegisterURLShortenerServer(srv, NewServer(s))
+	return srv
+}
+
+func (s *Server) link(u *model.URL) *pb.Link {
+	return &pb.Link{
+		Domain:       u.Domain,
+		Alias:        u.Alias,
+		LongUrl:      u.LongURL,
+		ShortUrl:     s.s.ShortURL(u.Domain, u.Alias),
+		Disabled:     u.Disabled,
+		Interstitial: u.Interstitial,
+		CreatedAt:    timestamppb.New(u.CreatedAt),
+	}
+}
+
+func (s *Server) CreateOrGet(ctx context.Context, req *pb.CreateOrGetRequest) (*pb.Link, error) {
+	u, err := s.s.CreateOrGetURL(ctx, req.GetDomain(), req.GetLongUrl())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return s.link(u), nil
+}
+
+func (s *Server) Resolve(ctx context.Context, req *pb.ResolveRequest) (*pb.Link, error) {
+	u, err := s.s.Resolve(ctx, req.GetDomain(), req.GetAlias())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return s.link(u), nil
+}
+
+// BatchCreate создаёт ссылки по очереди; ошибка одной попадает в её результат.
+// Если клиент отменил вызов, оставшиеся ссылки не создаются.
+func (s *Server) BatchCreate(ctx context.Context, req *pb.BatchCreateRequest) (*pb.BatchCreateResponse, error) {
+	items := req.GetItems()
+	if len(items) == 0 || len(items) > MaxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "batch must contain 1 to %d items", MaxBatchSize)
+	}
+
+	results := make([]*pb.BatchCreateResult, len(items))
+	for i, item := range items {
+		if err := ctx.Err(); err != nil {
+			return nil, status.FromContextError(err).Err()
+		}
+
+		u, err := s.s.CreateOrGetURL(ctx, item.GetDomain(), item.GetLongUrl())
+		if err != nil {
+			st := status.Convert(toStatus(ctx, err))
+			results[i] = &pb.BatchCreateResult{Result: &pb.BatchCreateResult_Error{Error: &pb.BatchError{
+				Code:    uint32(st.Code()),
+				Message: st.Message(),
+			}}}
+			continue
+		}
+		results[i] = &pb.BatchCreateResult{Result: &pb.BatchCreateResult_Link{Link: s.link(u)}}
+	}
+
+	return &pb.BatchCreateResponse{Results: results}, nil
+}
+
+func (s *Server) Delete(ctx context.Context, req *pb.DeleteRequest) (*pb.DeleteResponse, error) {
+	if err := s.s.Delete(ctx, req.GetDomain(), req.GetAlias()); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &pb.DeleteResponse{}, nil
+}
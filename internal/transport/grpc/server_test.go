@@ -0,0 +1,252 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/apperr"
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/service"
+	"github.com/Rasulikus/url-shortener/internal/transport/grpc/mocks"
+	pb "github.com/Rasulikus/url-shortener/internal/transport/grpc/shortenerv1"
+	"github.com/Rasulikus/url-shortener/internal/utils/requestid"
+	"github.com/Rasulikus/url-shortener/internal/utils/validate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testAdminToken = "secret"
+
+var testCreatedAt = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// newClient запускает сервер поверх s в памяти и возвращает клиента к нему.
+func newClient(t *testing.T, s URLService) pb.URLShortenerClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := New(s, func() string { return testAdminToken })
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return pb.NewURLShortenerClient(conn)
+}
+
+func testURL(longURL, alias string) *model.URL {
+	return &model.URL{ID: 1, Domain: "brand.link", LongURL: longURL, Alias: alias, CreatedAt: testCreatedAt}
+}
+
+func TestServer_CreateOrGet(t *testing.T) {
+	cases := []struct {
+		name     string
+		svcErr   error
+		wantCode codes.Code
+	}{
+		{"ok", nil, codes.OK},
+		{"invalid input", service.ErrInvalidInput, codes.InvalidArgument},
+		{"unknown domain", service.ErrUnknownDomain, codes.InvalidArgument},
+		{"conflict", service.ErrConflict, codes.AlreadyExists},
+		{"unavailable", service.ErrUnavailable, codes.ResourceExhausted},
+		{"internal", errors.New("db down"), codes.Internal},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := mocks.NewMockURLService(t)
+			var ret *model.URL
+			if tc.svcErr == nil {
+				ret = testURL("https://example.com", "aa")
+				s.EXPECT().ShortURL("brand.link", "aa").Return("https://brand.link/aa").Once()
+			}
+			s.EXPECT().CreateOrGetURL(mock.Anything, "brand.link", "https://example.com").Return(ret, tc.svcErr).Once()
+
+			link, err := newClient(t, s).CreateOrGet(context.Background(), &pb.CreateOrGetRequest{
+				LongUrl: "https://example.com",
+				Domain:  "brand.link",
+			})
+			require.Equal(t, tc.wantCode, status.Code(err))
+			if tc.wantCode != codes.OK {
+				return
+			}
+
+			assert.Equal(t, "brand.link", link.GetDomain())
+			assert.Equal(t, "aa", link.GetAlias())
+			assert.Equal(t, "https://example.com", link.GetLongUrl())
+			assert.Equal(t, "https://brand.link/aa", link.GetShortUrl())
+			assert.True(t, link.GetCreatedAt().AsTime().Equal(testCreatedAt))
+		})
+	}
+}
+
+func TestServer_Resolve(t *testing.T) {
+	cases := []struct {
+		name     string
+		svcErr   error
+		wantCode codes.Code
+	}{
+		{"ok", nil, codes.OK},
+		{"not found", service.ErrNotFound, codes.NotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := mocks.NewMockURLService(t)
+			var ret *model.URL
+			if tc.svcErr == nil {
+				ret = testURL("https://example.com", "aa")
+				ret.Disabled = true
+				s.EXPECT().ShortURL("brand.link", "aa").Return("https://brand.link/aa").Once()
+			}
+			s.EXPECT().Resolve(mock.Anything, "brand.link", "aa").Return(ret, tc.svcErr).Once()
+
+			link, err := newClient(t, s).Resolve(context.Background(), &pb.ResolveRequest{Domain: "brand.link", Alias: "aa"})
+			require.Equal(t, tc.wantCode, status.Code(err))
+			if tc.wantCode == codes.OK {
+				assert.True(t, link.GetDisabled())
+			}
+		})
+	}
+}
+
+func TestToStatus_Message(t *testing.T) {
+	cases := []struct {
+		name    string
+		err     error
+		wantMsg string
+	}{
+		{"sentinel", service.ErrNotFound, "not found"},
+		{"app error", service.InvalidField("long_url", validate.URL("")), "long_url: url is empty"},
+		{"internal app error", fmt.Errorf("db: %w", &apperr.Error{Code: apperr.CodeInternal, Message: "secret"}), "internal server error"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			st, ok := status.FromError(toStatus(context.Background(), tc.err))
+			require.True(t, ok)
+			assert.Equal(t, tc.wantMsg, st.Message())
+		})
+	}
+}
+
+func TestServer_BatchCreate(t *testing.T) {
+	s := mocks.NewMockURLService(t)
+	s.EXPECT().CreateOrGetURL(mock.Anything, "", "https://aa.com").Return(testURL("https://aa.com", "aa"), nil).Once()
+	s.EXPECT().CreateOrGetURL(mock.Anything, "", "ftp://bad").Return(nil, service.ErrInvalidInput).Once()
+	s.EXPECT().CreateOrGetURL(mock.Anything, "", "https://bb.com").Return(testURL("https://bb.com", "bb"), nil).Once()
+	s.EXPECT().ShortURL("brand.link", mock.Anything).RunAndReturn(func(_, alias string) string {
+		return "https://brand.link/" + alias
+	}).Twice()
+
+	resp, err := newClient(t, s).BatchCreate(context.Background(), &pb.BatchCreateRequest{Items: []*pb.CreateOrGetRequest{
+		{LongUrl: "https://aa.com"},
+		{LongUrl: "ftp://bad"},
+		{LongUrl: "https://bb.com"},
+	}})
+	require.NoError(t, err)
+	require.Len(t, resp.GetResults(), 3)
+
+	assert.Equal(t, "https://brand.link/aa", resp.GetResults()[0].GetLink().GetShortUrl())
+	assert.Equal(t, uint32(codes.InvalidArgument), resp.GetResults()[1].GetError().GetCode())
+	assert.Equal(t, "invalid input", resp.GetResults()[1].GetError().GetMessage())
+	assert.Equal(t, "https://brand.link/bb", resp.GetResults()[2].GetLink().GetShortUrl())
+}
+
+func TestServer_BatchCreate_Size(t *testing.T) {
+	client := newClient(t, mocks.NewMockURLService(t))
+
+	_, err := client.BatchCreate(context.Background(), &pb.BatchCreateRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	items := make([]*pb.CreateOrGetRequest, MaxBatchSize+1)
+	for i := range items {
+		items[i] = &pb.CreateOrGetRequest{LongUrl: "https://aa.com"}
+	}
+	_, err = client.BatchCreate(context.Background(), &pb.BatchCreateRequest{Items: items})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServer_Delete(t *testing.T) {
+	cases := []struct {
+		name     string
+		auth     string
+		svcErr   error
+		called   bool
+		wantCode codes.Code
+	}{
+		{"ok", "Bearer " + testAdminToken, nil, true, codes.OK},
+		{"not found", "Bearer " + testAdminToken, service.ErrNotFound, true, codes.NotFound},
+		{"missing token", "", nil, false, codes.Unauthenticated},
+		{"wrong token", "Bearer nope", nil, false, codes.Unauthenticated},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := mocks.NewMockURLService(t)
+			if tc.called {
+				s.EXPECT().Delete(mock.Anything, "", "aa").Return(tc.svcErr).Once()
+			}
+
+			ctx := context.Background()
+			if tc.auth != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", tc.auth)
+			}
+			_, err := newClient(t, s).Delete(ctx, &pb.DeleteRequest{Alias: "aa"})
+			require.Equal(t, tc.wantCode, status.Code(err))
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	cases := []struct {
+		name string
+		id   string
+		keep bool
+	}{
+		{"from client", "req-42", true},
+		{"generated", "", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var seen string
+			s := mocks.NewMockURLService(t)
+			s.EXPECT().Resolve(mock.Anything, "", "aa").
+				RunAndReturn(func(ctx context.Context, _, _ string) (*model.URL, error) {
+					seen = requestid.FromContext(ctx)
+					return nil, service.ErrNotFound
+				}).
+				Once()
+
+			ctx := context.Background()
+			if tc.id != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "x-request-id", tc.id)
+			}
+			var header metadata.MD
+			_, err := newClient(t, s).Resolve(ctx, &pb.ResolveRequest{Alias: "aa"}, grpc.Header(&header))
+			require.Equal(t, codes.NotFound, status.Code(err))
+
+			require.NotEmpty(t, seen)
+			assert.Equal(t, []string{seen}, header.Get("x-request-id"))
+			if tc.keep {
+				assert.Equal(t, tc.id, seen)
+			}
+		})
+	}
+}
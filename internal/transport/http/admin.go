@@ -0,0 +1,128 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Rasulikus/url-shortener/internal/apperr"
+	"github.com/Rasulikus/url-shortener/internal/model"
+	urlService "github.com/Rasulikus/url-shortener/internal/service/url"
+	"github.com/Rasulikus/url-shortener/internal/utils/bearer"
+	"github.com/Rasulikus/url-shortener/internal/utils/linkio"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type AdminService interface {
+	// Export передаёт fn все ссылки в порядке ID.
+	Export(ctx context.Context, fn func(u *model.URL) error) error
+
+	// Import загружает ссылки из r, пропуская уже занятые алиасы и long URL.
+	Import(ctx context.Context, r urlService.URLReader) (*urlService.ImportResult, error)
+}
+
+var (
+	// ErrUnauthorized — запрос к административному API без верного токена.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrBodyTooLarge — тело запроса больше допустимого.
+	ErrBodyTooLarge = errors.New("request body too large")
+)
+
+type AdminHandler struct {
+	s AdminService
+	// maxImportBytes — наибольший размер тела запроса импорта.
+	maxImportBytes int64
+}
+
+func NewAdminHandler(s AdminService, maxImportBytes int64) *AdminHandler {
+	return &AdminHandler{
+		s:              s,
+		maxImportBytes: maxImportBytes,
+	}
+}
+
+// AdminAuth пропускает только запросы с заголовком "Authorization: Bearer <token>".
+// Токен запрашивается на каждый запрос, поэтому его можно сменить без перезапуска.
+func AdminAuth(token func() string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !bearer.Match(c.GetHeader("Authorization"), token()) {
+			c.Header("WWW-Authenticate", "Bearer")
+			ErrorToHttp(c, &apperr.Error{
+				Code:    apperr.CodeUnauthorized,
+				Message: "missing or invalid admin token",
+				Err:     ErrUnauthorized,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// parseFormat читает формат из параметра format, по умолчанию jsonl.
+func parseFormat(c *gin.Context) (linkio.Format, error) {
+	f, err := linkio.ParseFormat(c.DefaultQuery("format", string(linkio.FormatJSONL)))
+	if err != nil {
+		return "", invalidParam(apperr.CodeInvalidParameter, "format", "must be jsonl or csv")
+	}
+	return f, nil
+}
+
+// Export отдаёт все ссылки потоком в формате jsonl или csv.
+func (h *AdminHandler) Export(c *gin.Context) {
+	format, err := parseFormat(c)
+	if err != nil {
+		ErrorToHttp(c, err)
+		return
+	}
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", `attachment; filename="links.`+string(format)+`"`)
+
+	enc := linkio.NewEncoder(c.Writer, format)
+	err = h.s.Export(c.Request.Context(), enc.Encode)
+	if err == nil {
+		err = enc.Flush()
+	}
+	if err != nil {
+		// Пока ничего не отправлено, можно вернуть обычный ответ с ошибкой
+		if !c.Writer.Written() {
+			ErrorToHttp(c, err)
+			return
+		}
+		log.Error().
+			Err(err).
+			Msg("export interrupted")
+
+		c.Abort()
+	}
+}
+
+// Import загружает ссылки из тела запроса и возвращает отчёт об импорте.
+func (h *AdminHandler) Import(c *gin.Context) {
+	format, err := parseFormat(c)
+	if err != nil {
+		ErrorToHttp(c, err)
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, h.maxImportBytes)
+	res, err := h.s.Import(c.Request.Context(), linkio.NewDecoder(body, format))
+	if err != nil {
+		// Записи до превышения лимита уже сохранены, как при любой ошибке чтения
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			err = &apperr.Error{
+				Code:    apperr.CodeBodyTooLarge,
+				Message: fmt.Sprintf("request body must not exceed %d bytes", tooLarge.Limit),
+				Details: map[string]any{"max_bytes": tooLarge.Limit},
+				Err:     ErrBodyTooLarge,
+			}
+		}
+		ErrorToHttp(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,235 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/service"
+	urlService "github.com/Rasulikus/url-shortener/internal/service/url"
+	"github.com/Rasulikus/url-shortener/internal/transport/http/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testAdminToken     = "secret"
+	testImportMaxBytes = 1 << 20
+)
+
+func setupAdminRouter(h *AdminHandler) *gin.Engine {
+	r := gin.New()
+	admin := r.Group("/admin", AdminAuth(func() string { return testAdminToken }))
+	admin.GET("/export", h.Export)
+	admin.POST("/import", h.Import)
+	return r
+}
+
+func adminRequest(method, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	return req
+}
+
+func TestAdminAuth(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+	}{
+		{"missing", ""},
+		{"wrong token", "Bearer nope"},
+		{"wrong scheme", "Basic " + testAdminToken},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := setupAdminRouter(NewAdminHandler(mocks.NewMockAdminService(t), testImportMaxBytes))
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/export", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusUnauthorized, w.Code)
+			require.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+			require.JSONEq(t, `{"type":"about:blank","title":"Unauthorized","status":401,"instance":"/admin/export",
+				"code":"unauthorized","detail":"missing or invalid admin token"}`, w.Body.String())
+		})
+	}
+}
+
+func TestAdminAuth_TokenRotation(t *testing.T) {
+	token := "old"
+	r := gin.New()
+	r.GET("/admin/ping", AdminAuth(func() string { return token }), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	do := func(bearer string) int {
+		req := httptest.NewRequest(http.MethodGet, "/admin/ping", nil)
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusNoContent, do("old"))
+
+	token = "new"
+	require.Equal(t, http.StatusUnauthorized, do("old"))
+	require.Equal(t, http.StatusNoContent, do("new"))
+
+	// Пустой токен не пускает никого, даже с пустым заголовком
+	token = ""
+	require.Equal(t, http.StatusUnauthorized, do(""))
+}
+
+func TestAdminHandler_Export(t *testing.T) {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	urls := []*model.URL{
+		{ID: 1, Alias: "aa", LongURL: "https://a.com", CreatedAt: createdAt},
+		{ID: 2, Alias: "bb", LongURL: "https://b.com", CreatedAt: createdAt, Disabled: true},
+	}
+
+	cases := []struct {
+		name        string
+		query       string
+		contentType string
+		want        string
+	}{
+		{
+			name:        "jsonl",
+			query:       "",
+			contentType: "application/x-ndjson",
+			want: `{"id":1,"alias":"aa","long_url":"https://a.com","created_at":"2024-01-02T03:04:05Z","disabled":false}
+{"id":2,"alias":"bb","long_url":"https://b.com","created_at":"2024-01-02T03:04:05Z","disabled":true}
+`,
+		},
+		{
+			name:        "csv",
+			query:       "?format=csv",
+			contentType: "text/csv; charset=utf-8",
+			want: `id,alias,long_url,created_at,disabled,domain,interstitial
+1,aa,https://a.com,2024-01-02T03:04:05Z,false,,false
+2,bb,https://b.com,2024-01-02T03:04:05Z,true,,false
+`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := mocks.NewMockAdminService(t)
+			s.EXPECT().
+				Export(mock.Anything, mock.Anything).
+				RunAndReturn(func(_ context.Context, fn func(u *model.URL) error) error {
+					for _, u := range urls {
+						if err := fn(u); err != nil {
+							return err
+						}
+					}
+					return nil
+				}).
+				Once()
+
+			r := setupAdminRouter(NewAdminHandler(s, testImportMaxBytes))
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, adminRequest(http.MethodGet, "/admin/export"+tc.query, nil))
+
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, tc.contentType, w.Header().Get("Content-Type"))
+			require.Equal(t, tc.want, w.Body.String())
+		})
+	}
+}
+
+func TestAdminHandler_Export_Error(t *testing.T) {
+	s := mocks.NewMockAdminService(t)
+	s.EXPECT().
+		Export(mock.Anything, mock.Anything).
+		Return(errors.New("boom")).
+		Once()
+
+	r := setupAdminRouter(NewAdminHandler(s, testImportMaxBytes))
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, adminRequest(http.MethodGet, "/admin/export", nil))
+
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	require.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"type":"about:blank","title":"Internal Server Error","status":500,"instance":"/admin/export",
+		"code":"internal","detail":"internal server error"}`, w.Body.String())
+}
+
+func TestAdminHandler_Export_InvalidFormat(t *testing.T) {
+	r := setupAdminRouter(NewAdminHandler(mocks.NewMockAdminService(t), testImportMaxBytes))
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, adminRequest(http.MethodGet, "/admin/export?format=xml", nil))
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"type":"about:blank","title":"Bad Request","status":400,"instance":"/admin/export",
+		"code":"invalid_parameter","field":"format","detail":"must be jsonl or csv"}`, w.Body.String())
+}
+
+func TestAdminHandler_Import(t *testing.T) {
+	s := mocks.NewMockAdminService(t)
+	s.EXPECT().
+		Import(mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, r urlService.URLReader) (*urlService.ImportResult, error) {
+			u, err := r.Decode()
+			require.NoError(t, err)
+			require.Equal(t, "aa", u.Alias)
+			require.Equal(t, "https://a.com", u.LongURL)
+
+			_, err = r.Decode()
+			require.ErrorIs(t, err, io.EOF)
+
+			return &urlService.ImportResult{Total: 1, Imported: 1}, nil
+		}).
+		Once()
+
+	r := setupAdminRouter(NewAdminHandler(s, testImportMaxBytes))
+	w := httptest.NewRecorder()
+
+	body := "alias,long_url\naa,https://a.com\n"
+	r.ServeHTTP(w, adminRequest(http.MethodPost, "/admin/import?format=csv", strings.NewReader(body)))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"total":1,"imported":1,"skipped":0,"invalid":0}`, w.Body.String())
+}
+
+func TestAdminHandler_Import_TooLarge(t *testing.T) {
+	s := mocks.NewMockAdminService(t)
+	s.EXPECT().
+		Import(mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, r urlService.URLReader) (*urlService.ImportResult, error) {
+			for {
+				if _, err := r.Decode(); err != nil {
+					return nil, fmt.Errorf("%w: %w", service.ErrInvalidInput, err)
+				}
+			}
+		}).
+		Once()
+
+	r := setupAdminRouter(NewAdminHandler(s, 64))
+	w := httptest.NewRecorder()
+
+	body := "alias,long_url\n" + strings.Repeat("aa,https://a.com\n", 10)
+	r.ServeHTTP(w, adminRequest(http.MethodPost, "/admin/import?format=csv", strings.NewReader(body)))
+
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	require.JSONEq(t, `{"type":"about:blank","title":"Request Entity Too Large","status":413,"instance":"/admin/import",
+		"code":"body_too_large","detail":"request body must not exceed 64 bytes","details":{"max_bytes":64}}`, w.Body.String())
+}
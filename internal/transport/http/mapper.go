@@ -1,29 +1,130 @@
 package http
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"reflect"
+	"strings"
 
+	"github.com/Rasulikus/url-shortener/internal/apperr"
 	"github.com/Rasulikus/url-shortener/internal/service"
+	"github.com/Rasulikus/url-shortener/internal/utils/requestid"
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 )
 
-type errorResponse struct {
-	Error string `json:"error"`
+// contentTypeProblem — тип ответа с ошибкой по RFC 7807.
+const contentTypeProblem = "application/problem+json"
+
+// problem — тело ответа с ошибкой по RFC 7807. Type всегда about:blank, поэтому Title
+// совпадает с текстом статуса, а ошибки различаются по расширению Code.
+type problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	Code      apperr.Code    `json:"code"`
+	Field     string         `json:"field,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
 }
 
-// ErrorToHttp преобразует ошибки приложения в HTTP-ответы.
+// ErrorToHttp преобразует ошибки приложения в ответы application/problem+json.
+// Статус задаёт класс ошибки, код, сообщение и поле берутся из *apperr.Error в цепочке,
+// если она есть. Подробности внутренних ошибок клиенту не отдаются.
 func ErrorToHttp(c *gin.Context, err error) {
+	status, code, detail := classify(err)
+	p := problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  c.Request.URL.Path,
+		Code:      code,
+		RequestID: requestid.FromContext(c.Request.Context()),
+	}
+	if e, ok := apperr.As(err); ok && status != http.StatusInternalServerError {
+		p.Code, p.Detail, p.Field, p.Details = e.Code, e.Message, e.Field, e.Details
+	}
+
+	c.Header("Content-Type", contentTypeProblem)
+	c.AbortWithStatusJSON(status, p)
+}
+
+// classify возвращает статус, код и сообщение по умолчанию для класса ошибки.
+func classify(err error) (int, apperr.Code, string) {
 	switch {
-	case errors.Is(err, ErrInvalidInput):
-		c.AbortWithStatusJSON(http.StatusBadRequest, errorResponse{Error: "invalid input"})
-	case errors.Is(err, service.ErrInvalidInput):
-		c.AbortWithStatusJSON(http.StatusBadRequest, errorResponse{Error: "invalid input"})
+	case errors.Is(err, ErrInvalidInput), errors.Is(err, service.ErrInvalidInput):
+		return http.StatusBadRequest, apperr.CodeInvalidInput, "invalid input"
+	case errors.Is(err, service.ErrUnknownDomain):
+		return http.StatusBadRequest, apperr.CodeUnknownDomain, "unknown domain"
+	case errors.Is(err, ErrBodyTooLarge):
+		return http.StatusRequestEntityTooLarge, apperr.CodeBodyTooLarge, "request body too large"
+	case errors.Is(err, ErrUnauthorized):
+		return http.StatusUnauthorized, apperr.CodeUnauthorized, "unauthorized"
 	case errors.Is(err, service.ErrNotFound):
-		c.AbortWithStatusJSON(http.StatusNotFound, errorResponse{Error: "not found"})
+		return http.StatusNotFound, apperr.CodeNotFound, "not found"
+	case errors.Is(err, service.ErrDisabled):
+		return http.StatusGone, apperr.CodeDisabled, "disabled"
 	case errors.Is(err, service.ErrConflict):
-		c.AbortWithStatusJSON(http.StatusConflict, errorResponse{Error: "conflict"})
+		return http.StatusConflict, apperr.CodeConflict, "conflict"
+	case errors.Is(err, service.ErrUnavailable):
+		return http.StatusServiceUnavailable, apperr.CodeUnavailable, "service unavailable"
+	default:
+		return http.StatusInternalServerError, apperr.CodeInternal, "internal server error"
+	}
+}
+
+// invalidParam — ошибка параметра запроса name.
+func invalidParam(code apperr.Code, name, msg string) error {
+	return &apperr.Error{Code: code, Message: msg, Field: name, Err: ErrInvalidInput}
+}
+
+// bindError объясняет, почему тело запроса не удалось разобрать в obj: JSON
+// искажён, у поля неверный тип или не заполнено обязательное поле. Поля
+// называются по тегам json, как их видит клиент.
+func bindError(obj any, err error) error {
+	var (
+		verrs     validator.ValidationErrors
+		typeErr   *json.UnmarshalTypeError
+		syntaxErr *json.SyntaxError
+	)
+	switch {
+	case errors.As(err, &verrs) && len(verrs) > 0:
+		fe := verrs[0]
+		field := jsonName(obj, fe.StructField())
+		if fe.Tag() == "required" {
+			return invalidParam(apperr.CodeMissingField, field, "field is required")
+		}
+		return invalidParam(apperr.CodeInvalidInput, field, fmt.Sprintf("does not satisfy %q", fe.Tag()))
+	case errors.As(err, &typeErr):
+		return invalidParam(apperr.CodeInvalidInput, typeErr.Field, "must be "+typeErr.Type.String())
+	case errors.As(err, &syntaxErr):
+		return &apperr.Error{
+			Code:    apperr.CodeInvalidJSON,
+			Message: "request body is not valid JSON",
+			Details: map[string]any{"offset": syntaxErr.Offset},
+			Err:     ErrInvalidInput,
+		}
 	default:
-		c.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		return &apperr.Error{Code: apperr.CodeInvalidJSON, Message: "request body is not valid JSON", Err: ErrInvalidInput}
+	}
+}
+
+// jsonName возвращает имя поля структуры *obj в JSON.
+func jsonName(obj any, field string) string {
+	t := reflect.TypeOf(obj)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if f, ok := t.FieldByName(field); ok {
+		if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "" && name != "-" {
+			return name
+		}
 	}
+	return field
 }
@@ -0,0 +1,165 @@
+// Code generated by mockery; DO NOT EDIT.
+// github.com/vektra/mockery
+// template: testify
+
+package mocks
+
+import (
+	"context"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/service/url"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// NewMockAdminService creates a new instance of MockAdminService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockAdminService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockAdminService {
+	mock := &MockAdminService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockAdminService is an autogenerated mock type for the AdminService type
+type MockAdminService struct {
+	mock.Mock
+}
+
+type MockAdminService_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockAdminService) EXPECT() *MockAdminService_Expecter {
+	return &MockAdminService_Expecter{mock: &_m.Mock}
+}
+
+// Export provides a mock function for the type MockAdminService
+func (_mock *MockAdminService) Export(ctx context.Context, fn func(u *model.URL) error) error {
+	ret := _mock.Called(ctx, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Export")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, func(u *model.URL) error) error); ok {
+		r0 = returnFunc(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockAdminService_Export_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Export'
+type MockAdminService_Export_Call struct {
+	*mock.Call
+}
+
+// Export is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fn func(u *model.URL) error
+func (_e *MockAdminService_Expecter) Export(ctx interface{}, fn interface{}) *MockAdminService_Export_Call {
+	return &MockAdminService_Export_Call{Call: _e.mock.On("Export", ctx, fn)}
+}
+
+func (_c *MockAdminService_Export_Call) Run(run func(ctx context.Context, fn func(u *model.URL) error)) *MockAdminService_Export_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 func(u *model.URL) error
+		if args[1] != nil {
+			arg1 = args[1].(func(u *model.URL) error)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockAdminService_Export_Call) Return(err error) *MockAdminService_Export_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockAdminService_Export_Call) RunAndReturn(run func(ctx context.Context, fn func(u *model.URL) error) error) *MockAdminService_Export_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Import provides a mock function for the type MockAdminService
+func (_mock *MockAdminService) Import(ctx context.Context, r url.URLReader) (*url.ImportResult, error) {
+	ret := _mock.Called(ctx, r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Import")
+	}
+
+	var r0 *url.ImportResult
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, url.URLReader) (*url.ImportResult, error)); ok {
+		return returnFunc(ctx, r)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, url.URLReader) *url.ImportResult); ok {
+		r0 = returnFunc(ctx, r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*url.ImportResult)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, url.URLReader) error); ok {
+		r1 = returnFunc(ctx, r)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockAdminService_Import_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Import'
+type MockAdminService_Import_Call struct {
+	*mock.Call
+}
+
+// Import is a helper method to define mock.On call
+//   - ctx context.Context
+//   - r url.URLReader
+func (_e *MockAdminService_Expecter) Import(ctx interface{}, r interface{}) *MockAdminService_Import_Call {
+	return &MockAdminService_Import_Call{Call: _e.mock.On("Import", ctx, r)}
+}
+
+func (_c *MockAdminService_Import_Call) Run(run func(ctx context.Context, r url.URLReader)) *MockAdminService_Import_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 url.URLReader
+		if args[1] != nil {
+			arg1 = args[1].(url.URLReader)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockAdminService_Import_Call) Return(importResult *url.ImportResult, err error) *MockAdminService_Import_Call {
+	_c.Call.Return(importResult, err)
+	return _c
+}
+
+func (_c *MockAdminService_Import_Call) RunAndReturn(run func(ctx context.Context, r url.URLReader) (*url.ImportResult, error)) *MockAdminService_Import_Call {
+	_c.Call.Return(run)
+	return _c
+}
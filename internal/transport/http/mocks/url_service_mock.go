@@ -7,6 +7,7 @@ package mocks
 import (
 	"context"
 
+	"github.com/Rasulikus/url-shortener/internal/model"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -37,9 +38,55 @@ func (_m *MockURLService) EXPECT() *MockURLService_Expecter {
 	return &MockURLService_Expecter{mock: &_m.Mock}
 }
 
+// Click provides a mock function for the type MockURLService
+func (_mock *MockURLService) Click(ctx context.Context, u *model.URL) {
+	_mock.Called(ctx, u)
+	return
+}
+
+// MockURLService_Click_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Click'
+type MockURLService_Click_Call struct {
+	*mock.Call
+}
+
+// Click is a helper method to define mock.On call
+//   - ctx context.Context
+//   - u *model.URL
+func (_e *MockURLService_Expecter) Click(ctx interface{}, u interface{}) *MockURLService_Click_Call {
+	return &MockURLService_Click_Call{Call: _e.mock.On("Click", ctx, u)}
+}
+
+func (_c *MockURLService_Click_Call) Run(run func(ctx context.Context, u *model.URL)) *MockURLService_Click_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *model.URL
+		if args[1] != nil {
+			arg1 = args[1].(*model.URL)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockURLService_Click_Call) Return() *MockURLService_Click_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockURLService_Click_Call) RunAndReturn(run func(ctx context.Context, u *model.URL)) *MockURLService_Click_Call {
+	_c.Run(run)
+	return _c
+}
+
 // CreateOrGet provides a mock function for the type MockURLService
-func (_mock *MockURLService) CreateOrGet(ctx context.Context, longURL string) (string, error) {
-	ret := _mock.Called(ctx, longURL)
+func (_mock *MockURLService) CreateOrGet(ctx context.Context, domain string, longURL string) (string, error) {
+	ret := _mock.Called(ctx, domain, longURL)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateOrGet")
@@ -47,16 +94,16 @@ func (_mock *MockURLService) CreateOrGet(ctx context.Context, longURL string) (s
 
 	var r0 string
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
-		return returnFunc(ctx, longURL)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+		return returnFunc(ctx, domain, longURL)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) string); ok {
-		r0 = returnFunc(ctx, longURL)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = returnFunc(ctx, domain, longURL)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, longURL)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, domain, longURL)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -70,12 +117,13 @@ type MockURLService_CreateOrGet_Call struct {
 
 // CreateOrGet is a helper method to define mock.On call
 //   - ctx context.Context
+//   - domain string
 //   - longURL string
-func (_e *MockURLService_Expecter) CreateOrGet(ctx interface{}, longURL interface{}) *MockURLService_CreateOrGet_Call {
-	return &MockURLService_CreateOrGet_Call{Call: _e.mock.On("CreateOrGet", ctx, longURL)}
+func (_e *MockURLService_Expecter) CreateOrGet(ctx interface{}, domain interface{}, longURL interface{}) *MockURLService_CreateOrGet_Call {
+	return &MockURLService_CreateOrGet_Call{Call: _e.mock.On("CreateOrGet", ctx, domain, longURL)}
 }
 
-func (_c *MockURLService_CreateOrGet_Call) Run(run func(ctx context.Context, longURL string)) *MockURLService_CreateOrGet_Call {
+func (_c *MockURLService_CreateOrGet_Call) Run(run func(ctx context.Context, domain string, longURL string)) *MockURLService_CreateOrGet_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -85,9 +133,14 @@ func (_c *MockURLService_CreateOrGet_Call) Run(run func(ctx context.Context, lon
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
 		run(
 			arg0,
 			arg1,
+			arg2,
 		)
 	})
 	return _c
@@ -98,50 +151,53 @@ func (_c *MockURLService_CreateOrGet_Call) Return(s string, err error) *MockURLS
 	return _c
 }
 
-func (_c *MockURLService_CreateOrGet_Call) RunAndReturn(run func(ctx context.Context, longURL string) (string, error)) *MockURLService_CreateOrGet_Call {
+func (_c *MockURLService_CreateOrGet_Call) RunAndReturn(run func(ctx context.Context, domain string, longURL string) (string, error)) *MockURLService_CreateOrGet_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetLongURLByAlias provides a mock function for the type MockURLService
-func (_mock *MockURLService) GetLongURLByAlias(ctx context.Context, alias string) (string, error) {
-	ret := _mock.Called(ctx, alias)
+// Follow provides a mock function for the type MockURLService
+func (_mock *MockURLService) Follow(ctx context.Context, domain string, alias string) (*model.URL, error) {
+	ret := _mock.Called(ctx, domain, alias)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetLongURLByAlias")
+		panic("no return value specified for Follow")
 	}
 
-	var r0 string
+	var r0 *model.URL
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
-		return returnFunc(ctx, alias)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*model.URL, error)); ok {
+		return returnFunc(ctx, domain, alias)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) string); ok {
-		r0 = returnFunc(ctx, alias)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *model.URL); ok {
+		r0 = returnFunc(ctx, domain, alias)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.URL)
+		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, alias)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, domain, alias)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockURLService_GetLongURLByAlias_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLongURLByAlias'
-type MockURLService_GetLongURLByAlias_Call struct {
+// MockURLService_Follow_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Follow'
+type MockURLService_Follow_Call struct {
 	*mock.Call
 }
 
-// GetLongURLByAlias is a helper method to define mock.On call
+// Follow is a helper method to define mock.On call
 //   - ctx context.Context
+//   - domain string
 //   - alias string
-func (_e *MockURLService_Expecter) GetLongURLByAlias(ctx interface{}, alias interface{}) *MockURLService_GetLongURLByAlias_Call {
-	return &MockURLService_GetLongURLByAlias_Call{Call: _e.mock.On("GetLongURLByAlias", ctx, alias)}
+func (_e *MockURLService_Expecter) Follow(ctx interface{}, domain interface{}, alias interface{}) *MockURLService_Follow_Call {
+	return &MockURLService_Follow_Call{Call: _e.mock.On("Follow", ctx, domain, alias)}
 }
 
-func (_c *MockURLService_GetLongURLByAlias_Call) Run(run func(ctx context.Context, alias string)) *MockURLService_GetLongURLByAlias_Call {
+func (_c *MockURLService_Follow_Call) Run(run func(ctx context.Context, domain string, alias string)) *MockURLService_Follow_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -151,20 +207,99 @@ func (_c *MockURLService_GetLongURLByAlias_Call) Run(run func(ctx context.Contex
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
 		run(
 			arg0,
 			arg1,
+			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *MockURLService_GetLongURLByAlias_Call) Return(s string, err error) *MockURLService_GetLongURLByAlias_Call {
-	_c.Call.Return(s, err)
+func (_c *MockURLService_Follow_Call) Return(uRL *model.URL, err error) *MockURLService_Follow_Call {
+	_c.Call.Return(uRL, err)
+	return _c
+}
+
+func (_c *MockURLService_Follow_Call) RunAndReturn(run func(ctx context.Context, domain string, alias string) (*model.URL, error)) *MockURLService_Follow_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Resolve provides a mock function for the type MockURLService
+func (_mock *MockURLService) Resolve(ctx context.Context, domain string, alias string) (*model.URL, error) {
+	ret := _mock.Called(ctx, domain, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Resolve")
+	}
+
+	var r0 *model.URL
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*model.URL, error)); ok {
+		return returnFunc(ctx, domain, alias)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *model.URL); ok {
+		r0 = returnFunc(ctx, domain, alias)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.URL)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, domain, alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockURLService_Resolve_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Resolve'
+type MockURLService_Resolve_Call struct {
+	*mock.Call
+}
+
+// Resolve is a helper method to define mock.On call
+//   - ctx context.Context
+//   - domain string
+//   - alias string
+func (_e *MockURLService_Expecter) Resolve(ctx interface{}, domain interface{}, alias interface{}) *MockURLService_Resolve_Call {
+	return &MockURLService_Resolve_Call{Call: _e.mock.On("Resolve", ctx, domain, alias)}
+}
+
+func (_c *MockURLService_Resolve_Call) Run(run func(ctx context.Context, domain string, alias string)) *MockURLService_Resolve_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockURLService_Resolve_Call) Return(uRL *model.URL, err error) *MockURLService_Resolve_Call {
+	_c.Call.Return(uRL, err)
 	return _c
 }
 
-func (_c *MockURLService_GetLongURLByAlias_Call) RunAndReturn(run func(ctx context.Context, alias string) (string, error)) *MockURLService_GetLongURLByAlias_Call {
+func (_c *MockURLService_Resolve_Call) RunAndReturn(run func(ctx context.Context, domain string, alias string) (*model.URL, error)) *MockURLService_Resolve_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -0,0 +1,211 @@
+// Code generated by mockery; DO NOT EDIT.
+// github.com/vektra/mockery
+// template: testify
+
+package mocks
+
+import (
+	"context"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// NewMockWebhookService creates a new instance of MockWebhookService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockWebhookService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockWebhookService {
+	mock := &MockWebhookService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockWebhookService is an autogenerated mock type for the WebhookService type
+type MockWebhookService struct {
+	mock.Mock
+}
+
+type MockWebhookService_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockWebhookService) EXPECT() *MockWebhookService_Expecter {
+	return &MockWebhookService_Expecter{mock: &_m.Mock}
+}
+
+// Deliveries provides a mock function for the type MockWebhookService
+func (_mock *MockWebhookService) Deliveries(ctx context.Context, f repository.DeliveryFilter) ([]*model.Delivery, error) {
+	ret := _mock.Called(ctx, f)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Deliveries")
+	}
+
+	var r0 []*model.Delivery
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, repository.DeliveryFilter) ([]*model.Delivery, error)); ok {
+		return returnFunc(ctx, f)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, repository.DeliveryFilter) []*model.Delivery); ok {
+		r0 = returnFunc(ctx, f)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Delivery)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, repository.DeliveryFilter) error); ok {
+		r1 = returnFunc(ctx, f)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockWebhookService_Deliveries_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Deliveries'
+type MockWebhookService_Deliveries_Call struct {
+	*mock.Call
+}
+
+// Deliveries is a helper method to define mock.On call
+//   - ctx context.Context
+//   - f repository.DeliveryFilter
+func (_e *MockWebhookService_Expecter) Deliveries(ctx interface{}, f interface{}) *MockWebhookService_Deliveries_Call {
+	return &MockWebhookService_Deliveries_Call{Call: _e.mock.On("Deliveries", ctx, f)}
+}
+
+func (_c *MockWebhookService_Deliveries_Call) Run(run func(ctx context.Context, f repository.DeliveryFilter)) *MockWebhookService_Deliveries_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 repository.DeliveryFilter
+		if args[1] != nil {
+			arg1 = args[1].(repository.DeliveryFilter)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockWebhookService_Deliveries_Call) Return(deliverys []*model.Delivery, err error) *MockWebhookService_Deliveries_Call {
+	_c.Call.Return(deliverys, err)
+	return _c
+}
+
+func (_c *MockWebhookService_Deliveries_Call) RunAndReturn(run func(ctx context.Context, f repository.DeliveryFilter) ([]*model.Delivery, error)) *MockWebhookService_Deliveries_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Endpoints provides a mock function for the type MockWebhookService
+func (_mock *MockWebhookService) Endpoints() []model.Webhook {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Endpoints")
+	}
+
+	var r0 []model.Webhook
+	if returnFunc, ok := ret.Get(0).(func() []model.Webhook); ok {
+		r0 = returnFunc()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Webhook)
+		}
+	}
+	return r0
+}
+
+// MockWebhookService_Endpoints_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Endpoints'
+type MockWebhookService_Endpoints_Call struct {
+	*mock.Call
+}
+
+// Endpoints is a helper method to define mock.On call
+func (_e *MockWebhookService_Expecter) Endpoints() *MockWebhookService_Endpoints_Call {
+	return &MockWebhookService_Endpoints_Call{Call: _e.mock.On("Endpoints")}
+}
+
+func (_c *MockWebhookService_Endpoints_Call) Run(run func()) *MockWebhookService_Endpoints_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockWebhookService_Endpoints_Call) Return(webhooks []model.Webhook) *MockWebhookService_Endpoints_Call {
+	_c.Call.Return(webhooks)
+	return _c
+}
+
+func (_c *MockWebhookService_Endpoints_Call) RunAndReturn(run func() []model.Webhook) *MockWebhookService_Endpoints_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Replay provides a mock function for the type MockWebhookService
+func (_mock *MockWebhookService) Replay(ctx context.Context, id int64) error {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Replay")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockWebhookService_Replay_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Replay'
+type MockWebhookService_Replay_Call struct {
+	*mock.Call
+}
+
+// Replay is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int64
+func (_e *MockWebhookService_Expecter) Replay(ctx interface{}, id interface{}) *MockWebhookService_Replay_Call {
+	return &MockWebhookService_Replay_Call{Call: _e.mock.On("Replay", ctx, id)}
+}
+
+func (_c *MockWebhookService_Replay_Call) Run(run func(ctx context.Context, id int64)) *MockWebhookService_Replay_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int64
+		if args[1] != nil {
+			arg1 = args[1].(int64)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockWebhookService_Replay_Call) Return(err error) *MockWebhookService_Replay_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockWebhookService_Replay_Call) RunAndReturn(run func(ctx context.Context, id int64) error) *MockWebhookService_Replay_Call {
+	_c.Call.Return(run)
+	return _c
+}
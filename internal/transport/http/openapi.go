@@ -0,0 +1,172 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Rasulikus/url-shortener/internal/apperr"
+	"github.com/Rasulikus/url-shortener/internal/utils/requestid"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// OpenAPISpec отдаёт документ спецификации API.
+func OpenAPISpec(spec []byte) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+	}
+}
+
+// ValidateOpenAPI проверяет запросы и ответы по спецификации doc. Запрос, который ей
+// не соответствует, отклоняется с 400, а несоответствие ответа только логируется:
+// он уже отправлен клиенту. Ответ целиком копируется в память, поэтому проверка
+// включается только в режиме разработки.
+func ValidateOpenAPI(doc *openapi3.T) gin.HandlerFunc {
+	// Токен проверяет AdminAuth, спецификация только описывает схему авторизации
+	reqOpts := &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc}
+	respOpts := &openapi3filter.Options{IncludeResponseStatus: true}
+
+	return func(c *gin.Context) {
+		route := openAPIRoute(doc, c)
+		if route == nil {
+			c.Next()
+			return
+		}
+
+		params := make(map[string]string, len(c.Params))
+		for _, p := range c.Params {
+			params[p.Key] = p.Value
+		}
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: params,
+			Route:      route,
+			Options:    reqOpts,
+		}
+
+		ctx := c.Request.Context()
+		if err := openapi3filter.ValidateRequest(ctx, input); err != nil {
+			log.Warn().
+				Err(err).
+				Str("request_id", requestid.FromContext(ctx)).
+				Str("route", c.Request.Method+" "+c.FullPath()).
+				Msg("request does not match OpenAPI spec")
+
+			ErrorToHttp(c, openAPIError(err))
+			return
+		}
+
+		rec := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+		c.Next()
+
+		err := openapi3filter.ValidateResponse(ctx, &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 rec.Status(),
+			Header:                 rec.Header(),
+			Body:                   io.NopCloser(&rec.body),
+			Options:                respOpts,
+		})
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("request_id", requestid.FromContext(ctx)).
+				Str("route", c.Request.Method+" "+c.FullPath()).
+				Int("status", rec.Status()).
+				Msg("response does not match OpenAPI spec")
+		}
+	}
+}
+
+// openAPIError описывает ошибку проверки запроса по спецификации: параметр или поле
+// тела, если их удалось определить, и причину.
+func openAPIError(err error) error {
+	e := &apperr.Error{
+		Code:    apperr.CodeInvalidInput,
+		Message: "request does not match the API specification",
+		Err:     ErrInvalidInput,
+	}
+	reason := err.Error()
+
+	var reqErr *openapi3filter.RequestError
+	if errors.As(err, &reqErr) {
+		var parseErr *openapi3filter.ParseError
+		switch {
+		case reqErr.Parameter != nil:
+			e.Code, e.Field = apperr.CodeInvalidParameter, reqErr.Parameter.Name
+		case reqErr.RequestBody != nil && errors.As(err, &parseErr):
+			e.Code, e.Message = apperr.CodeInvalidJSON, "request body is not valid JSON"
+		}
+		if reqErr.Reason != "" {
+			reason = reqErr.Reason
+		}
+	}
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		if e.Field == "" {
+			e.Field = strings.Join(schemaErr.JSONPointer(), ".")
+		}
+		reason = schemaErr.Reason
+	}
+
+	e.Details = map[string]any{"reason": reason}
+	return e
+}
+
+// openAPIRoute находит операцию спецификации по маршруту gin. nil — маршрут не найден
+// или не описан в спецификации; второе логируется.
+func openAPIRoute(doc *openapi3.T, c *gin.Context) *routers.Route {
+	if c.FullPath() == "" {
+		return nil
+	}
+
+	path := OpenAPIPath(c.FullPath())
+	item := doc.Paths.Value(path)
+	if item == nil || item.GetOperation(c.Request.Method) == nil {
+		log.Error().
+			Str("route", c.Request.Method+" "+c.FullPath()).
+			Msg("route is not described in OpenAPI spec")
+		return nil
+	}
+
+	return &routers.Route{
+		Spec:      doc,
+		Path:      path,
+		PathItem:  item,
+		Method:    c.Request.Method,
+		Operation: item.GetOperation(c.Request.Method),
+	}
+}
+
+// OpenAPIPath переводит шаблон пути gin в шаблон OpenAPI: /api/:alias -> /api/{alias}.
+func OpenAPIPath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, p := range parts {
+		if strings.HasPrefix(p, ":") || strings.HasPrefix(p, "*") {
+			parts[i] = "{" + p[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// bodyRecorder копирует тело ответа для проверки после обработчика.
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
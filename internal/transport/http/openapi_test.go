@@ -0,0 +1,190 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Rasulikus/url-shortener/api/openapi"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	"github.com/Rasulikus/url-shortener/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPIPath(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"/", "/"},
+		{"/api", "/api"},
+		{"/api/:alias", "/api/{alias}"},
+		{"/admin/webhooks/deliveries/:id/replay", "/admin/webhooks/deliveries/{id}/replay"},
+		{"/static/*path", "/static/{path}"},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, OpenAPIPath(tc.in))
+	}
+}
+
+func TestValidateOpenAPI(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		resp        any
+		err         error
+		wantCalled  bool
+		wantStatus  int
+		wantCode    string
+		wantField   string
+		wantLog     string
+	}{
+		{
+			name:        "ok",
+			contentType: "application/json",
+			body:        `{"long_url":"https://example.com"}`,
+			resp:        CreateUrlResponse{ShortUrl: "http://localhost/aa"},
+			wantCalled:  true,
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "missing long_url",
+			contentType: "application/json",
+			body:        `{"domain":"brand.link"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    "invalid_input",
+			wantField:   "long_url",
+			wantLog:     "request does not match OpenAPI spec",
+		},
+		{
+			name:        "invalid json",
+			contentType: "application/json",
+			body:        "{",
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    "invalid_json",
+			wantLog:     "request does not match OpenAPI spec",
+		},
+		{
+			name:        "wrong content type",
+			contentType: "text/plain",
+			body:        `{"long_url":"https://example.com"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    "invalid_input",
+			wantLog:     "request does not match OpenAPI spec",
+		},
+		{
+			name:        "problem response",
+			contentType: "application/json",
+			body:        `{"long_url":"https://example.com"}`,
+			err:         fmt.Errorf("%w: %w", service.ErrConflict, repository.ErrLongURLConflict),
+			wantCalled:  true,
+			wantStatus:  http.StatusConflict,
+			wantCode:    "long_url_conflict",
+			wantField:   "long_url",
+		},
+		{
+			name:        "response mismatch",
+			contentType: "application/json",
+			body:        `{"long_url":"https://example.com"}`,
+			resp:        gin.H{"url": "http://localhost/aa"},
+			wantCalled:  true,
+			wantStatus:  http.StatusOK,
+			wantLog:     "response does not match OpenAPI spec",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			prev := log.Logger
+			log.Logger = zerolog.New(&logs)
+			t.Cleanup(func() { log.Logger = prev })
+
+			called := false
+			r := gin.New()
+			r.Use(ValidateOpenAPI(doc))
+			r.POST("/api", func(c *gin.Context) {
+				called = true
+				if tc.err != nil {
+					ErrorToHttp(c, tc.err)
+					return
+				}
+				c.JSON(http.StatusOK, tc.resp)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, tc.wantStatus, w.Code)
+			assert.Equal(t, tc.wantCalled, called)
+			if tc.wantCode != "" {
+				var p problem
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+				assert.Equal(t, tc.wantCode, string(p.Code))
+				assert.Equal(t, tc.wantField, p.Field)
+			}
+			if tc.wantLog == "" {
+				assert.Empty(t, logs.String())
+			} else {
+				assert.Contains(t, logs.String(), tc.wantLog)
+			}
+		})
+	}
+}
+
+func TestValidateOpenAPI_UndocumentedRoute(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	var logs bytes.Buffer
+	prev := log.Logger
+	log.Logger = zerolog.New(&logs)
+	t.Cleanup(func() { log.Logger = prev })
+
+	r := gin.New()
+	r.Use(ValidateOpenAPI(doc))
+	r.GET("/undocumented", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/undocumented", nil))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Contains(t, logs.String(), "route is not described in OpenAPI spec")
+}
+
+func TestValidateOpenAPI_HTML(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	var logs bytes.Buffer
+	prev := log.Logger
+	log.Logger = zerolog.New(&logs)
+	t.Cleanup(func() { log.Logger = prev })
+
+	r := gin.New()
+	r.Use(ValidateOpenAPI(doc))
+	r.GET("/:alias", func(c *gin.Context) {
+		c.Data(http.StatusNotFound, "text/html; charset=utf-8", []byte("<h1>Not found</h1>"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/aa", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, logs.String())
+}
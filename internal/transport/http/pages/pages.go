@@ -0,0 +1,119 @@
+// Package pages рендерит HTML-страницы ошибок редиректа для браузеров.
+package pages
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Page — имя файла шаблона страницы. Страница задаёт блоки "title" и "content",
+// которые подставляются в общий шаблон layout.html.
+type Page string
+
+const (
+	NotFound Page = "not_found.html"
+	Disabled Page = "disabled.html"
+	Preview  Page = "preview.html"
+
+	layout = "layout.html"
+)
+
+//go:embed templates/*.html
+var defaults embed.FS
+
+// Data — значения, доступные в шаблонах.
+type Data struct {
+	// Alias — запрошенный алиас, пусто для корня домена.
+	Alias string
+	// ShortURL — запрошенная короткая ссылка.
+	ShortURL string
+	// HomeURL — адрес сайта домена из его root redirect, может быть пустым.
+	HomeURL string
+
+	// LongURL и CreatedAt — адрес назначения и дата создания ссылки, только на странице предпросмотра.
+	LongURL   string
+	CreatedAt time.Time
+	// FollowURL — адрес кнопки «Продолжить»: короткая ссылка, по которой переход засчитывается.
+	FollowURL string
+	// Title и Description — заголовок и описание страницы назначения, если они уже загружены.
+	Title       string
+	Description string
+}
+
+type Pages struct {
+	pages map[Page]*template.Template
+}
+
+// New загружает шаблоны. Файлы из dir с теми же именами заменяют встроенные,
+// остальные берутся из бинарника. Пустой dir — только встроенные шаблоны.
+func New(dir string) (*Pages, error) {
+	if dir != "" {
+		if st, err := os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("pages: %w", err)
+		} else if !st.IsDir() {
+			return nil, fmt.Errorf("pages: %s is not a directory", dir)
+		}
+	}
+
+	base, err := parse(template.New(""), dir, layout)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Pages{pages: make(map[Page]*template.Template)}
+	for _, page := range []Page{NotFound, Disabled, Preview} {
+		t, err := base.Clone()
+		if err != nil {
+			return nil, fmt.Errorf("pages: %w", err)
+		}
+		if p.pages[page], err = parse(t, dir, string(page)); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// parse добавляет в t шаблон name из dir или встроенный.
+func parse(t *template.Template, dir, name string) (*template.Template, error) {
+	var (
+		b   []byte
+		err = fs.ErrNotExist
+	)
+	if dir != "" {
+		b, err = os.ReadFile(filepath.Join(dir, name))
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		b, err = defaults.ReadFile("templates/" + name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("pages: %w", err)
+	}
+
+	if _, err := t.New(name).Parse(string(b)); err != nil {
+		return nil, fmt.Errorf("pages: %w", err)
+	}
+	return t, nil
+}
+
+// Render возвращает готовую страницу. Страница рендерится целиком,
+// чтобы ошибка шаблона не оставила клиенту половину ответа.
+func (p *Pages) Render(page Page, d Data) ([]byte, error) {
+	t, ok := p.pages[page]
+	if !ok {
+		return nil, fmt.Errorf("pages: unknown page %q", page)
+	}
+
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, layout, d); err != nil {
+		return nil, fmt.Errorf("pages: render %s: %w", page, err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,87 @@
+package pages
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPages_RenderDefaults(t *testing.T) {
+	p, err := New("")
+	require.NoError(t, err)
+
+	body, err := p.Render(NotFound, Data{
+		Alias:    "aa",
+		ShortURL: "https://sho.rt/aa",
+		HomeURL:  "https://example.com",
+	})
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "<code>https://sho.rt/aa</code>")
+	assert.Contains(t, string(body), `href="https://example.com"`)
+
+	body, err = p.Render(Disabled, Data{ShortURL: "https://sho.rt/<b>"})
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "https://sho.rt/&lt;b&gt;", "values must be escaped")
+	assert.NotContains(t, string(body), "На главную")
+}
+
+func TestPages_RenderPreview(t *testing.T) {
+	p, err := New("")
+	require.NoError(t, err)
+
+	body, err := p.Render(Preview, Data{
+		ShortURL:  "https://sho.rt/aa",
+		LongURL:   "https://example.com/?q=1",
+		FollowURL: "https://sho.rt/aa?follow=1",
+		CreatedAt: time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "<code>https://example.com/?q=1</code>")
+	assert.Contains(t, string(body), `href="https://sho.rt/aa?follow=1"`)
+	assert.Contains(t, string(body), "15.03.2024")
+	assert.NotContains(t, string(body), "<strong>")
+
+	body, err = p.Render(Preview, Data{LongURL: "https://example.com", Title: "Example <Domain>", Description: "About"})
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "<strong>Example &lt;Domain&gt;</strong>")
+	assert.Contains(t, string(body), "<p>About</p>")
+
+	// Небезопасная схема в адресе назначения не попадает в ссылку
+	body, err = p.Render(Preview, Data{LongURL: "javascript:alert(1)"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), `href="javascript:`)
+}
+
+func TestPages_Override(t *testing.T) {
+	dir := t.TempDir()
+	page := `{{define "title"}}Gone{{end}}{{define "content"}}<h1>missing {{.Alias}}</h1>{{end}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, string(NotFound)), []byte(page), 0o644))
+
+	p, err := New(dir)
+	require.NoError(t, err)
+
+	// Заменённая страница подставляется во встроенный layout
+	body, err := p.Render(NotFound, Data{Alias: "aa"})
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "<title>Gone</title>")
+	assert.Contains(t, string(body), "<h1>missing aa</h1>")
+
+	// Остальные страницы остаются встроенными
+	body, err = p.Render(Disabled, Data{})
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "Ссылка недоступна")
+}
+
+func TestNew_Invalid(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, layout), []byte("{{if}}"), 0o644))
+	_, err = New(dir)
+	require.Error(t, err)
+}
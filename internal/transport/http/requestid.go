@@ -0,0 +1,19 @@
+package http
+
+import (
+	"github.com/Rasulikus/url-shortener/internal/utils/requestid"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestID берёт идентификатор запроса из X-Request-ID или создаёт новый,
+// кладёт его в контекст запроса и возвращает в том же заголовке ответа.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := requestid.FromClient(c.GetHeader(requestid.Header))
+
+		c.Request = c.Request.WithContext(requestid.With(c.Request.Context(), id))
+		c.Header(requestid.Header, id)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Rasulikus/url-shortener/internal/utils/requestid"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{"from client", "req-42", true},
+		{"generated", "", false},
+		{"invalid", "bad id", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var seen string
+			r := gin.New()
+			r.Use(RequestID())
+			r.GET("/", func(c *gin.Context) {
+				seen = requestid.FromContext(c.Request.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				req.Header.Set(requestid.Header, tc.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.NotEmpty(t, seen)
+			assert.Equal(t, seen, w.Header().Get(requestid.Header))
+			if tc.keep {
+				assert.Equal(t, tc.header, seen)
+			} else {
+				assert.NotEqual(t, tc.header, seen)
+			}
+		})
+	}
+}
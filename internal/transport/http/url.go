@@ -4,33 +4,52 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"github.com/Rasulikus/url-shortener/internal/apperr"
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/service"
+	"github.com/Rasulikus/url-shortener/internal/transport/http/pages"
+	"github.com/Rasulikus/url-shortener/internal/utils/domains"
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
 )
 
 type URLService interface {
-	// CreateOrGet создаёт короткую ссылку для longURL или возвращает уже существующую.
-	CreateOrGet(ctx context.Context, longURL string) (string, error)
+	// CreateOrGet создаёт короткую ссылку для longURL на домене или возвращает уже существующую.
+	CreateOrGet(ctx context.Context, domain, longURL string) (string, error)
 
-	// GetLongURLByAlias возвращает исходный longURL по алиасу.
-	GetLongURLByAlias(ctx context.Context, alias string) (string, error)
+	// Resolve возвращает запись по алиасу на домене, в том числе отключённую.
+	Resolve(ctx context.Context, domain, alias string) (*model.URL, error)
+
+	// Follow возвращает запись для перехода по алиасу на домене, не засчитывая переход.
+	Follow(ctx context.Context, domain, alias string) (*model.URL, error)
+
+	// Click засчитывает переход по ссылке u.
+	Click(ctx context.Context, u *model.URL)
 }
 
 var ErrInvalidInput = errors.New("invalid input")
 
 type URLHandler struct {
-	s URLService
+	s       URLService
+	domains *domains.Registry
+	pages   *pages.Pages
 }
 
-func NewURLHandler(s URLService) *URLHandler {
+func NewURLHandler(s URLService, domains *domains.Registry, pages *pages.Pages) *URLHandler {
 	return &URLHandler{
-		s: s,
+		s:       s,
+		domains: domains,
+		pages:   pages,
 	}
 }
 
 type CreateUrlRequest struct {
 	LongURL string `json:"long_url" binding:"required"`
+	// Domain — короткий домен ссылки, пусто — домен по умолчанию.
+	Domain string `json:"domain"`
 }
 
 type CreateUrlResponse struct {
@@ -40,11 +59,11 @@ type CreateUrlResponse struct {
 func (h *URLHandler) Create(c *gin.Context) {
 	var req CreateUrlRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		ErrorToHttp(c, ErrInvalidInput)
+		ErrorToHttp(c, bindError(&req, err))
 		return
 	}
 
-	sUrl, err := h.s.CreateOrGet(c.Request.Context(), req.LongURL)
+	sUrl, err := h.s.CreateOrGet(c.Request.Context(), req.Domain, req.LongURL)
 	if err != nil {
 		ErrorToHttp(c, err)
 		return
@@ -55,37 +74,136 @@ func (h *URLHandler) Create(c *gin.Context) {
 
 type getURLResponse struct {
 	LongURL string `json:"long_url"`
+	// Health — результат последней проверки доступности, нет — ссылка ещё не проверялась.
+	Health *model.Health `json:"health,omitempty"`
 }
 
 func (h *URLHandler) GetLongURLByAlias(c *gin.Context) {
 	alias := strings.TrimSpace(c.Param("alias"))
 	if alias == "" {
-		ErrorToHttp(c, ErrInvalidInput)
+		ErrorToHttp(c, invalidParam(apperr.CodeMissingField, "alias", "alias is required"))
 		return
 	}
 
-	url, err := h.s.GetLongURLByAlias(c.Request.Context(), alias)
+	u, err := h.s.Resolve(c.Request.Context(), c.Query("domain"), alias)
+	if err == nil && u.Disabled {
+		err = service.ErrDisabled
+	}
 	if err != nil {
 		ErrorToHttp(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, getURLResponse{
-		LongURL: url,
+		LongURL: u.LongURL,
+		Health:  u.Health,
 	})
 }
 
+// Redirect перенаправляет по алиасу. Домен определяется по заголовку Host.
+// Алиас с суффиксом "+" или параметр preview=1 показывают страницу предпросмотра
+// вместо редиректа; для ссылок с флагом interstitial она показывается всегда.
+// Показ предпросмотра не считается переходом: кнопка «Продолжить» ведёт обратно
+// на короткую ссылку с follow=1, и переход засчитывается уже при редиректе.
 func (h *URLHandler) Redirect(c *gin.Context) {
-	alias := strings.TrimSpace(c.Param("alias"))
+	alias, preview := strings.CutSuffix(strings.TrimSpace(c.Param("alias")), "+")
 	if alias == "" {
-		ErrorToHttp(c, ErrInvalidInput)
+		ErrorToHttp(c, invalidParam(apperr.CodeMissingField, "alias", "alias is required"))
 		return
 	}
+	if !preview {
+		preview, _ = strconv.ParseBool(c.Query("preview"))
+	}
+	follow, _ := strconv.ParseBool(c.Query("follow"))
 
-	longURL, err := h.s.GetLongURLByAlias(c.Request.Context(), alias)
+	domain := h.domains.Resolve(c.Request.Host)
+	u, err := h.s.Follow(c.Request.Context(), domain, alias)
 	if err != nil {
+		h.redirectError(c, domain, alias, err)
+		return
+	}
+
+	if !follow && (preview || u.Interstitial) {
+		d, _ := h.domains.Lookup(domain)
+		shortURL := h.domains.ShortURL(domain, alias)
+		data := pages.Data{
+			Alias:     alias,
+			ShortURL:  shortURL,
+			HomeURL:   d.RootRedirect,
+			LongURL:   u.LongURL,
+			FollowURL: shortURL + "?follow=1",
+			CreatedAt: u.CreatedAt,
+		}
+		if u.Meta != nil {
+			data.Title, data.Description = u.Meta.Title, u.Meta.Description
+		}
+		h.page(c, http.StatusOK, pages.Preview, data)
+		return
+	}
+
+	h.s.Click(c.Request.Context(), u)
+	c.Redirect(http.StatusFound, u.LongURL)
+}
+
+// Root перенаправляет запрос к корню домена на его адрес по умолчанию, если он задан.
+func (h *URLHandler) Root(c *gin.Context) {
+	domain := h.domains.Resolve(c.Request.Host)
+	d, _ := h.domains.Lookup(domain)
+	if d.RootRedirect == "" {
+		h.redirectError(c, domain, "", service.ErrNotFound)
+		return
+	}
+
+	c.Redirect(http.StatusFound, d.RootRedirect)
+}
+
+// redirectError отвечает на ошибку редиректа. API-клиенты получают JSON, браузеры —
+// HTML-страницу или, если для домена задан адрес для неизвестных алиасов, редирект на него.
+func (h *URLHandler) redirectError(c *gin.Context, domain, alias string, err error) {
+	if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) != gin.MIMEHTML {
+		ErrorToHttp(c, err)
+		return
+	}
+
+	d, _ := h.domains.Lookup(domain)
+
+	var (
+		page   pages.Page
+		status int
+	)
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		if d.NotFoundRedirect != "" {
+			c.Redirect(http.StatusFound, d.NotFoundRedirect)
+			return
+		}
+		page, status = pages.NotFound, http.StatusNotFound
+	case errors.Is(err, service.ErrDisabled):
+		page, status = pages.Disabled, http.StatusGone
+	default:
+		ErrorToHttp(c, err)
+		return
+	}
+
+	h.page(c, status, page, pages.Data{
+		Alias:    alias,
+		ShortURL: h.domains.ShortURL(domain, alias),
+		HomeURL:  d.RootRedirect,
+	})
+}
+
+// page отвечает HTML-страницей.
+func (h *URLHandler) page(c *gin.Context, status int, page pages.Page, d pages.Data) {
+	body, err := h.pages.Render(page, d)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("page", string(page)).
+			Msg("failed to render page")
+
 		ErrorToHttp(c, err)
 		return
 	}
 
-	c.Redirect(http.StatusFound, longURL)
+	c.Data(status, "text/html; charset=utf-8", body)
+	c.Abort()
 }
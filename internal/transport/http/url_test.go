@@ -2,34 +2,62 @@ package http
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
 	"github.com/Rasulikus/url-shortener/internal/service"
 	"github.com/Rasulikus/url-shortener/internal/transport/http/mocks"
+	"github.com/Rasulikus/url-shortener/internal/transport/http/pages"
+	"github.com/Rasulikus/url-shortener/internal/utils/domains"
+	"github.com/Rasulikus/url-shortener/internal/utils/validate"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
+func newTestDomains(t *testing.T) *domains.Registry {
+	t.Helper()
+
+	reg, err := domains.New("http://localhost:8080", domains.Domain{RootRedirect: "https://home.example.com"}, []domains.Domain{
+		{Name: "brand.link", RootRedirect: "https://brand.com", NotFoundRedirect: "https://brand.com/missing"},
+		{Name: "go.link"},
+	})
+	require.NoError(t, err)
+	return reg
+}
+
+func newTestPages(t *testing.T) *pages.Pages {
+	t.Helper()
+
+	p, err := pages.New("")
+	require.NoError(t, err)
+	return p
+}
+
 func setupRouter(h *URLHandler) *gin.Engine {
 	r := gin.New()
+	r.GET("/", h.Root)
 	r.POST("/api", h.Create)
 	r.GET("/api/:alias", h.GetLongURLByAlias)
 	r.GET("/:alias", h.Redirect)
 	return r
 }
+
 func TestURLHandler_Create_OK(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		s := mocks.NewMockURLService(t)
 
-		s.On("CreateOrGet", mock.Anything, "http://example.com").
+		s.On("CreateOrGet", mock.Anything, "", "http://example.com").
 			Return("http://localhost:8080/aa", nil).
 			Once()
 
-		h := NewURLHandler(s)
+		h := NewURLHandler(s, newTestDomains(t), newTestPages(t))
 		r := setupRouter(h)
 
 		req := httptest.NewRequest(http.MethodPost, "/api", strings.NewReader(`{"long_url":"http://example.com"}`))
@@ -48,24 +76,43 @@ func TestURLHandler_Create_OK(t *testing.T) {
 func TestURLHandler_Create_InvalidJSON(t *testing.T) {
 	s := mocks.NewMockURLService(t)
 
-	h := NewURLHandler(s)
+	h := NewURLHandler(s, newTestDomains(t), newTestPages(t))
 	r := setupRouter(h)
 
 	cases := []struct {
-		name string
-		body string
+		name     string
+		body     string
+		wantBody string
 	}{
 		{
-			"invalid json",
+			"missing field",
 			`{"lon":""}`,
+			`{"type":"about:blank","title":"Bad Request","status":400,"instance":"/api",
+				"code":"missing_field","field":"long_url","detail":"field is required"}`,
 		},
 		{
-			"invalid json value",
+			"empty field",
 			`{"long_url":""}`,
+			`{"type":"about:blank","title":"Bad Request","status":400,"instance":"/api",
+				"code":"missing_field","field":"long_url","detail":"field is required"}`,
+		},
+		{
+			"wrong type",
+			`{"long_url":1}`,
+			`{"type":"about:blank","title":"Bad Request","status":400,"instance":"/api",
+				"code":"invalid_input","field":"long_url","detail":"must be string"}`,
 		},
 		{
 			"invalid json syntax",
+			`{"long_url":}`,
+			`{"type":"about:blank","title":"Bad Request","status":400,"instance":"/api",
+				"code":"invalid_json","detail":"request body is not valid JSON","details":{"offset":13}}`,
+		},
+		{
+			"truncated json",
 			"{",
+			`{"type":"about:blank","title":"Bad Request","status":400,"instance":"/api",
+				"code":"invalid_json","detail":"request body is not valid JSON"}`,
 		},
 	}
 
@@ -78,7 +125,8 @@ func TestURLHandler_Create_InvalidJSON(t *testing.T) {
 			r.ServeHTTP(w, req)
 
 			require.Equal(t, http.StatusBadRequest, w.Code)
-			require.JSONEq(t, `{"error":"invalid input"}`, w.Body.String())
+			require.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+			require.JSONEq(t, tc.wantBody, w.Body.String())
 
 			s.AssertNotCalled(t, "CreateOrGet")
 		})
@@ -86,14 +134,59 @@ func TestURLHandler_Create_InvalidJSON(t *testing.T) {
 }
 
 func TestURLHandler_Create_ServiceInvalidInput(t *testing.T) {
-	t.Run("invalid input", func(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		wantBody string
+	}{
+		{
+			name: "invalid input",
+			err:  service.ErrInvalidInput,
+			wantBody: `{"type":"about:blank","title":"Bad Request","status":400,"instance":"/api",
+				"code":"invalid_input","detail":"invalid input"}`,
+		},
+		{
+			name: "app error",
+			err:  service.InvalidField("long_url", validate.URL("example.com")),
+			wantBody: `{"type":"about:blank","title":"Bad Request","status":400,"instance":"/api",
+				"code":"invalid_url_scheme","field":"long_url","detail":"url must start with a scheme such as https://"}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := mocks.NewMockURLService(t)
+
+			s.On("CreateOrGet", mock.Anything, "", "http://example.com").
+				Return("", tc.err).
+				Once()
+
+			h := NewURLHandler(s, newTestDomains(t), newTestPages(t))
+			r := setupRouter(h)
+
+			req := httptest.NewRequest(http.MethodPost, "/api", strings.NewReader(`{"long_url":"http://example.com"}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusBadRequest, w.Code)
+			require.JSONEq(t, tc.wantBody, w.Body.String())
+
+			s.AssertExpectations(t)
+		})
+	}
+}
+
+func TestURLHandler_Create_AliasCollision(t *testing.T) {
+	t.Run("alias collision", func(t *testing.T) {
 		s := mocks.NewMockURLService(t)
 
-		s.On("CreateOrGet", mock.Anything, "http://example.com").
-			Return("", service.ErrInvalidInput).
+		s.On("CreateOrGet", mock.Anything, "", "http://example.com").
+			Return("", service.ErrConflict).
 			Once()
 
-		h := NewURLHandler(s)
+		h := NewURLHandler(s, newTestDomains(t), newTestPages(t))
 		r := setupRouter(h)
 
 		req := httptest.NewRequest(http.MethodPost, "/api", strings.NewReader(`{"long_url":"http://example.com"}`))
@@ -102,22 +195,21 @@ func TestURLHandler_Create_ServiceInvalidInput(t *testing.T) {
 
 		r.ServeHTTP(w, req)
 
-		require.Equal(t, http.StatusBadRequest, w.Code)
-		require.JSONEq(t, `{"error":"invalid input"}`, w.Body.String())
+		require.Equal(t, http.StatusConflict, w.Code)
+		require.JSONEq(t, `{"type":"about:blank","title":"Conflict","status":409,"instance":"/api",
+			"code":"conflict","detail":"conflict"}`, w.Body.String())
 
 		s.AssertExpectations(t)
 	})
-}
 
-func TestURLHandler_Create_AliasCollision(t *testing.T) {
-	t.Run("alias collision", func(t *testing.T) {
+	t.Run("long url conflict", func(t *testing.T) {
 		s := mocks.NewMockURLService(t)
 
-		s.On("CreateOrGet", mock.Anything, "http://example.com").
-			Return("", service.ErrConflict).
+		s.On("CreateOrGet", mock.Anything, "", "http://example.com").
+			Return("", fmt.Errorf("%w: %w", service.ErrConflict, repository.ErrLongURLConflict)).
 			Once()
 
-		h := NewURLHandler(s)
+		h := NewURLHandler(s, newTestDomains(t), newTestPages(t))
 		r := setupRouter(h)
 
 		req := httptest.NewRequest(http.MethodPost, "/api", strings.NewReader(`{"long_url":"http://example.com"}`))
@@ -127,7 +219,8 @@ func TestURLHandler_Create_AliasCollision(t *testing.T) {
 		r.ServeHTTP(w, req)
 
 		require.Equal(t, http.StatusConflict, w.Code)
-		require.JSONEq(t, `{"error":"conflict"}`, w.Body.String())
+		require.JSONEq(t, `{"type":"about:blank","title":"Conflict","status":409,"instance":"/api",
+			"code":"long_url_conflict","field":"long_url","detail":"long_url is already saved under another alias"}`, w.Body.String())
 
 		s.AssertExpectations(t)
 	})
@@ -137,11 +230,11 @@ func TestURLHandler_Create_DefaultError(t *testing.T) {
 	t.Run("default error", func(t *testing.T) {
 		s := mocks.NewMockURLService(t)
 
-		s.On("CreateOrGet", mock.Anything, "http://example.com").
+		s.On("CreateOrGet", mock.Anything, "", "http://example.com").
 			Return("", errors.New("some err")).
 			Once()
 
-		h := NewURLHandler(s)
+		h := NewURLHandler(s, newTestDomains(t), newTestPages(t))
 		r := setupRouter(h)
 
 		req := httptest.NewRequest(http.MethodPost, "/api", strings.NewReader(`{"long_url":"http://example.com"}`))
@@ -151,7 +244,8 @@ func TestURLHandler_Create_DefaultError(t *testing.T) {
 		r.ServeHTTP(w, req)
 
 		require.Equal(t, http.StatusInternalServerError, w.Code)
-		require.JSONEq(t, `{"error":"internal server error"}`, w.Body.String())
+		require.JSONEq(t, `{"type":"about:blank","title":"Internal Server Error","status":500,"instance":"/api",
+			"code":"internal","detail":"internal server error"}`, w.Body.String())
 
 		s.AssertExpectations(t)
 	})
@@ -161,11 +255,11 @@ func TestURLHandler_GetLongURLByAlias_OK(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		s := mocks.NewMockURLService(t)
 
-		s.On("GetLongURLByAlias", mock.Anything, "aa").
-			Return("http://example.com", nil).
+		s.On("Resolve", mock.Anything, "", "aa").
+			Return(&model.URL{LongURL: "http://example.com"}, nil).
 			Once()
 
-		h := NewURLHandler(s)
+		h := NewURLHandler(s, newTestDomains(t), newTestPages(t))
 		r := setupRouter(h)
 
 		req := httptest.NewRequest(http.MethodGet, "/api/aa", nil)
@@ -184,11 +278,11 @@ func TestURLHandler_GetByAlias_ServiceNotFound(t *testing.T) {
 	t.Run("service not found", func(t *testing.T) {
 		s := mocks.NewMockURLService(t)
 
-		s.On("GetLongURLByAlias", mock.Anything, "aa").
-			Return("", service.ErrNotFound).
+		s.On("Resolve", mock.Anything, "", "aa").
+			Return(nil, service.ErrNotFound).
 			Once()
 
-		h := NewURLHandler(s)
+		h := NewURLHandler(s, newTestDomains(t), newTestPages(t))
 		r := setupRouter(h)
 
 		req := httptest.NewRequest(http.MethodGet, "/api/aa", nil)
@@ -197,7 +291,8 @@ func TestURLHandler_GetByAlias_ServiceNotFound(t *testing.T) {
 		r.ServeHTTP(w, req)
 
 		require.Equal(t, http.StatusNotFound, w.Code)
-		require.JSONEq(t, `{"error":"not found"}`, w.Body.String())
+		require.JSONEq(t, `{"type":"about:blank","title":"Not Found","status":404,"instance":"/api/aa",
+			"code":"not_found","detail":"not found"}`, w.Body.String())
 
 		s.AssertExpectations(t)
 	})
@@ -206,11 +301,11 @@ func TestURLHandler_GetByAlias_ServiceNotFound(t *testing.T) {
 func TestURLHandler_GetByAlias_DefaultError(t *testing.T) {
 	t.Run("default error", func(t *testing.T) {
 		s := mocks.NewMockURLService(t)
-		s.On("GetLongURLByAlias", mock.Anything, "aa").
-			Return("", errors.New("some err")).
+		s.On("Resolve", mock.Anything, "", "aa").
+			Return(nil, errors.New("some err")).
 			Once()
 
-		h := NewURLHandler(s)
+		h := NewURLHandler(s, newTestDomains(t), newTestPages(t))
 		r := setupRouter(h)
 
 		req := httptest.NewRequest(http.MethodGet, "/api/aa", nil)
@@ -219,7 +314,8 @@ func TestURLHandler_GetByAlias_DefaultError(t *testing.T) {
 		r.ServeHTTP(w, req)
 
 		require.Equal(t, http.StatusInternalServerError, w.Code)
-		require.JSONEq(t, `{"error":"internal server error"}`, w.Body.String())
+		require.JSONEq(t, `{"type":"about:blank","title":"Internal Server Error","status":500,"instance":"/api/aa",
+			"code":"internal","detail":"internal server error"}`, w.Body.String())
 
 		s.AssertExpectations(t)
 	})
@@ -229,11 +325,15 @@ func TestURLHandler_Redirect_OK(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		s := mocks.NewMockURLService(t)
 
-		s.On("GetLongURLByAlias", mock.Anything, "aa").
-			Return("http://example.com", nil).
+		u := &model.URL{Alias: "aa", LongURL: "http://example.com"}
+		s.On("Follow", mock.Anything, "", "aa").
+			Return(u, nil).
+			Once()
+		s.On("Click", mock.Anything, u).
+			Return().
 			Once()
 
-		h := NewURLHandler(s)
+		h := NewURLHandler(s, newTestDomains(t), newTestPages(t))
 		r := setupRouter(h)
 
 		req := httptest.NewRequest(http.MethodGet, "/aa", nil)
@@ -252,11 +352,11 @@ func TestURLHandler_Redirect_ServiceNotFound(t *testing.T) {
 	t.Run("service not found", func(t *testing.T) {
 		s := mocks.NewMockURLService(t)
 
-		s.On("GetLongURLByAlias", mock.Anything, "aa").
-			Return("", service.ErrNotFound).
+		s.On("Follow", mock.Anything, "", "aa").
+			Return(nil, service.ErrNotFound).
 			Once()
 
-		h := NewURLHandler(s)
+		h := NewURLHandler(s, newTestDomains(t), newTestPages(t))
 		r := setupRouter(h)
 
 		req := httptest.NewRequest(http.MethodGet, "/aa", nil)
@@ -265,7 +365,8 @@ func TestURLHandler_Redirect_ServiceNotFound(t *testing.T) {
 		r.ServeHTTP(w, req)
 
 		require.Equal(t, http.StatusNotFound, w.Code)
-		require.JSONEq(t, `{"error":"not found"}`, w.Body.String())
+		require.JSONEq(t, `{"type":"about:blank","title":"Not Found","status":404,"instance":"/aa",
+			"code":"not_found","detail":"not found"}`, w.Body.String())
 
 		s.AssertExpectations(t)
 	})
@@ -275,11 +376,11 @@ func TestURLHandler_Redirect_DefaultError(t *testing.T) {
 	t.Run("default error", func(t *testing.T) {
 		s := mocks.NewMockURLService(t)
 
-		s.On("GetLongURLByAlias", mock.Anything, "aa").
-			Return("", errors.New("some err")).
+		s.On("Follow", mock.Anything, "", "aa").
+			Return(nil, errors.New("some err")).
 			Once()
 
-		h := NewURLHandler(s)
+		h := NewURLHandler(s, newTestDomains(t), newTestPages(t))
 		r := setupRouter(h)
 
 		req := httptest.NewRequest(http.MethodGet, "/aa", nil)
@@ -288,8 +389,411 @@ func TestURLHandler_Redirect_DefaultError(t *testing.T) {
 		r.ServeHTTP(w, req)
 
 		require.Equal(t, http.StatusInternalServerError, w.Code)
-		require.JSONEq(t, `{"error":"internal server error"}`, w.Body.String())
+		require.JSONEq(t, `{"type":"about:blank","title":"Internal Server Error","status":500,"instance":"/aa",
+			"code":"internal","detail":"internal server error"}`, w.Body.String())
+
+		s.AssertExpectations(t)
+	})
+}
+
+func TestURLHandler_Redirect_ServiceDisabled(t *testing.T) {
+	t.Run("service disabled", func(t *testing.T) {
+		s := mocks.NewMockURLService(t)
+
+		s.On("Follow", mock.Anything, "", "aa").
+			Return(nil, service.ErrDisabled).
+			Once()
+
+		h := NewURLHandler(s, newTestDomains(t), newTestPages(t))
+		r := setupRouter(h)
+
+		req := httptest.NewRequest(http.MethodGet, "/aa", nil)
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusGone, w.Code)
+		require.JSONEq(t, `{"type":"about:blank","title":"Gone","status":410,"instance":"/aa",
+			"code":"disabled","detail":"disabled"}`, w.Body.String())
 
 		s.AssertExpectations(t)
 	})
 }
+
+func TestURLHandler_Create_Domain(t *testing.T) {
+	cases := []struct {
+		name     string
+		ret      string
+		err      error
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "success",
+			ret:      "http://brand.link/aa",
+			wantCode: http.StatusOK,
+			wantBody: `{"short_url":"http://brand.link/aa"}`,
+		},
+		{
+			name:     "unknown domain",
+			err:      service.ErrUnknownDomain,
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"type":"about:blank","title":"Bad Request","status":400,"instance":"/api",
+				"code":"unknown_domain","detail":"unknown domain"}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := mocks.NewMockURLService(t)
+			s.On("CreateOrGet", mock.Anything, "brand.link", "http://example.com").
+				Return(tc.ret, tc.err).
+				Once()
+
+			r := setupRouter(NewURLHandler(s, newTestDomains(t), newTestPages(t)))
+
+			req := httptest.NewRequest(http.MethodPost, "/api", strings.NewReader(`{"long_url":"http://example.com","domain":"brand.link"}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, tc.wantCode, w.Code)
+			require.JSONEq(t, tc.wantBody, w.Body.String())
+		})
+	}
+}
+
+func TestURLHandler_GetLongURLByAlias_Health(t *testing.T) {
+	checkedAt := time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		url      *model.URL
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "checked",
+			url: &model.URL{LongURL: "http://example.com", Health: &model.Health{
+				Status:    http.StatusNotFound,
+				Latency:   150 * time.Millisecond,
+				Redirects: []string{"https://example.com/"},
+				Failures:  3,
+				Broken:    true,
+				CheckedAt: checkedAt,
+			}},
+			wantCode: http.StatusOK,
+			wantBody: `{"long_url":"http://example.com","health":{"status":404,"latency":150000000,
+				"redirects":["https://example.com/"],"failures":3,"broken":true,"checked_at":"2024-03-15T10:00:00Z"}}`,
+		},
+		{
+			name:     "disabled",
+			url:      &model.URL{LongURL: "http://example.com", Disabled: true},
+			wantCode: http.StatusGone,
+			wantBody: `{"type":"about:blank","title":"Gone","status":410,"instance":"/api/aa",
+				"code":"disabled","detail":"disabled"}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := mocks.NewMockURLService(t)
+			s.On("Resolve", mock.Anything, "", "aa").
+				Return(tc.url, nil).
+				Once()
+
+			r := setupRouter(NewURLHandler(s, newTestDomains(t), newTestPages(t)))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/aa", nil)
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, tc.wantCode, w.Code)
+			require.JSONEq(t, tc.wantBody, w.Body.String())
+		})
+	}
+}
+
+func TestURLHandler_GetLongURLByAlias_Domain(t *testing.T) {
+	s := mocks.NewMockURLService(t)
+	s.On("Resolve", mock.Anything, "brand.link", "aa").
+		Return(&model.URL{Domain: "brand.link", LongURL: "http://brand.com"}, nil).
+		Once()
+
+	r := setupRouter(NewURLHandler(s, newTestDomains(t), newTestPages(t)))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/aa?domain=brand.link", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"long_url":"http://brand.com"}`, w.Body.String())
+}
+
+func TestURLHandler_Redirect_Host(t *testing.T) {
+	cases := []struct {
+		name   string
+		host   string
+		domain string
+	}{
+		{"registered domain", "brand.link", "brand.link"},
+		{"registered domain with port", "go.link:8080", "go.link"},
+		{"default domain", "localhost:8080", ""},
+		{"unknown host", "other.com", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := mocks.NewMockURLService(t)
+			u := &model.URL{Alias: "aa", LongURL: "http://example.com"}
+			s.On("Follow", mock.Anything, tc.domain, "aa").
+				Return(u, nil).
+				Once()
+			s.On("Click", mock.Anything, u).
+				Return().
+				Once()
+
+			r := setupRouter(NewURLHandler(s, newTestDomains(t), newTestPages(t)))
+
+			req := httptest.NewRequest(http.MethodGet, "/aa", nil)
+			req.Host = tc.host
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusFound, w.Code)
+			require.Equal(t, "http://example.com", w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestURLHandler_Root(t *testing.T) {
+	cases := []struct {
+		name         string
+		host         string
+		wantCode     int
+		wantLocation string
+	}{
+		{"default domain", "localhost:8080", http.StatusFound, "https://home.example.com"},
+		{"registered domain", "brand.link", http.StatusFound, "https://brand.com"},
+		{"domain without root redirect", "go.link", http.StatusNotFound, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := mocks.NewMockURLService(t)
+			r := setupRouter(NewURLHandler(s, newTestDomains(t), newTestPages(t)))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Host = tc.host
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, tc.wantCode, w.Code)
+			require.Equal(t, tc.wantLocation, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestURLHandler_Redirect_HTML(t *testing.T) {
+	const browser = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+
+	cases := []struct {
+		name         string
+		host         string
+		accept       string
+		err          error
+		wantCode     int
+		wantType     string
+		wantBody     string
+		wantLocation string
+	}{
+		{
+			name:     "not found page",
+			host:     "localhost:8080",
+			accept:   browser,
+			err:      service.ErrNotFound,
+			wantCode: http.StatusNotFound,
+			wantType: "text/html",
+			wantBody: "http://localhost:8080/aa",
+		},
+		{
+			name:     "disabled page",
+			host:     "go.link",
+			accept:   browser,
+			err:      service.ErrDisabled,
+			wantCode: http.StatusGone,
+			wantType: "text/html",
+			wantBody: "http://go.link/aa",
+		},
+		{
+			name:         "not found redirect",
+			host:         "brand.link",
+			accept:       browser,
+			err:          service.ErrNotFound,
+			wantCode:     http.StatusFound,
+			wantLocation: "https://brand.com/missing",
+		},
+		{
+			name:     "disabled ignores not found redirect",
+			host:     "brand.link",
+			accept:   browser,
+			err:      service.ErrDisabled,
+			wantCode: http.StatusGone,
+			wantType: "text/html",
+			wantBody: `href="https://brand.com"`,
+		},
+		{
+			name:     "internal error stays problem",
+			host:     "localhost:8080",
+			accept:   browser,
+			err:      errors.New("some err"),
+			wantCode: http.StatusInternalServerError,
+			wantType: "application/problem+json",
+			wantBody: `"code":"internal"`,
+		},
+		{
+			name:     "api client",
+			host:     "brand.link",
+			accept:   "application/json",
+			err:      service.ErrNotFound,
+			wantCode: http.StatusNotFound,
+			wantType: "application/problem+json",
+			wantBody: `"code":"not_found"`,
+		},
+		{
+			name:     "no accept header",
+			host:     "localhost:8080",
+			err:      service.ErrNotFound,
+			wantCode: http.StatusNotFound,
+			wantType: "application/problem+json",
+			wantBody: `"code":"not_found"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := mocks.NewMockURLService(t)
+			s.On("Follow", mock.Anything, mock.Anything, "aa").
+				Return(nil, tc.err).
+				Once()
+
+			r := setupRouter(NewURLHandler(s, newTestDomains(t), newTestPages(t)))
+
+			req := httptest.NewRequest(http.MethodGet, "/aa", nil)
+			req.Host = tc.host
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, tc.wantCode, w.Code)
+			require.Equal(t, tc.wantLocation, w.Header().Get("Location"))
+			require.Contains(t, w.Header().Get("Content-Type"), tc.wantType)
+			require.Contains(t, w.Body.String(), tc.wantBody)
+		})
+	}
+}
+
+func TestURLHandler_Root_HTML(t *testing.T) {
+	s := mocks.NewMockURLService(t)
+	r := setupRouter(NewURLHandler(s, newTestDomains(t), newTestPages(t)))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = "go.link"
+	req.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	require.Contains(t, w.Body.String(), "Ссылка не найдена")
+}
+
+func TestURLHandler_Redirect_Preview(t *testing.T) {
+	createdAt := time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name         string
+		path         string
+		interstitial bool
+		wantCode     int
+	}{
+		{"redirect", "/aa", false, http.StatusFound},
+		{"plus suffix", "/aa+", false, http.StatusOK},
+		{"preview query", "/aa?preview=1", false, http.StatusOK},
+		{"preview disabled in query", "/aa?preview=0", false, http.StatusFound},
+		{"interstitial link", "/aa", true, http.StatusOK},
+		{"interstitial ignores preview=0", "/aa?preview=0", true, http.StatusOK},
+		{"follow from preview", "/aa+?follow=1", false, http.StatusFound},
+		{"follow from interstitial", "/aa?follow=1", true, http.StatusFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			u := &model.URL{
+				Domain:       "brand.link",
+				Alias:        "aa",
+				LongURL:      "https://example.com/page?a=1&b=2",
+				CreatedAt:    createdAt,
+				Interstitial: tc.interstitial,
+				Meta:         &model.Metadata{Title: "Example page"},
+			}
+			s := mocks.NewMockURLService(t)
+			s.On("Follow", mock.Anything, "brand.link", "aa").
+				Return(u, nil).
+				Once()
+			// Переход засчитывается только при редиректе, показ предпросмотра — не переход
+			if tc.wantCode == http.StatusFound {
+				s.On("Click", mock.Anything, u).
+					Return().
+					Once()
+			}
+
+			r := setupRouter(NewURLHandler(s, newTestDomains(t), newTestPages(t)))
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Host = "brand.link"
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, tc.wantCode, w.Code)
+			if tc.wantCode == http.StatusFound {
+				require.Equal(t, "https://example.com/page?a=1&b=2", w.Header().Get("Location"))
+				return
+			}
+			require.Contains(t, w.Header().Get("Content-Type"), "text/html")
+			require.Contains(t, w.Body.String(), "<code>https://example.com/page?a=1&amp;b=2</code>")
+			require.Contains(t, w.Body.String(), `href="http://brand.link/aa?follow=1"`)
+			require.Contains(t, w.Body.String(), "http://brand.link/aa")
+			require.Contains(t, w.Body.String(), "15.03.2024")
+			require.Contains(t, w.Body.String(), "<strong>Example page</strong>")
+		})
+	}
+}
+
+func TestURLHandler_Redirect_PreviewNotFound(t *testing.T) {
+	s := mocks.NewMockURLService(t)
+	s.On("Follow", mock.Anything, "", "aa").
+		Return(nil, service.ErrNotFound).
+		Once()
+
+	r := setupRouter(NewURLHandler(s, newTestDomains(t), newTestPages(t)))
+
+	req := httptest.NewRequest(http.MethodGet, "/aa+", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.JSONEq(t, `{"type":"about:blank","title":"Not Found","status":404,"instance":"/aa+",
+		"code":"not_found","detail":"not found"}`, w.Body.String())
+}
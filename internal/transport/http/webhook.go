@@ -0,0 +1,108 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/Rasulikus/url-shortener/internal/apperr"
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultDeliveriesLimit — размер страницы доставок, если limit не задан.
+const defaultDeliveriesLimit = 100
+
+type WebhookService interface {
+	// Endpoints возвращает настроенные подписки.
+	Endpoints() []model.Webhook
+
+	// Deliveries возвращает недоставленные события, подходящие под фильтр.
+	Deliveries(ctx context.Context, f repository.DeliveryFilter) ([]*model.Delivery, error)
+
+	// Replay ставит доставку в очередь заново со сброшенным счётчиком попыток.
+	Replay(ctx context.Context, id int64) error
+}
+
+type WebhookHandler struct {
+	s WebhookService
+}
+
+func NewWebhookHandler(s WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		s: s,
+	}
+}
+
+type listWebhooksResponse struct {
+	Webhooks []model.Webhook `json:"webhooks"`
+}
+
+// List отдаёт настроенные подписки.
+func (h *WebhookHandler) List(c *gin.Context) {
+	c.JSON(http.StatusOK, listWebhooksResponse{Webhooks: h.s.Endpoints()})
+}
+
+type listDeliveriesResponse struct {
+	Deliveries []*model.Delivery `json:"deliveries"`
+}
+
+// queryInt64 читает целый параметр запроса, def — если он не задан.
+func queryInt64(c *gin.Context, name string, def int64) (int64, error) {
+	v := c.Query(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, invalidParam(apperr.CodeInvalidParameter, name, "must be an integer")
+	}
+	return n, nil
+}
+
+// Deliveries отдаёт страницу доставок, ожидающих повтора или отправленных в dead letter.
+// Параметры: status (pending или dead), after_id и limit.
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	afterID, err := queryInt64(c, "after_id", 0)
+	if err != nil {
+		ErrorToHttp(c, err)
+		return
+	}
+	limit, err := queryInt64(c, "limit", defaultDeliveriesLimit)
+	if err != nil {
+		ErrorToHttp(c, err)
+		return
+	}
+
+	list, err := h.s.Deliveries(c.Request.Context(), repository.DeliveryFilter{
+		AfterID: afterID,
+		Limit:   int(limit),
+		Status:  model.DeliveryStatus(c.Query("status")),
+	})
+	if err != nil {
+		ErrorToHttp(c, err)
+		return
+	}
+	if list == nil {
+		list = []*model.Delivery{}
+	}
+
+	c.JSON(http.StatusOK, listDeliveriesResponse{Deliveries: list})
+}
+
+// Replay ставит доставку в очередь заново.
+func (h *WebhookHandler) Replay(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		ErrorToHttp(c, invalidParam(apperr.CodeInvalidParameter, "id", "must be an integer"))
+		return
+	}
+
+	if err := h.s.Replay(c.Request.Context(), id); err != nil {
+		ErrorToHttp(c, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/repository"
+	"github.com/Rasulikus/url-shortener/internal/service"
+	"github.com/Rasulikus/url-shortener/internal/transport/http/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupWebhookRouter(h *WebhookHandler) *gin.Engine {
+	r := gin.New()
+	admin := r.Group("/admin", AdminAuth(func() string { return testAdminToken }))
+	admin.GET("/webhooks", h.List)
+	admin.GET("/webhooks/deliveries", h.Deliveries)
+	admin.POST("/webhooks/deliveries/:id/replay", h.Replay)
+	return r
+}
+
+func TestWebhookHandler_List(t *testing.T) {
+	s := mocks.NewMockWebhookService(t)
+	s.EXPECT().Endpoints().Return([]model.Webhook{
+		{Name: "crm", URL: "https://crm.com/hook", Events: []model.EventType{model.EventCreated}},
+	}).Once()
+
+	w := httptest.NewRecorder()
+	setupWebhookRouter(NewWebhookHandler(s)).ServeHTTP(w, adminRequest(http.MethodGet, "/admin/webhooks", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"webhooks":[{"name":"crm","url":"https://crm.com/hook","events":["url.created"]}]}`, w.Body.String())
+}
+
+func TestWebhookHandler_Deliveries(t *testing.T) {
+	cases := []struct {
+		name       string
+		query      string
+		filter     *repository.DeliveryFilter
+		ret        []*model.Delivery
+		svcErr     error
+		wantStatus int
+		wantLen    int
+	}{
+		{
+			name:       "defaults",
+			filter:     &repository.DeliveryFilter{Limit: defaultDeliveriesLimit},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "filtered",
+			query:      "?status=dead&after_id=5&limit=2",
+			filter:     &repository.DeliveryFilter{AfterID: 5, Limit: 2, Status: model.DeliveryDead},
+			ret:        []*model.Delivery{{ID: 6, Webhook: "crm", Status: model.DeliveryDead}},
+			wantStatus: http.StatusOK,
+			wantLen:    1,
+		},
+		{
+			name:       "bad limit",
+			query:      "?limit=abc",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid filter",
+			query:      "?status=done",
+			filter:     &repository.DeliveryFilter{Limit: defaultDeliveriesLimit, Status: "done"},
+			svcErr:     service.ErrInvalidInput,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := mocks.NewMockWebhookService(t)
+			if tc.filter != nil {
+				s.EXPECT().Deliveries(mock.Anything, *tc.filter).Return(tc.ret, tc.svcErr).Once()
+			}
+
+			w := httptest.NewRecorder()
+			setupWebhookRouter(NewWebhookHandler(s)).
+				ServeHTTP(w, adminRequest(http.MethodGet, "/admin/webhooks/deliveries"+tc.query, nil))
+
+			require.Equal(t, tc.wantStatus, w.Code)
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp listDeliveriesResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			require.NotNil(t, resp.Deliveries)
+			assert.Len(t, resp.Deliveries, tc.wantLen)
+		})
+	}
+}
+
+func TestWebhookHandler_Replay(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		svcErr     error
+		wantStatus int
+	}{
+		{"ok", "7", nil, http.StatusAccepted},
+		{"not found", "7", service.ErrNotFound, http.StatusNotFound},
+		{"bad id", "abc", nil, http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := mocks.NewMockWebhookService(t)
+			if tc.id == "7" {
+				s.EXPECT().Replay(mock.Anything, int64(7)).
+					RunAndReturn(func(context.Context, int64) error { return tc.svcErr }).
+					Once()
+			}
+
+			w := httptest.NewRecorder()
+			setupWebhookRouter(NewWebhookHandler(s)).
+				ServeHTTP(w, adminRequest(http.MethodPost, "/admin/webhooks/deliveries/"+tc.id+"/replay", nil))
+
+			require.Equal(t, tc.wantStatus, w.Code)
+		})
+	}
+}
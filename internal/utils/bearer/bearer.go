@@ -0,0 +1,17 @@
+// Package bearer проверяет токен из заголовка "Authorization: Bearer <token>".
+// Используется и HTTP, и gRPC API, чтобы правила авторизации совпадали.
+package bearer
+
+import (
+	"crypto/subtle"
+	"strings"
+)
+
+const prefix = "Bearer "
+
+// Match сообщает, что header содержит токен want. Пустой want не совпадает
+// ни с чем: API без токена закрыт.
+func Match(header, want string) bool {
+	got, ok := strings.CutPrefix(header, prefix)
+	return ok && want != "" && subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
@@ -0,0 +1,28 @@
+package bearer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+		want   string
+		ok     bool
+	}{
+		{"match", "Bearer secret", "secret", true},
+		{"missing", "", "secret", false},
+		{"wrong token", "Bearer nope", "secret", false},
+		{"wrong scheme", "Basic secret", "secret", false},
+		{"empty token configured", "Bearer ", "", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.ok, Match(tc.header, tc.want))
+		})
+	}
+}
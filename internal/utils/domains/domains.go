@@ -0,0 +1,114 @@
+package domains
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+var ErrInvalidDomain = errors.New("invalid domain")
+
+// Domain — короткий домен со своим пространством алиасов: один и тот же алиас
+// на разных доменах ведёт на разные ссылки.
+type Domain struct {
+	// Name — хост домена, как в заголовке Host, например brand.link. Пусто — домен по умолчанию.
+	Name string
+	// RootRedirect — куда перенаправлять запрос к корню домена. Пусто — 404.
+	RootRedirect string
+	// NotFoundRedirect — куда перенаправлять браузер с неизвестного алиаса
+	// вместо страницы 404. Пусто — показывается страница.
+	NotFoundRedirect string
+}
+
+// Registry — реестр доменов. Домен по умолчанию берётся из BASE_URL,
+// он же обслуживает запросы с незарегистрированных хостов.
+type Registry struct {
+	baseURL string
+	scheme  string
+	def     Domain
+	byName  map[string]Domain
+}
+
+// New создаёт реестр. baseURL задаёт короткие ссылки домена по умолчанию и схему для остальных,
+// def — редиректы домена по умолчанию, его имя должно быть пустым.
+func New(baseURL string, def Domain, list []Domain) (*Registry, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("domains: invalid base url: %q", baseURL)
+	}
+	if def.Name != "" {
+		return nil, fmt.Errorf("domains: default domain must not have a name: %q", def.Name)
+	}
+
+	r := &Registry{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		scheme:  base.Scheme,
+		def:     def,
+		byName:  make(map[string]Domain, len(list)),
+	}
+	for _, d := range list {
+		name, err := Normalize(d.Name)
+		if err != nil || name == "" {
+			return nil, fmt.Errorf("domains: %w: %q", ErrInvalidDomain, d.Name)
+		}
+		if name == strings.ToLower(base.Host) {
+			return nil, fmt.Errorf("domains: %q is the default domain from base url", d.Name)
+		}
+		if _, ok := r.byName[name]; ok {
+			return nil, fmt.Errorf("domains: duplicate domain %q", d.Name)
+		}
+		d.Name = name
+		r.byName[name] = d
+	}
+
+	return r, nil
+}
+
+// Normalize приводит имя домена к виду, в котором оно хранится: нижний регистр,
+// без завершающей точки. Пустое имя означает домен по умолчанию.
+func Normalize(name string) (string, error) {
+	name = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+	if name == "" {
+		return "", nil
+	}
+
+	u, err := url.Parse("//" + name)
+	if err != nil || u.Host != name || u.Path != "" || u.User != nil {
+		return "", ErrInvalidDomain
+	}
+	return name, nil
+}
+
+// Lookup возвращает домен по имени. Пустое имя — домен по умолчанию.
+func (r *Registry) Lookup(name string) (Domain, bool) {
+	if name == "" {
+		return r.def, true
+	}
+	d, ok := r.byName[name]
+	return d, ok
+}
+
+// Resolve возвращает имя домена для заголовка Host. Хост можно указать с портом или без.
+// Незарегистрированные хосты относятся к домену по умолчанию.
+func (r *Registry) Resolve(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if _, ok := r.byName[host]; ok {
+		return host
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		if _, ok := r.byName[h]; ok {
+			return h
+		}
+	}
+	return ""
+}
+
+// ShortURL возвращает короткую ссылку для алиаса на домене.
+func (r *Registry) ShortURL(domain, alias string) string {
+	if domain == "" {
+		return r.baseURL + "/" + alias
+	}
+	return r.scheme + "://" + domain + "/" + alias
+}
@@ -0,0 +1,90 @@
+package domains
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRegistry(t *testing.T) *Registry {
+	t.Helper()
+
+	r, err := New("https://sho.rt/", Domain{RootRedirect: "https://example.com"}, []Domain{
+		{Name: "Brand.Link", RootRedirect: "https://brand.com"},
+		{Name: "go.brand.link"},
+		{Name: "localhost:8082"},
+	})
+	require.NoError(t, err)
+	return r
+}
+
+func TestRegistry_Resolve(t *testing.T) {
+	r := newRegistry(t)
+
+	cases := []struct {
+		host string
+		want string
+	}{
+		{"brand.link", "brand.link"},
+		{"BRAND.LINK", "brand.link"},
+		{"brand.link:443", "brand.link"},
+		{"brand.link.", "brand.link"},
+		{"go.brand.link", "go.brand.link"},
+		{"localhost:8082", "localhost:8082"},
+		{"localhost:8081", ""},
+		{"sho.rt", ""},
+		{"unknown.com", ""},
+		{"", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.host, func(t *testing.T) {
+			assert.Equal(t, tc.want, r.Resolve(tc.host))
+		})
+	}
+}
+
+func TestRegistry_Lookup(t *testing.T) {
+	r := newRegistry(t)
+
+	d, ok := r.Lookup("")
+	require.True(t, ok)
+	assert.Equal(t, "https://example.com", d.RootRedirect)
+
+	d, ok = r.Lookup("brand.link")
+	require.True(t, ok)
+	assert.Equal(t, "https://brand.com", d.RootRedirect)
+
+	_, ok = r.Lookup("unknown.com")
+	assert.False(t, ok)
+}
+
+func TestRegistry_ShortURL(t *testing.T) {
+	r := newRegistry(t)
+
+	assert.Equal(t, "https://sho.rt/aa", r.ShortURL("", "aa"))
+	assert.Equal(t, "https://brand.link/aa", r.ShortURL("brand.link", "aa"))
+}
+
+func TestNew_Invalid(t *testing.T) {
+	cases := []struct {
+		name    string
+		baseURL string
+		list    []Domain
+	}{
+		{"bad base url", "sho.rt", nil},
+		{"path in domain", "https://sho.rt", []Domain{{Name: "brand.link/x"}}},
+		{"scheme in domain", "https://sho.rt", []Domain{{Name: "https://brand.link"}}},
+		{"empty domain", "https://sho.rt", []Domain{{Name: " "}}},
+		{"duplicate", "https://sho.rt", []Domain{{Name: "brand.link"}, {Name: "BRAND.link"}}},
+		{"default domain", "https://sho.rt", []Domain{{Name: "sho.rt"}}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(tc.baseURL, Domain{}, tc.list)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// IDAllocator выдаёт уникальные ID для генераторов на основе счётчика.
+type IDAllocator interface {
+	NextID(ctx context.Context) (uint64, error)
+}
+
+// LocalAllocator — счётчик в памяти процесса. Подходит только для одного
+// экземпляра сервиса.
+type LocalAllocator struct {
+	nextID atomic.Uint64
+}
+
+// NewLocalAllocator создаёт счётчик, первый выданный ID которого равен lastID+1.
+func NewLocalAllocator(lastID uint64) *LocalAllocator {
+	a := new(LocalAllocator)
+	a.nextID.Store(lastID)
+	return a
+}
+
+func (a *LocalAllocator) NextID(_ context.Context) (uint64, error) {
+	return a.nextID.Add(1), nil
+}
+
+// IDLeaser резервирует ID в общем хранилище, например в последовательности БД.
+type IDLeaser interface {
+	// LeaseIDs резервирует n уникальных ID. ID не обязаны идти подряд.
+	LeaseIDs(ctx context.Context, n uint64) ([]uint64, error)
+}
+
+const leaseTimeout = 5 * time.Second
+
+// BlockAllocator выдаёт ID из пачек, зарезервированных через IDLeaser, поэтому
+// несколько экземпляров сервиса не выдают одинаковых ID. Следующая пачка
+// резервируется в фоне, когда в текущей остаётся меньше lowWater ID.
+type BlockAllocator struct {
+	leaser   IDLeaser
+	size     uint64
+	lowWater int
+
+	mu        sync.Mutex
+	cur       []uint64
+	prefetch  []uint64
+	refill    chan struct{}
+	refillErr error
+}
+
+func NewBlockAllocator(leaser IDLeaser, size uint64) (*BlockAllocator, error) {
+	if leaser == nil {
+		return nil, fmt.Errorf("id allocator: leaser is nil")
+	}
+	if size == 0 {
+		return nil, fmt.Errorf("id allocator: block size must be positive")
+	}
+
+	return &BlockAllocator{
+		leaser:   leaser,
+		size:     size,
+		lowWater: int(size / 5),
+	}, nil
+}
+
+func (a *BlockAllocator) NextID(ctx context.Context) (uint64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for len(a.cur) == 0 {
+		if len(a.prefetch) > 0 {
+			a.cur, a.prefetch = a.prefetch, nil
+			break
+		}
+
+		if a.refill == nil {
+			a.startRefill()
+		}
+		ch := a.refill
+
+		a.mu.Unlock()
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			a.mu.Lock()
+			return 0, ctx.Err()
+		}
+		a.mu.Lock()
+
+		if len(a.prefetch) == 0 && a.refillErr != nil {
+			return 0, a.refillErr
+		}
+	}
+
+	id := a.cur[0]
+	a.cur = a.cur[1:]
+
+	if len(a.cur) <= a.lowWater && len(a.prefetch) == 0 && a.refill == nil {
+		a.startRefill()
+	}
+
+	return id, nil
+}
+
+// startRefill резервирует следующую пачку в фоне. Вызывается под a.mu.
+func (a *BlockAllocator) startRefill() {
+	ch := make(chan struct{})
+	a.refill = ch
+	a.refillErr = nil
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), leaseTimeout)
+		defer cancel()
+
+		ids, err := a.leaser.LeaseIDs(ctx, a.size)
+		if err == nil && len(ids) == 0 {
+			err = fmt.Errorf("leaser returned no ids")
+		}
+
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		if err != nil {
+			log.Error().
+				Err(err).
+				Uint64("block_size", a.size).
+				Msg("failed to lease ids")
+
+			a.refillErr = fmt.Errorf("id allocator: lease ids: %w", err)
+		} else {
+			log.Debug().
+				Uint64("first", ids[0]).
+				Int("count", len(ids)).
+				Msg("leased ids")
+
+			a.prefetch = ids
+		}
+
+		a.refill = nil
+		close(ch)
+	}()
+}
@@ -0,0 +1,119 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLeaser struct {
+	mu     sync.Mutex
+	next   uint64
+	leases int
+	err    error
+}
+
+func (l *fakeLeaser) LeaseIDs(_ context.Context, n uint64) ([]uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.err != nil {
+		return nil, l.err
+	}
+
+	ids := make([]uint64, 0, n)
+	for i := uint64(0); i < n; i++ {
+		ids = append(ids, l.next)
+		l.next++
+	}
+	l.leases++
+	return ids, nil
+}
+
+func TestLocalAllocator_NextID(t *testing.T) {
+	a := NewLocalAllocator(41)
+
+	id, err := a.NextID(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), id)
+}
+
+func TestNewBlockAllocator_Invalid(t *testing.T) {
+	a, err := NewBlockAllocator(nil, 10)
+	require.Error(t, err)
+	require.Nil(t, a)
+
+	a, err = NewBlockAllocator(&fakeLeaser{next: 1}, 0)
+	require.Error(t, err)
+	require.Nil(t, a)
+}
+
+func TestBlockAllocator_NextID(t *testing.T) {
+	leaser := &fakeLeaser{next: 1}
+	a, err := NewBlockAllocator(leaser, 10)
+	require.NoError(t, err)
+
+	for want := uint64(1); want <= 35; want++ {
+		id, err := a.NextID(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, want, id)
+	}
+}
+
+func TestBlockAllocator_SharedLeaser(t *testing.T) {
+	// Два экземпляра с общим хранилищем диапазонов не должны выдавать одинаковых ID
+	leaser := &fakeLeaser{next: 1}
+	a1, err := NewBlockAllocator(leaser, 7)
+	require.NoError(t, err)
+	a2, err := NewBlockAllocator(leaser, 7)
+	require.NoError(t, err)
+
+	var (
+		mu   sync.Mutex
+		seen = make(map[uint64]struct{})
+		wg   sync.WaitGroup
+	)
+	for _, a := range []*BlockAllocator{a1, a2} {
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 100; i++ {
+					id, err := a.NextID(context.Background())
+					require.NoError(t, err)
+
+					mu.Lock()
+					_, ok := seen[id]
+					seen[id] = struct{}{}
+					mu.Unlock()
+					require.False(t, ok, "id %d allocated twice", id)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	require.Len(t, seen, 800)
+}
+
+func TestBlockAllocator_LeaseError(t *testing.T) {
+	leaseErr := errors.New("db down")
+	leaser := &fakeLeaser{err: leaseErr}
+	a, err := NewBlockAllocator(leaser, 10)
+	require.NoError(t, err)
+
+	_, err = a.NextID(context.Background())
+	require.ErrorIs(t, err, leaseErr)
+
+	leaser.mu.Lock()
+	leaser.err = nil
+	leaser.next = 100
+	leaser.mu.Unlock()
+
+	id, err := a.NextID(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), id)
+}
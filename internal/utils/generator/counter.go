@@ -1,42 +1,74 @@
 package generator
 
 import (
-	"sync/atomic"
+	"context"
+	"math"
+	"strings"
 )
 
+// CounterGenerator выдаёт алиасы для последовательных ID. ID переставляется
+// ключевой перестановкой над пространством алиасов заданной длины, поэтому
+// алиасы не идут подряд, не повторяются и могут быть декодированы обратно в ID
+// при знании секрета.
 type CounterGenerator struct {
-	nextID atomic.Uint64
-	secret uint64
+	ids    IDAllocator
+	perm   *feistel
 	length int
-	base   uint64
 }
 
-func NewCounter(startFrom uint64, secret uint64, length int) (*CounterGenerator, error) {
+func NewCounter(ids IDAllocator, secret uint64, length int) (*CounterGenerator, error) {
 	if length <= 0 {
 		return nil, ErrInvalidLength
 	}
 
-	g := &CounterGenerator{
-		secret: secret,
+	return &CounterGenerator{
+		ids:    ids,
+		perm:   newFeistel(secret, length),
 		length: length,
-		base:   uint64(len(alphabet)),
-	}
-	g.nextID.Store(startFrom)
-	return g, nil
+	}, nil
 }
 
-func (g *CounterGenerator) NewAlias() (string, error) {
-	// Берём следующий уникальный ID атомарно
-	id := g.nextID.Add(1)
+// NewAlias возвращает следующий ID и выведенный из него алиас. Запись должна
+// сохраняться с этим ID, тогда алиас однозначно соответствует строке в хранилище.
+func (g *CounterGenerator) NewAlias(ctx context.Context, _ string, _ int) (uint64, string, error) {
+	// Берём следующий уникальный ID
+	id, err := g.ids.NextID(ctx)
+	if err != nil {
+		return 0, "", err
+	}
 
-	// Защита от простого угадывания
-	mixed := id ^ g.secret
+	// Переставляем ID внутри пространства алиасов, чтобы скрыть порядок
+	mixed, err := g.perm.Permute(id)
+	if err != nil {
+		return 0, "", err
+	}
 
 	// Кодировка числа в строку
-	return encode(mixed, g.length, g.base)
+	alias, err := encode(mixed, g.length, alphabet)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return id, alias, nil
+}
+
+// Decode восстанавливает ID, из которого был получен алиас.
+func (g *CounterGenerator) Decode(alias string) (uint64, error) {
+	if len(alias) != g.length {
+		return 0, ErrInvalidAlias
+	}
+
+	n, err := decode(alias, alphabet)
+	if err != nil {
+		return 0, err
+	}
+
+	return g.perm.Unpermute(n)
 }
 
-func encode(n uint64, length int, base uint64) (string, error) {
+func encode(n uint64, length int, alpha string) (string, error) {
+	base := uint64(len(alpha))
+
 	// Кол-во символов на выход, если не все заполняется то будут 0 вначале, в данном случае это 'a'
 	out := make([]byte, length)
 
@@ -44,7 +76,7 @@ func encode(n uint64, length int, base uint64) (string, error) {
 	for i := length - 1; i >= 0; i-- {
 		rem := n % base
 		n /= base
-		out[i] = alphabet[rem]
+		out[i] = alpha[rem]
 	}
 
 	// Если осталось число после length операций, значит оно не поместилось в заданную длину
@@ -54,3 +86,23 @@ func encode(n uint64, length int, base uint64) (string, error) {
 
 	return string(out), nil
 }
+
+func decode(s string, alpha string) (uint64, error) {
+	base := uint64(len(alpha))
+
+	var n uint64
+	for i := 0; i < len(s); i++ {
+		idx := strings.IndexByte(alpha, s[i])
+		if idx < 0 {
+			return 0, ErrInvalidAlias
+		}
+
+		// Проверка переполнения перед сдвигом на один разряд
+		if n > (math.MaxUint64-uint64(idx))/base {
+			return 0, ErrOverflow
+		}
+		n = n*base + uint64(idx)
+	}
+
+	return n, nil
+}
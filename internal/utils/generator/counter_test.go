@@ -1,13 +1,14 @@
 package generator
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 )
 
 func TestCounterGenerator_ErrInvalidLength(t *testing.T) {
-	gen, err := NewCounter(0, 123, -10)
+	gen, err := NewCounter(NewLocalAllocator(0), 123, -10)
 	require.ErrorIs(t, ErrInvalidLength, err)
 	require.Nil(t, gen)
 }
@@ -24,11 +25,11 @@ func TestCounterGenerator_GenerateAlias_Length(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			gen, err := NewCounter(0, 123456, tc.length)
+			gen, err := NewCounter(NewLocalAllocator(0), 123456, tc.length)
 			require.NoError(t, err)
 			require.NotNil(t, gen)
 
-			a, err := gen.NewAlias()
+			_, a, err := gen.NewAlias(context.Background(), "", 0)
 			require.NoError(t, err)
 			require.Len(t, a, tc.length)
 		})
@@ -36,14 +37,100 @@ func TestCounterGenerator_GenerateAlias_Length(t *testing.T) {
 }
 
 func TestCounterGenerator_Uniqueness(t *testing.T) {
-	gen, err := NewCounter(0, 42, DefaultLength)
+	gen, err := NewCounter(NewLocalAllocator(0), 42, DefaultLength)
 	require.NoError(t, err)
 
-	a1, err := gen.NewAlias()
+	_, a1, err := gen.NewAlias(context.Background(), "", 0)
 	require.NoError(t, err)
 
-	a2, err := gen.NewAlias()
+	_, a2, err := gen.NewAlias(context.Background(), "", 0)
 	require.NoError(t, err)
 
 	require.NotEqual(t, a1, a2, "aliases must be unique for different ids")
 }
+
+func TestCounterGenerator_Decode(t *testing.T) {
+	cases := []struct {
+		name   string
+		length int
+	}{
+		{"len 5", 5},
+		{"len 10", 10},
+		{"len 12", 12},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gen, err := NewCounter(NewLocalAllocator(1000), 987654321, tc.length)
+			require.NoError(t, err)
+
+			for want := uint64(1001); want <= 1100; want++ {
+				id, a, err := gen.NewAlias(context.Background(), "", 0)
+				require.NoError(t, err)
+				require.Equal(t, want, id)
+
+				decoded, err := gen.Decode(a)
+				require.NoError(t, err)
+				require.Equal(t, id, decoded)
+			}
+		})
+	}
+}
+
+func TestCounterGenerator_DecodeInvalid(t *testing.T) {
+	gen, err := NewCounter(NewLocalAllocator(0), 42, DefaultLength)
+	require.NoError(t, err)
+
+	_, err = gen.Decode("short")
+	require.ErrorIs(t, err, ErrInvalidAlias)
+
+	_, err = gen.Decode("aaaaaaaa-a")
+	require.ErrorIs(t, err, ErrInvalidAlias)
+}
+
+func TestCounterGenerator_DecodeWrongSecret(t *testing.T) {
+	gen, err := NewCounter(NewLocalAllocator(0), 42, DefaultLength)
+	require.NoError(t, err)
+	other, err := NewCounter(NewLocalAllocator(0), 43, DefaultLength)
+	require.NoError(t, err)
+
+	_, a, err := gen.NewAlias(context.Background(), "", 0)
+	require.NoError(t, err)
+
+	id, err := other.Decode(a)
+	require.NoError(t, err)
+	require.NotEqual(t, uint64(1), id)
+}
+
+func TestCounterGenerator_Bijective(t *testing.T) {
+	// Пространство алиасов длины 2 достаточно мало, чтобы перебрать его целиком
+	gen, err := NewCounter(NewLocalAllocator(0), 42, 2)
+	require.NoError(t, err)
+
+	size := len(alphabet) * len(alphabet)
+	seen := make(map[string]struct{}, size)
+	for i := 0; i < size-1; i++ {
+		_, a, err := gen.NewAlias(context.Background(), "", 0)
+		require.NoError(t, err)
+
+		_, ok := seen[a]
+		require.False(t, ok, "alias %q generated twice", a)
+		seen[a] = struct{}{}
+	}
+
+	_, _, err = gen.NewAlias(context.Background(), "", 0)
+	require.ErrorIs(t, err, ErrOverflow, "alias space must be exhausted")
+}
+
+func TestCounterGenerator_NotSequential(t *testing.T) {
+	gen, err := NewCounter(NewLocalAllocator(0), 42, DefaultLength)
+	require.NoError(t, err)
+
+	_, a1, err := gen.NewAlias(context.Background(), "", 0)
+	require.NoError(t, err)
+	_, a2, err := gen.NewAlias(context.Background(), "", 0)
+	require.NoError(t, err)
+
+	// При XOR-схеме соседние ID давали алиасы, отличающиеся в последнем символе
+	require.NotEqual(t, a1[:DefaultLength-1], a2[:DefaultLength-1])
+}
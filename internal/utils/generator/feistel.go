@@ -0,0 +1,127 @@
+package generator
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"math/bits"
+)
+
+const feistelRounds = 8
+
+// feistel — ключевая перестановка чисел [0, domain) на основе сбалансированной
+// сети Фейстеля. Сеть работает над 2k битами, значения вне домена прогоняются
+// повторно (cycle walking), поэтому результат всегда остаётся в домене, а
+// перестановка обратима при знании ключа.
+type feistel struct {
+	// domain — размер домена; 0 означает весь диапазон uint64.
+	domain   uint64
+	halfBits uint
+	mask     uint64
+	key      []byte
+}
+
+// newFeistel строит перестановку над алфавитом заданной длины: домен равен
+// len(alphabet)^length, но не больше 2^64.
+func newFeistel(secret uint64, length int) *feistel {
+	domain, ok := pow(uint64(len(alphabet)), length)
+	if !ok {
+		domain = 0
+	}
+
+	n := uint(64)
+	if domain != 0 {
+		n = uint(bits.Len64(domain - 1))
+	}
+	// Половины должны быть одинаковой ширины, поэтому число бит чётное
+	if n%2 == 1 {
+		n++
+	}
+	if n < 2 {
+		n = 2
+	}
+
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, secret)
+
+	return &feistel{
+		domain:   domain,
+		halfBits: n / 2,
+		mask:     1<<(n/2) - 1,
+		key:      key,
+	}
+}
+
+func (f *feistel) inDomain(n uint64) bool {
+	return f.domain == 0 || n < f.domain
+}
+
+// Permute отображает n в другое число того же домена.
+func (f *feistel) Permute(n uint64) (uint64, error) {
+	if !f.inDomain(n) {
+		return 0, ErrOverflow
+	}
+
+	for {
+		n = f.encrypt(n)
+		if f.inDomain(n) {
+			return n, nil
+		}
+	}
+}
+
+// Unpermute — обратное к Permute отображение.
+func (f *feistel) Unpermute(n uint64) (uint64, error) {
+	if !f.inDomain(n) {
+		return 0, ErrOverflow
+	}
+
+	for {
+		n = f.decrypt(n)
+		if f.inDomain(n) {
+			return n, nil
+		}
+	}
+}
+
+func (f *feistel) encrypt(n uint64) uint64 {
+	l, r := n>>f.halfBits&f.mask, n&f.mask
+	for i := 0; i < feistelRounds; i++ {
+		l, r = r, l^f.round(i, r)
+	}
+	return l<<f.halfBits | r
+}
+
+func (f *feistel) decrypt(n uint64) uint64 {
+	l, r := n>>f.halfBits&f.mask, n&f.mask
+	for i := feistelRounds - 1; i >= 0; i-- {
+		l, r = r^f.round(i, l), l
+	}
+	return l<<f.halfBits | r
+}
+
+// round — раундовая функция: HMAC-SHA256 от номера раунда и половины блока.
+func (f *feistel) round(i int, half uint64) uint64 {
+	var msg [9]byte
+	msg[0] = byte(i)
+	binary.BigEndian.PutUint64(msg[1:], half)
+
+	mac := hmac.New(sha256.New, f.key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	return binary.BigEndian.Uint64(sum) & f.mask
+}
+
+// pow возвращает base^exp и false при переполнении uint64.
+func pow(base uint64, exp int) (uint64, bool) {
+	result := uint64(1)
+	for i := 0; i < exp; i++ {
+		if result > math.MaxUint64/base {
+			return 0, false
+		}
+		result *= base
+	}
+	return result, true
+}
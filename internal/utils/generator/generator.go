@@ -12,4 +12,5 @@ const (
 var (
 	ErrInvalidLength = errors.New("alias generator: invalid length")
 	ErrOverflow      = errors.New("alias generator: counter value overflow")
+	ErrInvalidAlias  = errors.New("alias generator: invalid alias")
 )
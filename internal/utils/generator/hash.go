@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"strconv"
+)
+
+// HashGenerator строит алиас из SHA-256 длинного URL, поэтому один и тот же URL
+// всегда получает один и тот же алиас. При коллизии номер попытки добавляется к
+// входу хэша, чтобы получить другой алиас.
+type HashGenerator struct {
+	length int
+}
+
+func NewHash(length int) (*HashGenerator, error) {
+	if length <= 0 {
+		return nil, ErrInvalidLength
+	}
+
+	return &HashGenerator{
+		length: length,
+	}, nil
+}
+
+func (g *HashGenerator) NewAlias(_ context.Context, longURL string, attempt int) (uint64, string, error) {
+	seed := longURL
+	if attempt > 0 {
+		seed += "\x00" + strconv.Itoa(attempt)
+	}
+
+	out := make([]byte, 0, g.length)
+
+	// Читаем байты из потока sha256(seed || block) и отбрасываем значения выше
+	// кратного мощности алфавита, чтобы символы распределялись равномерно
+	limit := byte(256 / len(alphabet) * len(alphabet))
+	buf := make([]byte, len(seed)+8)
+	copy(buf, seed)
+
+	for block := uint64(0); len(out) < g.length; block++ {
+		binary.BigEndian.PutUint64(buf[len(seed):], block)
+		sum := sha256.Sum256(buf)
+
+		for _, b := range sum {
+			if b >= limit {
+				continue
+			}
+			out = append(out, alphabet[int(b)%len(alphabet)])
+			if len(out) == g.length {
+				break
+			}
+		}
+	}
+
+	return 0, string(out), nil
+}
@@ -0,0 +1,59 @@
+package generator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashGenerator_ErrInvalidLength(t *testing.T) {
+	gen, err := NewHash(0)
+	require.ErrorIs(t, ErrInvalidLength, err)
+	require.Nil(t, gen)
+}
+
+func TestHashGenerator_GenerateAlias(t *testing.T) {
+	cases := []struct {
+		name   string
+		length int
+	}{
+		{"len 5", 5},
+		{"len 10", 10},
+		{"len 100", 100},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gen, err := NewHash(tc.length)
+			require.NoError(t, err)
+
+			_, a, err := gen.NewAlias(context.Background(), "http://example.com", 0)
+			require.NoError(t, err)
+			require.Len(t, a, tc.length)
+			for _, c := range a {
+				require.True(t, strings.ContainsRune(alphabet, c))
+			}
+		})
+	}
+}
+
+func TestHashGenerator_Deterministic(t *testing.T) {
+	gen, err := NewHash(DefaultLength)
+	require.NoError(t, err)
+
+	_, a1, err := gen.NewAlias(context.Background(), "http://example.com", 0)
+	require.NoError(t, err)
+	_, a2, err := gen.NewAlias(context.Background(), "http://example.com", 0)
+	require.NoError(t, err)
+	require.Equal(t, a1, a2)
+
+	_, other, err := gen.NewAlias(context.Background(), "http://example.org", 0)
+	require.NoError(t, err)
+	require.NotEqual(t, a1, other)
+
+	_, retry, err := gen.NewAlias(context.Background(), "http://example.com", 1)
+	require.NoError(t, err)
+	require.NotEqual(t, a1, retry, "retry attempt must produce a different alias")
+}
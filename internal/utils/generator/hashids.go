@@ -0,0 +1,69 @@
+package generator
+
+import (
+	"context"
+	"strconv"
+)
+
+// HashidsGenerator кодирует последовательный ID алфавитом, перемешанным по соли,
+// как это делает hashids. Перед кодированием ID переставляется той же ключевой
+// перестановкой, что у CounterGenerator, иначе соседние ID давали бы соседние алиасы
+// и выдавали порядок и число созданных ссылок. Алиасы не повторяются, пока не
+// переполнится длина.
+type HashidsGenerator struct {
+	ids      IDAllocator
+	perm     *feistel
+	length   int
+	alphabet string
+}
+
+func NewHashids(ids IDAllocator, secret uint64, length int) (*HashidsGenerator, error) {
+	if length <= 0 {
+		return nil, ErrInvalidLength
+	}
+
+	return &HashidsGenerator{
+		ids:      ids,
+		perm:     newFeistel(secret, length),
+		length:   length,
+		alphabet: shuffle(alphabet, strconv.FormatUint(secret, 10)),
+	}, nil
+}
+
+func (g *HashidsGenerator) NewAlias(ctx context.Context, _ string, _ int) (uint64, string, error) {
+	id, err := g.ids.NextID(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+
+	mixed, err := g.perm.Permute(id)
+	if err != nil {
+		return 0, "", err
+	}
+
+	alias, err := encode(mixed, g.length, g.alphabet)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return id, alias, nil
+}
+
+// shuffle детерминированно перемешивает алфавит по соли (consistent shuffle из hashids).
+func shuffle(alpha, salt string) string {
+	out := []byte(alpha)
+	if salt == "" {
+		return alpha
+	}
+
+	for i, v, p := len(out)-1, 0, 0; i > 0; i-- {
+		v %= len(salt)
+		c := int(salt[v])
+		p += c
+		j := (c + v + p) % i
+		out[i], out[j] = out[j], out[i]
+		v++
+	}
+
+	return string(out)
+}
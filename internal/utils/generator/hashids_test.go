@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashidsGenerator_ErrInvalidLength(t *testing.T) {
+	gen, err := NewHashids(NewLocalAllocator(0), 123, 0)
+	require.ErrorIs(t, ErrInvalidLength, err)
+	require.Nil(t, gen)
+}
+
+func TestHashidsGenerator_GenerateAlias_Length(t *testing.T) {
+	cases := []struct {
+		name   string
+		length int
+	}{
+		{"len 5", 5},
+		{"len 10", 10},
+		{"len 100", 100},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gen, err := NewHashids(NewLocalAllocator(0), 123456, tc.length)
+			require.NoError(t, err)
+			require.NotNil(t, gen)
+
+			_, a, err := gen.NewAlias(context.Background(), "", 0)
+			require.NoError(t, err)
+			require.Len(t, a, tc.length)
+		})
+	}
+}
+
+func TestHashidsGenerator_Uniqueness(t *testing.T) {
+	gen, err := NewHashids(NewLocalAllocator(0), 42, DefaultLength)
+	require.NoError(t, err)
+
+	seen := make(map[string]struct{})
+	for i := 0; i < 1000; i++ {
+		_, a, err := gen.NewAlias(context.Background(), "", 0)
+		require.NoError(t, err)
+
+		_, ok := seen[a]
+		require.False(t, ok, "aliases must be unique for different ids")
+		seen[a] = struct{}{}
+	}
+}
+
+func TestHashidsGenerator_AdjacentIDs(t *testing.T) {
+	gen, err := NewHashids(NewLocalAllocator(0), 42, DefaultLength)
+	require.NoError(t, err)
+
+	_, prev, err := gen.NewAlias(context.Background(), "", 0)
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		_, a, err := gen.NewAlias(context.Background(), "", 0)
+		require.NoError(t, err)
+
+		// Без перестановки соседние ID отличались бы только последними символами
+		require.NotEqual(t, prev[:DefaultLength-2], a[:DefaultLength-2], "adjacent ids gave adjacent aliases %q and %q", prev, a)
+		prev = a
+	}
+}
+
+func TestHashidsGenerator_SaltChangesAlphabet(t *testing.T) {
+	g1, err := NewHashids(NewLocalAllocator(0), 1, DefaultLength)
+	require.NoError(t, err)
+	g2, err := NewHashids(NewLocalAllocator(0), 2, DefaultLength)
+	require.NoError(t, err)
+
+	_, a1, err := g1.NewAlias(context.Background(), "", 0)
+	require.NoError(t, err)
+	_, a2, err := g2.NewAlias(context.Background(), "", 0)
+	require.NoError(t, err)
+
+	require.NotEqual(t, a1, a2)
+	require.ElementsMatch(t, []byte(alphabet), []byte(g1.alphabet))
+}
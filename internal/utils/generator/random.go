@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"context"
 	"crypto/rand"
 	"fmt"
 	"math/big"
@@ -20,17 +21,17 @@ func NewRandom(length int) (*RandomGenerator, error) {
 	}, nil
 }
 
-func (g *RandomGenerator) NewAlias() (string, error) {
+func (g *RandomGenerator) NewAlias(_ context.Context, _ string, _ int) (uint64, string, error) {
 	b := make([]byte, g.length)
 	lenAlpha := big.NewInt(int64(len(alphabet)))
 
 	for i := 0; i < g.length; i++ {
 		n, err := rand.Int(rand.Reader, lenAlpha)
 		if err != nil {
-			return "", fmt.Errorf("alias generator: rand.Int: %w", err)
+			return 0, "", fmt.Errorf("alias generator: rand.Int: %w", err)
 		}
 		b[i] = alphabet[n.Int64()]
 	}
 
-	return string(b), nil
+	return 0, string(b), nil
 }
@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -28,7 +29,7 @@ func TestRandomGenerator_GenerateAlias(t *testing.T) {
 			require.NoError(t, err)
 			require.NotNil(t, gen)
 
-			a, err := gen.NewAlias()
+			_, a, err := gen.NewAlias(context.Background(), "", 0)
 			require.NoError(t, err)
 			require.NotNil(t, a)
 			require.Len(t, a, tc.len)
@@ -40,10 +41,10 @@ func TestRandomGenerator_Uniqueness(t *testing.T) {
 	gen, err := NewRandom(DefaultLength)
 	require.NoError(t, err)
 
-	a1, err := gen.NewAlias()
+	_, a1, err := gen.NewAlias(context.Background(), "", 0)
 	require.NoError(t, err)
 
-	a2, err := gen.NewAlias()
+	_, a2, err := gen.NewAlias(context.Background(), "", 0)
 	require.NoError(t, err)
 
 	require.NotEqual(t, a1, a2, "aliases must be unique")
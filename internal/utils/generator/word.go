@@ -0,0 +1,48 @@
+package generator
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+const (
+	consonants = "bcdfghjklmnprstvz"
+	vowels     = "aeiou"
+)
+
+// WordGenerator генерирует случайные произносимые алиасы из чередующихся
+// согласных и гласных, например "bakodure".
+type WordGenerator struct {
+	length int
+}
+
+func NewWord(length int) (*WordGenerator, error) {
+	if length <= 0 {
+		return nil, ErrInvalidLength
+	}
+
+	return &WordGenerator{
+		length: length,
+	}, nil
+}
+
+func (g *WordGenerator) NewAlias(_ context.Context, _ string, _ int) (uint64, string, error) {
+	b := make([]byte, g.length)
+
+	for i := 0; i < g.length; i++ {
+		letters := consonants
+		if i%2 == 1 {
+			letters = vowels
+		}
+
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
+		if err != nil {
+			return 0, "", fmt.Errorf("alias generator: rand.Int: %w", err)
+		}
+		b[i] = letters[n.Int64()]
+	}
+
+	return 0, string(b), nil
+}
@@ -0,0 +1,32 @@
+package generator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWordGenerator_ErrInvalidLength(t *testing.T) {
+	gen, err := NewWord(-1)
+	require.ErrorIs(t, ErrInvalidLength, err)
+	require.Nil(t, gen)
+}
+
+func TestWordGenerator_GenerateAlias(t *testing.T) {
+	gen, err := NewWord(DefaultLength)
+	require.NoError(t, err)
+
+	_, a, err := gen.NewAlias(context.Background(), "", 0)
+	require.NoError(t, err)
+	require.Len(t, a, DefaultLength)
+
+	for i, c := range a {
+		if i%2 == 0 {
+			require.True(t, strings.ContainsRune(consonants, c), "expected consonant at %d: %q", i, a)
+		} else {
+			require.True(t, strings.ContainsRune(vowels, c), "expected vowel at %d: %q", i, a)
+		}
+	}
+}
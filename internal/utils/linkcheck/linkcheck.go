@@ -0,0 +1,191 @@
+// Package linkcheck проверяет доступность страниц назначения ссылок.
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/utils/safehttp"
+)
+
+var (
+	// ErrForbiddenAddress — адрес страницы указывает во внутреннюю сеть.
+	ErrForbiddenAddress = safehttp.ErrForbiddenAddress
+	// ErrUnsupportedScheme — ссылка не http(s).
+	ErrUnsupportedScheme = errors.New("linkcheck: unsupported scheme")
+)
+
+const (
+	// DefaultUserAgent — User-Agent запросов, если в Config он не задан.
+	DefaultUserAgent = "url-shortener-linkcheck/1.0"
+
+	maxRedirects = 10
+	// maxHosts — сколько хостов помнить для паузы между запросами, см. Checker.wait.
+	maxHosts = 10000
+	// maxDrain — сколько байт тела GET-ответа дочитывается, чтобы переиспользовать соединение.
+	maxDrain = 64 << 10
+)
+
+type Config struct {
+	// Timeout ограничивает одну проверку вместе с редиректами.
+	Timeout time.Duration
+	// HostDelay — минимальная пауза между запросами к одному хосту.
+	HostDelay time.Duration
+	// AllowPrivate разрешает адреса внутренней сети, например для тестов с httptest.
+	AllowPrivate bool
+	UserAgent    string
+}
+
+type Checker struct {
+	client    *http.Client
+	hostDelay time.Duration
+	userAgent string
+
+	mu sync.Mutex
+	// next — когда можно отправить следующий запрос к хосту.
+	next map[string]time.Time
+}
+
+// New создаёт Checker. Адреса внутренней сети запрещены, если не задан AllowPrivate.
+func New(cfg Config) *Checker {
+	userAgent := cfg.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+
+	return &Checker{
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: safehttp.Transport(cfg.Timeout, cfg.AllowPrivate),
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return fmt.Errorf("linkcheck: stopped after %d redirects", maxRedirects)
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return ErrUnsupportedScheme
+				}
+				return nil
+			},
+		},
+		hostDelay: cfg.HostDelay,
+		userAgent: userAgent,
+		next:      make(map[string]time.Time),
+	}
+}
+
+// Check запрашивает rawURL и возвращает статус ответа, время запроса и цепочку редиректов.
+// Сначала отправляется HEAD, а если сервер его не поддерживает — GET.
+// Ошибка возвращается, только если ответа нет.
+func (c *Checker) Check(ctx context.Context, rawURL string) (*model.Health, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("linkcheck: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, ErrUnsupportedScheme
+	}
+
+	h, err := c.do(ctx, http.MethodHead, u)
+	if err == nil && (h.Status == http.StatusMethodNotAllowed || h.Status == http.StatusNotImplemented) {
+		h, err = c.do(ctx, http.MethodGet, u)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrForbiddenAddress):
+			return nil, ErrForbiddenAddress
+		case errors.Is(err, ErrUnsupportedScheme):
+			return nil, ErrUnsupportedScheme
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("linkcheck: %w", err)
+	}
+	return h, nil
+}
+
+func (c *Checker) do(ctx context.Context, method string, u *url.URL) (*model.Health, error) {
+	if err := c.wait(ctx, u.Host); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	latency := time.Since(start)
+	_, _ = io.CopyN(io.Discard, resp.Body, maxDrain)
+	resp.Body.Close()
+
+	return &model.Health{
+		Status:    resp.StatusCode,
+		Latency:   latency,
+		Redirects: redirects(resp),
+	}, nil
+}
+
+// redirects восстанавливает цепочку редиректов по ответу: каждый запрос
+// после первого хранит ответ, который к нему привёл.
+func redirects(resp *http.Response) []string {
+	var chain []string
+	for req := resp.Request; req.Response != nil; req = req.Response.Request {
+		chain = append(chain, req.URL.String())
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// wait выдерживает паузу HostDelay с предыдущего запроса к host. Очередь запросов
+// к хосту распределяется сразу при вызове, поэтому одновременные проверки одного
+// хоста выстраиваются друг за другом.
+func (c *Checker) wait(ctx context.Context, host string) error {
+	if c.hostDelay <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	if len(c.next) >= maxHosts {
+		for h, t := range c.next {
+			if t.Before(now) {
+				delete(c.next, h)
+			}
+		}
+	}
+	at := c.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	c.next[host] = at.Add(c.hostDelay)
+	c.mu.Unlock()
+
+	d := at.Sub(now)
+	if d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,158 @@
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() Config {
+	return Config{
+		Timeout:      time.Second,
+		AllowPrivate: true,
+	}
+}
+
+func TestChecker_Check(t *testing.T) {
+	var methods []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+	mux.HandleFunc("/get-only", func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.Handle("/old", http.RedirectHandler("/moved", http.StatusMovedPermanently))
+	mux.Handle("/moved", http.RedirectHandler("/ok", http.StatusFound))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cases := []struct {
+		path          string
+		wantStatus    int
+		wantRedirects []string
+	}{
+		{"/ok", http.StatusOK, nil},
+		{"/gone", http.StatusGone, nil},
+		{"/get-only", http.StatusOK, nil},
+		{"/old", http.StatusOK, []string{srv.URL + "/moved", srv.URL + "/ok"}},
+	}
+
+	c := New(testConfig())
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			h, err := c.Check(context.Background(), srv.URL+tc.path)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantStatus, h.Status)
+			assert.Equal(t, tc.wantRedirects, h.Redirects)
+			assert.Positive(t, h.Latency)
+		})
+	}
+	assert.Equal(t, []string{http.MethodHead, http.MethodGet}, methods, "GET only after HEAD is rejected")
+}
+
+func TestChecker_Check_Errors(t *testing.T) {
+	t.Run("forbidden address", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		t.Cleanup(srv.Close)
+
+		cfg := testConfig()
+		cfg.AllowPrivate = false
+		_, err := New(cfg).Check(context.Background(), srv.URL)
+		require.ErrorIs(t, err, ErrForbiddenAddress)
+	})
+
+	t.Run("unsupported scheme", func(t *testing.T) {
+		_, err := New(testConfig()).Check(context.Background(), "mailto:someone@example.com")
+		require.ErrorIs(t, err, ErrUnsupportedScheme)
+	})
+
+	t.Run("connection refused", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		srv.Close()
+
+		_, err := New(testConfig()).Check(context.Background(), srv.URL)
+		require.Error(t, err)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		done := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-done:
+			case <-r.Context().Done():
+			}
+		}))
+		t.Cleanup(srv.Close)
+		t.Cleanup(func() { close(done) })
+
+		cfg := testConfig()
+		cfg.Timeout = 50 * time.Millisecond
+		_, err := New(cfg).Check(context.Background(), srv.URL)
+		require.Error(t, err)
+	})
+}
+
+func TestChecker_HostDelay(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		last time.Time
+		gaps []time.Duration
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !last.IsZero() {
+			gaps = append(gaps, time.Since(last))
+		}
+		last = time.Now()
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := testConfig()
+	cfg.HostDelay = 30 * time.Millisecond
+	c := New(cfg)
+
+	// Одновременные проверки одного хоста идут с паузой
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Int32
+	)
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Check(context.Background(), srv.URL); err != nil {
+				failed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Zero(t, failed.Load())
+	require.Len(t, gaps, 2)
+	for _, gap := range gaps {
+		assert.GreaterOrEqual(t, gap, 20*time.Millisecond)
+	}
+
+	// Ожидание прерывается отменой контекста
+	c.hostDelay = time.Hour
+	_, err := c.Check(context.Background(), srv.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = c.Check(ctx, srv.URL)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
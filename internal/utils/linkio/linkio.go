@@ -0,0 +1,264 @@
+package linkio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+)
+
+// Format — формат выгрузки ссылок.
+type Format string
+
+const (
+	FormatJSONL Format = "jsonl"
+	FormatCSV   Format = "csv"
+)
+
+func ParseFormat(s string) (Format, error) {
+	v := Format(strings.ToLower(strings.TrimSpace(s)))
+	switch v {
+	case FormatJSONL, FormatCSV:
+		return v, nil
+	default:
+		return "", fmt.Errorf("unknown format: %q", s)
+	}
+}
+
+// ContentType возвращает MIME-тип формата.
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// ErrInvalidRecord — строку не удалось разобрать. Чтение можно продолжать со следующей.
+var ErrInvalidRecord = errors.New("invalid record")
+
+// csvHeader — столбцы CSV. Новые столбцы добавляются в конец, чтобы не сдвигать
+// остальные в выгрузках старых версий; при импорте столбцы ищутся по имени.
+var csvHeader = []string{"id", "alias", "long_url", "created_at", "disabled", "domain", "interstitial"}
+
+// record — строка выгрузки. ID выгружается для справки и при импорте игнорируется.
+type record struct {
+	ID           int64     `json:"id,omitempty"`
+	Alias        string    `json:"alias"`
+	LongURL      string    `json:"long_url"`
+	CreatedAt    time.Time `json:"created_at"`
+	Disabled     bool      `json:"disabled"`
+	Domain       string    `json:"domain,omitempty"`
+	Interstitial bool      `json:"interstitial,omitempty"`
+}
+
+// Encoder построчно пишет ссылки в w.
+type Encoder struct {
+	format Format
+	w      *bufio.Writer
+	csv    *csv.Writer
+	header bool
+}
+
+func NewEncoder(w io.Writer, format Format) *Encoder {
+	bw := bufio.NewWriter(w)
+	e := &Encoder{
+		format: format,
+		w:      bw,
+	}
+	if format == FormatCSV {
+		e.csv = csv.NewWriter(bw)
+	}
+	return e
+}
+
+func (e *Encoder) Encode(u *model.URL) error {
+	if e.format == FormatCSV {
+		if !e.header {
+			if err := e.csv.Write(csvHeader); err != nil {
+				return err
+			}
+			e.header = true
+		}
+		return e.csv.Write([]string{
+			strconv.FormatInt(u.ID, 10),
+			u.Alias,
+			u.LongURL,
+			u.CreatedAt.UTC().Format(time.RFC3339Nano),
+			strconv.FormatBool(u.Disabled),
+			u.Domain,
+			strconv.FormatBool(u.Interstitial),
+		})
+	}
+
+	b, err := json.Marshal(record{
+		ID:           u.ID,
+		Alias:        u.Alias,
+		LongURL:      u.LongURL,
+		CreatedAt:    u.CreatedAt.UTC(),
+		Disabled:     u.Disabled,
+		Domain:       u.Domain,
+		Interstitial: u.Interstitial,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := e.w.Write(b); err != nil {
+		return err
+	}
+	return e.w.WriteByte('\n')
+}
+
+// Flush дописывает буферизованные данные. Для CSV без записей пишет только заголовок.
+func (e *Encoder) Flush() error {
+	if e.format == FormatCSV {
+		if !e.header {
+			if err := e.csv.Write(csvHeader); err != nil {
+				return err
+			}
+			e.header = true
+		}
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	return e.w.Flush()
+}
+
+// Decoder построчно читает ссылки из r.
+type Decoder struct {
+	format Format
+	line   int
+
+	scanner *bufio.Scanner
+	csv     *csv.Reader
+	columns map[string]int
+}
+
+const maxLineSize = 1 << 20
+
+func NewDecoder(r io.Reader, format Format) *Decoder {
+	d := &Decoder{format: format}
+	if format == FormatCSV {
+		d.csv = csv.NewReader(r)
+		d.csv.FieldsPerRecord = -1
+	} else {
+		d.scanner = bufio.NewScanner(r)
+		d.scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	}
+	return d
+}
+
+// Line возвращает номер последней прочитанной строки, начиная с 1.
+func (d *Decoder) Line() int {
+	return d.line
+}
+
+// Decode возвращает следующую ссылку или io.EOF в конце потока. Ошибка разбора
+// одной строки не мешает читать следующие.
+func (d *Decoder) Decode() (*model.URL, error) {
+	if d.format == FormatCSV {
+		return d.decodeCSV()
+	}
+	return d.decodeJSONL()
+}
+
+func (d *Decoder) decodeJSONL() (*model.URL, error) {
+	for d.scanner.Scan() {
+		d.line++
+
+		line := strings.TrimSpace(d.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var rec record
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w: %v", d.line, ErrInvalidRecord, err)
+		}
+		return &model.URL{
+			Domain:       rec.Domain,
+			Alias:        rec.Alias,
+			LongURL:      rec.LongURL,
+			CreatedAt:    rec.CreatedAt,
+			Disabled:     rec.Disabled,
+			Interstitial: rec.Interstitial,
+		}, nil
+	}
+	if err := d.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (d *Decoder) decodeCSV() (*model.URL, error) {
+	if d.columns == nil {
+		header, err := d.csv.Read()
+		if err != nil {
+			return nil, err
+		}
+		d.line++
+
+		d.columns = make(map[string]int, len(header))
+		for i, name := range header {
+			d.columns[strings.TrimSpace(strings.ToLower(name))] = i
+		}
+		for _, required := range []string{"alias", "long_url"} {
+			if _, ok := d.columns[required]; !ok {
+				return nil, fmt.Errorf("line %d: missing column %q", d.line, required)
+			}
+		}
+	}
+
+	row, err := d.csv.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			d.line = parseErr.Line
+			return nil, fmt.Errorf("line %d: %w: %v", d.line, ErrInvalidRecord, parseErr.Err)
+		}
+		return nil, err
+	}
+	d.line, _ = d.csv.FieldPos(0)
+
+	field := func(name string) string {
+		i, ok := d.columns[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	u := &model.URL{
+		Domain:  field("domain"),
+		Alias:   field("alias"),
+		LongURL: field("long_url"),
+	}
+	if v := field("created_at"); v != "" {
+		u.CreatedAt, err = time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w: created_at: %v", d.line, ErrInvalidRecord, err)
+		}
+	}
+	if v := field("disabled"); v != "" {
+		u.Disabled, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w: disabled: %v", d.line, ErrInvalidRecord, err)
+		}
+	}
+	if v := field("interstitial"); v != "" {
+		u.Interstitial, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w: interstitial: %v", d.line, ErrInvalidRecord, err)
+		}
+	}
+
+	return u, nil
+}
@@ -0,0 +1,127 @@
+package linkio
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat(" CSV ")
+	require.NoError(t, err)
+	require.Equal(t, FormatCSV, f)
+
+	_, err = ParseFormat("xml")
+	require.Error(t, err)
+}
+
+func TestRoundTrip(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 30, 0, 123, time.UTC)
+	urls := []*model.URL{
+		{ID: 1, Alias: "aa", LongURL: "https://example.com/?a=1,b=2", CreatedAt: created},
+		{ID: 2, Alias: "bb", LongURL: `https://example.com/"quoted"`, CreatedAt: created, Disabled: true},
+		{ID: 3, Domain: "brand.link", Alias: "aa", LongURL: "https://brand.com", CreatedAt: created, Interstitial: true},
+	}
+
+	for _, format := range []Format{FormatJSONL, FormatCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := NewEncoder(&buf, format)
+			for _, u := range urls {
+				require.NoError(t, enc.Encode(u))
+			}
+			require.NoError(t, enc.Flush())
+
+			dec := NewDecoder(&buf, format)
+			for _, want := range urls {
+				got, err := dec.Decode()
+				require.NoError(t, err)
+				require.Zero(t, got.ID, "id must not be imported")
+				require.Equal(t, want.Alias, got.Alias)
+				require.Equal(t, want.LongURL, got.LongURL)
+				require.True(t, want.CreatedAt.Equal(got.CreatedAt))
+				require.Equal(t, want.Disabled, got.Disabled)
+				require.Equal(t, want.Domain, got.Domain)
+				require.Equal(t, want.Interstitial, got.Interstitial)
+			}
+
+			_, err := dec.Decode()
+			require.ErrorIs(t, err, io.EOF)
+		})
+	}
+}
+
+func TestDecoder_JSONLInvalidLine(t *testing.T) {
+	in := `{"alias":"aa","long_url":"https://a.com"}
+
+not json
+{"alias":"bb","long_url":"https://b.com"}
+`
+	dec := NewDecoder(strings.NewReader(in), FormatJSONL)
+
+	u, err := dec.Decode()
+	require.NoError(t, err)
+	require.Equal(t, "aa", u.Alias)
+	require.Equal(t, 1, dec.Line())
+
+	_, err = dec.Decode()
+	require.ErrorIs(t, err, ErrInvalidRecord)
+	require.Equal(t, 3, dec.Line())
+
+	u, err = dec.Decode()
+	require.NoError(t, err)
+	require.Equal(t, "bb", u.Alias)
+}
+
+func TestDecoder_CSVColumns(t *testing.T) {
+	in := "long_url,alias\nhttps://a.com,aa\nhttps://b.com,bb\n"
+	dec := NewDecoder(strings.NewReader(in), FormatCSV)
+
+	u, err := dec.Decode()
+	require.NoError(t, err)
+	require.Equal(t, "aa", u.Alias)
+	require.Equal(t, "https://a.com", u.LongURL)
+	require.True(t, u.CreatedAt.IsZero())
+	require.Equal(t, 2, dec.Line())
+
+	u, err = dec.Decode()
+	require.NoError(t, err)
+	require.Equal(t, "bb", u.Alias)
+	require.Equal(t, 3, dec.Line())
+
+	_, err = dec.Decode()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestDecoder_CSVMissingColumn(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("alias\naa\n"), FormatCSV)
+
+	_, err := dec.Decode()
+	require.Error(t, err)
+}
+
+func TestEncoder_CSVEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf, FormatCSV)
+	require.NoError(t, enc.Flush())
+	require.Equal(t, "id,alias,long_url,created_at,disabled,domain,interstitial\n", buf.String())
+}
+
+func TestDecoder_CSVInvalidRow(t *testing.T) {
+	in := "alias,long_url,disabled\naa,https://a.com,maybe\nbb,https://b.com,true\n"
+	dec := NewDecoder(strings.NewReader(in), FormatCSV)
+
+	_, err := dec.Decode()
+	require.ErrorIs(t, err, ErrInvalidRecord)
+	require.Equal(t, 2, dec.Line())
+
+	u, err := dec.Decode()
+	require.NoError(t, err)
+	require.Equal(t, "bb", u.Alias)
+	require.True(t, u.Disabled)
+}
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"time"
 
@@ -11,6 +12,8 @@ import (
 
 type Config struct {
 	Level string
+	// Output — куда писать логи, по умолчанию os.Stdout.
+	Output io.Writer
 }
 
 func Init(cfg Config) error {
@@ -23,7 +26,12 @@ func Init(cfg Config) error {
 
 	zerolog.TimeFieldFormat = time.RFC3339
 
-	log.Logger = zerolog.New(os.Stdout).With().Timestamp().Logger()
+	out := cfg.Output
+	if out == nil {
+		out = os.Stdout
+	}
+
+	log.Logger = zerolog.New(out).With().Timestamp().Logger()
 
 	return nil
 }
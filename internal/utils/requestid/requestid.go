@@ -0,0 +1,50 @@
+// Package requestid передаёт идентификатор запроса через контекст. Идентификатор
+// приходит от клиента в заголовке X-Request-ID или создаётся заново и возвращается
+// в ответе, чтобы запрос можно было найти в логах.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Header — заголовок HTTP с идентификатором; в gRPC тот же ключ в нижнем регистре.
+const Header = "X-Request-ID"
+
+// maxLen ограничивает идентификатор клиента, чтобы он не раздувал логи.
+const maxLen = 128
+
+type ctxKey struct{}
+
+// New возвращает случайный идентификатор из 32 hex-символов.
+func New() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// FromClient возвращает идентификатор клиента, если он допустим, иначе новый.
+// Допустимы до 128 печатных ASCII-символов.
+func FromClient(id string) string {
+	if id == "" || len(id) > maxLen {
+		return New()
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return New()
+		}
+	}
+	return id
+}
+
+// With возвращает контекст с идентификатором id.
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext возвращает идентификатор запроса или пустую строку.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
@@ -0,0 +1,41 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromClient(t *testing.T) {
+	cases := []struct {
+		name string
+		id   string
+		keep bool
+	}{
+		{"valid", "req-42", true},
+		{"empty", "", false},
+		{"too long", strings.Repeat("a", maxLen+1), false},
+		{"space", "req 42", false},
+		{"non ascii", "запрос", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := FromClient(tc.id)
+			if tc.keep {
+				assert.Equal(t, tc.id, got)
+				return
+			}
+			assert.NotEqual(t, tc.id, got)
+			assert.Len(t, got, 32)
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	assert.Empty(t, FromContext(context.Background()))
+	assert.Equal(t, "req-42", FromContext(With(context.Background(), "req-42")))
+	assert.NotEqual(t, New(), New())
+}
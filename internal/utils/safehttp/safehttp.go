@@ -0,0 +1,78 @@
+// Package safehttp создаёт HTTP-транспорт для запросов по адресам, которые задают
+// пользователи: соединения с внутренней сетью сервера запрещены.
+package safehttp
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress — адрес указывает во внутреннюю сеть.
+var ErrForbiddenAddress = errors.New("forbidden address")
+
+// blocked — диапазоны, которые не отсекаются проверками netip.Addr, но не должны быть доступны.
+var blocked = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+}
+
+// Transport возвращает транспорт с таймаутами timeout на подключение и ожидание ответа.
+// Адрес проверяется при установке соединения, поэтому проверку не обойти ни редиректом,
+// ни DNS-записью, указывающей во внутреннюю сеть. allowPrivate отключает проверку,
+// например для тестов с httptest.
+func Transport(timeout time.Duration, allowPrivate bool) *http.Transport {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = control
+	}
+
+	return &http.Transport{
+		// Прокси из окружения не используется: он обошёл бы проверку адреса.
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+	}
+}
+
+// control отклоняет соединение с адресом, который не является публичным.
+func control(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !Public(ip) {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
+
+// Public сообщает, можно ли подключаться к ip: адрес глобальный и не из частных диапазонов.
+func Public(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range blocked {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
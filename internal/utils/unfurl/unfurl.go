@@ -0,0 +1,251 @@
+// Package unfurl загружает страницу назначения ссылки и извлекает из неё
+// заголовок, описание, картинку OpenGraph и favicon.
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/Rasulikus/url-shortener/internal/utils/safehttp"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+var (
+	// ErrForbiddenAddress — адрес страницы указывает во внутреннюю сеть.
+	ErrForbiddenAddress = safehttp.ErrForbiddenAddress
+	// ErrUnsupportedScheme — ссылка не http(s).
+	ErrUnsupportedScheme = errors.New("unfurl: unsupported scheme")
+)
+
+const (
+	// DefaultUserAgent — User-Agent запросов, если в Config он не задан.
+	DefaultUserAgent = "url-shortener-unfurl/1.0"
+
+	maxRedirects      = 5
+	maxTitleLen       = 300
+	maxDescriptionLen = 1000
+)
+
+type Config struct {
+	// Timeout ограничивает запрос целиком, включая редиректы и чтение тела.
+	Timeout time.Duration
+	// MaxBytes — сколько байт страницы читается, остальное отбрасывается.
+	MaxBytes int64
+	// AllowPrivate разрешает адреса внутренней сети, например для тестов с httptest.
+	AllowPrivate bool
+	UserAgent    string
+}
+
+type Fetcher struct {
+	client    *http.Client
+	maxBytes  int64
+	userAgent string
+}
+
+// New создаёт Fetcher. Адреса внутренней сети запрещены, если не задан AllowPrivate.
+func New(cfg Config) *Fetcher {
+	userAgent := cfg.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+
+	return &Fetcher{
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: safehttp.Transport(cfg.Timeout, cfg.AllowPrivate),
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return fmt.Errorf("unfurl: stopped after %d redirects", maxRedirects)
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return ErrUnsupportedScheme
+				}
+				return nil
+			},
+		},
+		maxBytes:  cfg.MaxBytes,
+		userAgent: userAgent,
+	}
+}
+
+// Fetch загружает страницу rawURL и возвращает её метаданные.
+// Для ответа не в HTML возвращаются пустые метаданные без ошибки.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*model.Metadata, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("unfurl: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, ErrUnsupportedScheme
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unfurl: %w", err)
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.1")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrForbiddenAddress) {
+			return nil, ErrForbiddenAddress
+		}
+		if errors.Is(err, ErrUnsupportedScheme) {
+			return nil, ErrUnsupportedScheme
+		}
+		return nil, fmt.Errorf("unfurl: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unfurl: unexpected status %d", resp.StatusCode)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if mt, _, _ := mime.ParseMediaType(contentType); mt != "text/html" && mt != "application/xhtml+xml" {
+		return &model.Metadata{}, nil
+	}
+
+	body, err := charset.NewReader(io.LimitReader(resp.Body, f.maxBytes), contentType)
+	if err != nil {
+		return nil, fmt.Errorf("unfurl: %w", err)
+	}
+
+	meta, err := parse(body, resp.Request.URL)
+	if err != nil {
+		return nil, fmt.Errorf("unfurl: %w", err)
+	}
+	return meta, nil
+}
+
+// parse читает <head> страницы. Значения OpenGraph предпочтительнее <title> и
+// meta description. Чтение останавливается на </head> или <body>.
+func parse(r io.Reader, base *url.URL) (*model.Metadata, error) {
+	var (
+		z              = html.NewTokenizer(r)
+		meta           model.Metadata
+		title, ogTitle string
+		desc, ogDesc   string
+		image, favicon string
+		inTitle        bool
+	)
+
+loop:
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			break loop
+		case html.TextToken:
+			if inTitle {
+				title += string(z.Text())
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				break loop
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			attrs := make(map[string]string)
+			for hasAttr {
+				var k, v []byte
+				k, v, hasAttr = z.TagAttr()
+				attrs[string(k)] = string(v)
+			}
+
+			switch string(name) {
+			case "body":
+				break loop
+			case "title":
+				// Берём первый заголовок: <title> внутри <svg> в <head> не нужен.
+				inTitle = title == "" && tt == html.StartTagToken
+			case "meta":
+				content := attrs["content"]
+				switch strings.ToLower(attrs["property"]) {
+				case "og:title":
+					ogTitle = content
+				case "og:description":
+					ogDesc = content
+				case "og:image", "og:image:url":
+					if image == "" {
+						image = content
+					}
+				}
+				if strings.EqualFold(attrs["name"], "description") {
+					desc = content
+				}
+			case "link":
+				if favicon == "" && isIcon(attrs["rel"]) {
+					favicon = attrs["href"]
+				}
+			}
+		}
+	}
+
+	meta.Title = clean(first(ogTitle, title), maxTitleLen)
+	meta.Description = clean(first(ogDesc, desc), maxDescriptionLen)
+	meta.ImageURL = resolve(base, image)
+	meta.FaviconURL = resolve(base, favicon)
+	if meta.FaviconURL == "" {
+		meta.FaviconURL = resolve(base, "/favicon.ico")
+	}
+
+	return &meta, nil
+}
+
+func isIcon(rel string) bool {
+	for _, r := range strings.Fields(strings.ToLower(rel)) {
+		if r == "icon" {
+			return true
+		}
+	}
+	return false
+}
+
+func first(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// clean схлопывает пробелы и обрезает строку до n символов.
+func clean(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		s = strings.TrimSpace(string(r[:n-1])) + "…"
+	}
+	return s
+}
+
+// resolve приводит ссылку со страницы к абсолютному http(s) URL, иначе возвращает пустую строку.
+func resolve(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.String()
+}
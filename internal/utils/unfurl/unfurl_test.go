@@ -0,0 +1,197 @@
+package unfurl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/url-shortener/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() Config {
+	return Config{
+		Timeout:      time.Second,
+		MaxBytes:     1 << 20,
+		AllowPrivate: true,
+	}
+}
+
+func serve(t *testing.T, contentType, body string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFetcher_Fetch(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		want        func(base string) *model.Metadata
+	}{
+		{
+			name:        "opengraph",
+			contentType: "text/html; charset=utf-8",
+			body: `<!doctype html><html><head>
+				<title>Plain title</title>
+				<meta property="og:title" content="OG   title">
+				<meta property="og:description" content="OG description">
+				<meta name="description" content="Plain description">
+				<meta property="og:image" content="/img/cover.png">
+				<link rel="shortcut icon" href="/static/icon.png">
+				</head><body><title>ignored</title></body></html>`,
+			want: func(base string) *model.Metadata {
+				return &model.Metadata{
+					Title:       "OG title",
+					Description: "OG description",
+					ImageURL:    base + "/img/cover.png",
+					FaviconURL:  base + "/static/icon.png",
+				}
+			},
+		},
+		{
+			name:        "plain tags",
+			contentType: "text/html",
+			body: `<html><head><title>
+				Plain
+				title</title><meta name="Description" content="Plain description"></head></html>`,
+			want: func(base string) *model.Metadata {
+				return &model.Metadata{
+					Title:       "Plain title",
+					Description: "Plain description",
+					FaviconURL:  base + "/favicon.ico",
+				}
+			},
+		},
+		{
+			name:        "charset from meta",
+			contentType: "text/html",
+			body:        "<html><head><meta charset=\"windows-1251\"><title>\xcf\xf0\xe8\xe2\xe5\xf2</title></head></html>",
+			want: func(base string) *model.Metadata {
+				return &model.Metadata{Title: "Привет", FaviconURL: base + "/favicon.ico"}
+			},
+		},
+		{
+			name:        "unsafe image scheme",
+			contentType: "text/html",
+			body:        `<head><meta property="og:image" content="javascript:alert(1)"><link rel="icon" href="data:image/png;base64,AAAA"></head>`,
+			want: func(base string) *model.Metadata {
+				return &model.Metadata{FaviconURL: base + "/favicon.ico"}
+			},
+		},
+		{
+			name:        "not html",
+			contentType: "image/png",
+			body:        "<title>not a page</title>",
+			want: func(string) *model.Metadata {
+				return &model.Metadata{}
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := serve(t, tc.contentType, tc.body)
+
+			got, err := New(testConfig()).Fetch(context.Background(), srv.URL+"/page")
+			require.NoError(t, err)
+			assert.Equal(t, tc.want(srv.URL), got)
+		})
+	}
+}
+
+func TestFetcher_Fetch_Redirect(t *testing.T) {
+	srv := serve(t, "text/html", "<title>Target</title>")
+
+	redirect := httptest.NewServer(http.RedirectHandler(srv.URL+"/dir/page", http.StatusFound))
+	t.Cleanup(redirect.Close)
+
+	got, err := New(testConfig()).Fetch(context.Background(), redirect.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "Target", got.Title)
+	assert.Equal(t, srv.URL+"/favicon.ico", got.FaviconURL, "relative links resolve against the final url")
+}
+
+func TestFetcher_Fetch_MaxBytes(t *testing.T) {
+	body := "<html><head>" + strings.Repeat("<meta name=x>", 1000) + "<title>Too far</title></head>"
+	srv := serve(t, "text/html", body)
+
+	cfg := testConfig()
+	cfg.MaxBytes = 1024
+	got, err := New(cfg).Fetch(context.Background(), srv.URL)
+	require.NoError(t, err)
+	assert.Empty(t, got.Title)
+}
+
+func TestFetcher_Fetch_TruncatesTitle(t *testing.T) {
+	srv := serve(t, "text/html", "<title>"+strings.Repeat("я", maxTitleLen+10)+"</title>")
+
+	got, err := New(testConfig()).Fetch(context.Background(), srv.URL)
+	require.NoError(t, err)
+	assert.Len(t, []rune(got.Title), maxTitleLen)
+	assert.True(t, strings.HasSuffix(got.Title, "…"))
+}
+
+func TestFetcher_Fetch_Errors(t *testing.T) {
+	t.Run("forbidden address", func(t *testing.T) {
+		srv := serve(t, "text/html", "<title>secret</title>")
+
+		cfg := testConfig()
+		cfg.AllowPrivate = false
+		_, err := New(cfg).Fetch(context.Background(), srv.URL)
+		require.ErrorIs(t, err, ErrForbiddenAddress)
+	})
+
+	t.Run("unsupported scheme", func(t *testing.T) {
+		_, err := New(testConfig()).Fetch(context.Background(), "ftp://example.com/file")
+		require.ErrorIs(t, err, ErrUnsupportedScheme)
+	})
+
+	t.Run("status", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		t.Cleanup(srv.Close)
+
+		_, err := New(testConfig()).Fetch(context.Background(), srv.URL)
+		require.ErrorContains(t, err, "unexpected status 404")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		done := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-done:
+			case <-r.Context().Done():
+			}
+		}))
+		t.Cleanup(srv.Close)
+		t.Cleanup(func() { close(done) })
+
+		cfg := testConfig()
+		cfg.Timeout = 50 * time.Millisecond
+		start := time.Now()
+		_, err := New(cfg).Fetch(context.Background(), srv.URL)
+		require.Error(t, err)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("too many redirects", func(t *testing.T) {
+		var srv *httptest.Server
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, srv.URL+r.URL.Path+"x", http.StatusFound)
+		}))
+		t.Cleanup(srv.Close)
+
+		_, err := New(testConfig()).Fetch(context.Background(), srv.URL+"/")
+		require.ErrorContains(t, err, "redirects")
+	})
+}
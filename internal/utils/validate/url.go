@@ -2,16 +2,126 @@ package validate
 
 import (
 	"errors"
+	"fmt"
 	"net/url"
+	"strings"
+
+	"github.com/Rasulikus/url-shortener/internal/apperr"
+)
+
+var (
+	ErrInvalidURL   = errors.New("invalid url")
+	ErrInvalidAlias = errors.New("invalid alias")
 )
 
-var ErrInvalidURL = errors.New("invalid url")
+// MaxAliasLength — максимальная длина алиаса, в том числе импортированного.
+const MaxAliasLength = 64
 
+// URL проверяет, что u — URL запроса, обычно абсолютный со схемой. Ошибка —
+// *apperr.Error, которая оборачивает ErrInvalidURL; её код отличает URL без схемы
+// от пустого или искажённого.
 func URL(u string) error {
-	_, err := url.ParseRequestURI(u)
+	if strings.TrimSpace(u) == "" {
+		return urlError(apperr.CodeInvalidURL, "url is empty", nil)
+	}
+
+	if _, err := url.ParseRequestURI(u); err != nil {
+		if !hasScheme(u) {
+			return urlError(apperr.CodeInvalidURLScheme, "url must start with a scheme such as https://", nil)
+		}
+
+		reason := err.Error()
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			reason = uerr.Err.Error()
+		}
+		return urlError(apperr.CodeInvalidURL, "url is malformed", map[string]any{"reason": reason})
+	}
+
+	return nil
+}
+
+func urlError(code apperr.Code, msg string, details map[string]any) error {
+	return &apperr.Error{Code: code, Message: msg, Details: details, Err: ErrInvalidURL}
+}
+
+// hasScheme сообщает, что u начинается со схемы по RFC 3986: буква, затем буквы,
+// цифры, '+', '-' или '.', и двоеточие.
+func hasScheme(u string) bool {
+	i := strings.IndexByte(u, ':')
+	if i <= 0 {
+		return false
+	}
+
+	for j := 0; j < i; j++ {
+		c := u[j]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case j > 0 && (c >= '0' && c <= '9' || c == '+' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// CanonicalURL проверяет URL и приводит его к каноническому виду: схема и хост
+// в нижнем регистре, без порта по умолчанию и без пустого фрагмента. Одинаковые
+// по смыслу URL после этого дедуплицируются как один long_url.
+func CanonicalURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if err := URL(raw); err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(raw)
 	if err != nil {
-		return ErrInvalidURL
+		return "", urlError(apperr.CodeInvalidURL, "url is malformed", nil)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+
+	switch {
+	case u.Scheme == "http" && u.Port() == "80",
+		u.Scheme == "https" && u.Port() == "443":
+		// Hostname снимает скобки с IPv6-адреса, поэтому отрезаем только порт
+		u.Host = strings.TrimSuffix(u.Host, ":"+u.Port())
+	}
+
+	u.ForceQuery = false
+	if u.Fragment == "" {
+		u.RawFragment = ""
+	}
+
+	return u.String(), nil
+}
+
+// Alias проверяет, что алиас непустой, не длиннее MaxAliasLength и состоит из
+// латинских букв, цифр, '_' и '-'. Ошибка — *apperr.Error, которая оборачивает ErrInvalidAlias.
+func Alias(a string) error {
+	if a == "" || len(a) > MaxAliasLength {
+		return aliasError()
+	}
+
+	for i := 0; i < len(a); i++ {
+		c := a[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_', c == '-':
+		default:
+			return aliasError()
+		}
 	}
 
 	return nil
 }
+
+func aliasError() error {
+	return &apperr.Error{
+		Code:    apperr.CodeInvalidAlias,
+		Message: fmt.Sprintf("alias must be 1 to %d latin letters, digits, '_' or '-'", MaxAliasLength),
+		Details: map[string]any{"max_length": MaxAliasLength},
+		Err:     ErrInvalidAlias,
+	}
+}
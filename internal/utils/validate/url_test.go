@@ -1,35 +1,110 @@
 package validate
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/Rasulikus/url-shortener/internal/apperr"
 	"github.com/stretchr/testify/require"
 )
 
 func TestValidate_URL(t *testing.T) {
+	cases := []struct {
+		name     string
+		in       string
+		wantCode apperr.Code
+	}{
+		{"success http", "http://example.com", ""},
+		{"success https", "https://example.com/path?Q=1", ""},
+		{"success ssh", "ssh://example.com", ""},
+
+		{"empty", "", apperr.CodeInvalidURL},
+		{"spaces", "   ", apperr.CodeInvalidURL},
+		{"no scheme", "example.com", apperr.CodeInvalidURLScheme},
+		{"bad scheme", "ht!tp://example.com", apperr.CodeInvalidURLScheme},
+		{"control character", "https://exa\nmple.com", apperr.CodeInvalidURL},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := URL(tc.in)
+			if tc.wantCode == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, ErrInvalidURL)
+
+			e, ok := apperr.As(err)
+			require.True(t, ok)
+			require.Equal(t, tc.wantCode, e.Code)
+			require.NotEmpty(t, e.Message)
+		})
+	}
+}
+
+func TestValidate_CanonicalURL(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr error
+	}{
+		{"unchanged", "http://example.com", "http://example.com", nil},
+		{"trim spaces", "  https://example.com/path  ", "https://example.com/path", nil},
+		{"lower scheme and host", "HTTPS://Example.COM/Path", "https://example.com/Path", nil},
+		{"default http port", "http://example.com:80/a", "http://example.com/a", nil},
+		{"default https port", "https://example.com:443/a", "https://example.com/a", nil},
+		{"custom port", "https://example.com:8443/a", "https://example.com:8443/a", nil},
+		{"ipv6 default port", "http://[::1]:80/x", "http://[::1]/x", nil},
+		{"ipv6 custom port", "https://[2001:DB8::1]:8443/x", "https://[2001:db8::1]:8443/x", nil},
+		{"ipv6 no port", "https://[::1]/x", "https://[::1]/x", nil},
+		{"empty query", "https://example.com/a?", "https://example.com/a", nil},
+		{"keep query", "https://example.com/a?Q=1", "https://example.com/a?Q=1", nil},
+
+		{"failed", "example.com", "", ErrInvalidURL},
+		{"failed", "", "", ErrInvalidURL},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := CanonicalURL(tc.in)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestValidate_Alias(t *testing.T) {
 	cases := []struct {
 		name string
 		in   string
 		want error
 	}{
-		{"success http", "http://example.com", nil},
-		{"success https", "https://example.com/path?Q=1", nil},
-		{"success ssh", "ssh://example.com", nil},
-
-		{"failed", "", ErrInvalidURL},
-		{"failed", "   ", ErrInvalidURL},
-		{"failed", "example.com", ErrInvalidURL},
-		{"failed", "ht!tp://example.com", ErrInvalidURL},
+		{"success", "aZ09_-", nil},
+
+		{"empty", "", ErrInvalidAlias},
+		{"too long", strings.Repeat("a", MaxAliasLength+1), ErrInvalidAlias},
+		{"slash", "a/b", ErrInvalidAlias},
+		{"space", "a b", ErrInvalidAlias},
+		{"unicode", "алиас", ErrInvalidAlias},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			err := URL(tc.in)
+			err := Alias(tc.in)
 			if tc.want == nil {
 				require.NoError(t, err)
-			} else {
-				require.ErrorIs(t, tc.want, err)
+				return
 			}
+			require.ErrorIs(t, err, tc.want)
+
+			e, ok := apperr.As(err)
+			require.True(t, ok)
+			require.Equal(t, apperr.CodeInvalidAlias, e.Code)
 		})
 	}
 }
@@ -0,0 +1,10 @@
+-- Откат возможен, только если нет алиасов длиннее 10 символов: иначе сократить столбец нельзя.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM urls WHERE length(alias) > 10) THEN
+        RAISE EXCEPTION 'cannot roll back 0002_alias_length: urls has aliases longer than 10 characters';
+    END IF;
+END
+$$;
+
+ALTER TABLE urls ALTER COLUMN alias TYPE VARCHAR(10);
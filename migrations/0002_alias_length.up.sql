@@ -0,0 +1 @@
+ALTER TABLE urls ALTER COLUMN alias TYPE TEXT;
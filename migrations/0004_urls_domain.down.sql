@@ -0,0 +1,7 @@
+-- Откат возможен, только если алиасы и длинные URL не повторяются между доменами.
+ALTER TABLE urls DROP CONSTRAINT IF EXISTS urls_domain_alias_key;
+ALTER TABLE urls DROP CONSTRAINT IF EXISTS urls_domain_long_url_key;
+ALTER TABLE urls ADD CONSTRAINT urls_alias_key UNIQUE (alias);
+ALTER TABLE urls ADD CONSTRAINT urls_long_url_key UNIQUE (long_url);
+
+ALTER TABLE urls DROP COLUMN IF EXISTS domain;
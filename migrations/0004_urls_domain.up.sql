@@ -0,0 +1,7 @@
+-- Алиасы уникальны в пределах домена, пустой домен — домен по умолчанию из BASE_URL.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS domain TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE urls DROP CONSTRAINT IF EXISTS urls_alias_key;
+ALTER TABLE urls DROP CONSTRAINT IF EXISTS urls_long_url_key;
+ALTER TABLE urls ADD CONSTRAINT urls_domain_alias_key UNIQUE (domain, alias);
+ALTER TABLE urls ADD CONSTRAINT urls_domain_long_url_key UNIQUE (domain, long_url);
@@ -0,0 +1,2 @@
+-- Метаданные страницы назначения, NULL — ещё не загружались.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS metadata JSONB;
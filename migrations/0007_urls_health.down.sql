@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS urls_broken_idx;
+ALTER TABLE urls DROP COLUMN IF EXISTS broken;
+ALTER TABLE urls DROP COLUMN IF EXISTS health;
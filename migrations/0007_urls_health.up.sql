@@ -0,0 +1,5 @@
+-- Результат последней проверки доступности страницы назначения, NULL — ещё не проверялась.
+-- broken дублирует health.broken, чтобы выбирать неработающие ссылки по индексу.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS health JSONB;
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS broken BOOLEAN NOT NULL DEFAULT false;
+CREATE INDEX IF NOT EXISTS urls_broken_idx ON urls (id) WHERE broken;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_outbox;
+ALTER TABLE urls DROP COLUMN IF EXISTS clicks;
@@ -0,0 +1,22 @@
+-- Число переходов по ссылке, считается для событий url.clicks.
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS clicks BIGINT NOT NULL DEFAULT 0;
+
+-- События ссылок, записанные той же транзакцией, что и изменение. Воркер вебхуков
+-- раскладывает их по подпискам в webhook_deliveries и удаляет.
+CREATE TABLE IF NOT EXISTS webhook_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event JSONB NOT NULL
+);
+
+-- Доставки событий подписчикам. Успешные удаляются, исчерпавшие попытки остаются со статусом dead.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    webhook TEXT NOT NULL,
+    event JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,14 @@
+// Package migrations содержит SQL-миграции схемы Postgres и SQLite.
+package migrations
+
+import "embed"
+
+// FS — встроенные в бинарник файлы миграций Postgres вида NNNN_name.up.sql / NNNN_name.down.sql.
+//
+//go:embed *.sql
+var FS embed.FS
+
+// SQLiteFS — миграции SQLite в каталоге sqlite/ с тем же форматом имён.
+//
+//go:embed sqlite/*.sql
+var SQLiteFS embed.FS
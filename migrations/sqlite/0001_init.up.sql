@@ -0,0 +1,9 @@
+-- Схема SQLite соответствует схеме Postgres после всех миграций из migrations/.
+-- AUTOINCREMENT ведёт счётчик в sqlite_sequence, который играет роль urls_id_seq.
+CREATE TABLE IF NOT EXISTS urls (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    long_url TEXT NOT NULL UNIQUE,
+    alias TEXT NOT NULL UNIQUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    disabled BOOLEAN NOT NULL DEFAULT 0
+);
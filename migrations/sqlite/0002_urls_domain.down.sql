@@ -0,0 +1,17 @@
+CREATE TABLE urls_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    long_url TEXT NOT NULL UNIQUE,
+    alias TEXT NOT NULL UNIQUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    disabled BOOLEAN NOT NULL DEFAULT 0
+);
+
+INSERT INTO urls_old (id, long_url, alias, created_at, disabled)
+SELECT id, long_url, alias, created_at, disabled FROM urls;
+
+DELETE FROM sqlite_sequence WHERE name = 'urls_old';
+INSERT INTO sqlite_sequence (name, seq)
+SELECT 'urls_old', seq FROM sqlite_sequence WHERE name = 'urls';
+
+DROP TABLE urls;
+ALTER TABLE urls_old RENAME TO urls;
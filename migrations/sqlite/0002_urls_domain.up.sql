@@ -0,0 +1,23 @@
+-- SQLite не умеет менять ограничения UNIQUE, поэтому таблица пересоздаётся:
+-- алиас и длинный URL теперь уникальны в пределах домена.
+CREATE TABLE urls_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    domain TEXT NOT NULL DEFAULT '',
+    long_url TEXT NOT NULL,
+    alias TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    disabled BOOLEAN NOT NULL DEFAULT 0,
+    UNIQUE (domain, alias),
+    UNIQUE (domain, long_url)
+);
+
+INSERT INTO urls_new (id, long_url, alias, created_at, disabled)
+SELECT id, long_url, alias, created_at, disabled FROM urls;
+
+-- Счётчик ID переносится вместе с зарезервированными через LeaseIDs значениями
+DELETE FROM sqlite_sequence WHERE name = 'urls_new';
+INSERT INTO sqlite_sequence (name, seq)
+SELECT 'urls_new', seq FROM sqlite_sequence WHERE name = 'urls';
+
+DROP TABLE urls;
+ALTER TABLE urls_new RENAME TO urls;
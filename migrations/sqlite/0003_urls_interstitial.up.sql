@@ -0,0 +1 @@
+ALTER TABLE urls ADD COLUMN interstitial BOOLEAN NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- Метаданные страницы назначения в JSON, NULL — ещё не загружались.
+ALTER TABLE urls ADD COLUMN metadata TEXT;
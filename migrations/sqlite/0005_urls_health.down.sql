@@ -0,0 +1,3 @@
+DROP INDEX urls_broken_idx;
+ALTER TABLE urls DROP COLUMN broken;
+ALTER TABLE urls DROP COLUMN health;
@@ -0,0 +1,5 @@
+-- Результат последней проверки доступности страницы назначения в JSON, NULL — ещё не проверялась.
+-- broken дублирует health.broken, чтобы выбирать неработающие ссылки по индексу.
+ALTER TABLE urls ADD COLUMN health TEXT;
+ALTER TABLE urls ADD COLUMN broken BOOLEAN NOT NULL DEFAULT 0;
+CREATE INDEX urls_broken_idx ON urls (id) WHERE broken;
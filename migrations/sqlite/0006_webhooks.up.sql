@@ -0,0 +1,22 @@
+-- Число переходов по ссылке, считается для событий url.clicks.
+ALTER TABLE urls ADD COLUMN clicks INTEGER NOT NULL DEFAULT 0;
+
+-- События ссылок в JSON, записанные той же транзакцией, что и изменение.
+CREATE TABLE webhook_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event TEXT NOT NULL
+);
+
+-- Доставки событий подписчикам. next_attempt_at хранится в миллисекундах Unix,
+-- чтобы сравнение в запросах не зависело от формата даты.
+CREATE TABLE webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook TEXT NOT NULL,
+    event TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at INTEGER NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';